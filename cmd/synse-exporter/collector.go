@@ -0,0 +1,353 @@
+package main
+
+// collector.go collects readings and plugin health from Synse Server and
+// turns them into metrics.
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vapor-ware/synse-client-go/synse"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// Sources of scrape errors, used as the label of the scrape error counter.
+const (
+	sourceStatus       = "status"
+	sourceRead         = "read"
+	sourceStream       = "stream"
+	sourceScan         = "scan"
+	sourcePluginHealth = "plugin_health"
+)
+
+// collector collects the metrics of a Synse Server.
+type collector struct {
+	// connect creates the client used to collect the metrics. It is called
+	// until it succeeds, so that the exporter keeps serving while Synse
+	// Server is down.
+	connect func(ctx context.Context) (synse.Client, error)
+
+	// connMu guards client and registry, and serializes the connects.
+	connMu sync.Mutex
+
+	// client is the client used to collect the metrics, once connected.
+	client synse.Client
+
+	// registry holds the devices, for the labels of the readings.
+	registry *synse.DeviceRegistry
+
+	// tags holds the tag groups which select the devices to export.
+	tags []string
+
+	// labelTags holds the tag annotations which are exported as labels, e.g.
+	// `rack` for a `rack:1` tag.
+	labelTags []string
+
+	// streamRetry is the wait time before restarting a failed stream.
+	streamRetry time.Duration
+
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// streaming is set when the readings come from a stream instead of
+	// being read on each scrape.
+	streaming bool
+
+	// latest holds the latest streamed reading of each device reading type.
+	latest map[string]*scheme.Read
+
+	// errors counts the scrape errors by source.
+	errors map[string]int
+}
+
+// newCollector creates a collector for the devices selected by the tag
+// groups, with a client created by connect.
+func newCollector(connect func(ctx context.Context) (synse.Client, error), tags, labelTags []string) *collector {
+	return &collector{
+		connect:     connect,
+		tags:        tags,
+		labelTags:   labelTags,
+		streamRetry: time.Second,
+		latest:      map[string]*scheme.Read{},
+		errors:      map[string]int{},
+	}
+}
+
+// connected returns the client, connecting it first if it is not yet.
+func (c *collector) connected(ctx context.Context) (synse.Client, *synse.DeviceRegistry, error) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.client == nil {
+		client, err := c.connect(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.client = client
+		c.registry = synse.NewDeviceRegistry(client, &synse.RegistryOptions{
+			Scan: scheme.ScanOptions{Tags: c.tags},
+		})
+	}
+	return c.client, c.registry, nil
+}
+
+// disconnect closes a client which failed with a transport error, e.g. when
+// Synse Server restarted and closed a websocket connection, so that the next
+// scrape connects again. The client is only closed if it was not replaced in
+// the meantime.
+func (c *collector) disconnect(client synse.Client) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.client != client {
+		return
+	}
+	_ = c.client.Close()
+	c.client = nil
+	c.registry = nil
+}
+
+// close closes the client, if it is connected.
+func (c *collector) close() error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.client == nil {
+		return nil
+	}
+	return c.client.Close()
+}
+
+// stream streams readings until the context is done, keeping the latest
+// reading of each device reading type for the scrapes. A failed stream, or
+// a failure to connect, is retried after a wait.
+func (c *collector) stream(ctx context.Context) {
+	c.mu.Lock()
+	c.streaming = true
+	c.mu.Unlock()
+
+	for {
+		client, _, err := c.connected(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.countError(sourceStream)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.streamRetry):
+			}
+			continue
+		}
+
+		out := make(chan *scheme.Read)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for r := range out {
+				c.mu.Lock()
+				c.latest[r.Device+"/"+r.Type] = r
+				c.mu.Unlock()
+			}
+		}()
+
+		err = client.ReadStreamContext(ctx, scheme.ReadStreamOptions{Tags: c.tags}, out, nil)
+		close(out)
+		<-done
+
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.countError(sourceStream)
+			if synse.IsTransport(err) {
+				c.disconnect(client)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.streamRetry):
+		}
+	}
+}
+
+// collect collects the metrics and writes them to w. A failure to collect a
+// part of the metrics is counted as a scrape error, and the other metrics
+// are still written.
+func (c *collector) collect(ctx context.Context, w io.Writer) error {
+	start := time.Now()
+	m := newMetricSet()
+
+	// Synse Server is down if the client can not connect to it, in which
+	// case there is nothing else to collect.
+	up := 0.0
+	client, registry, err := c.connected(ctx)
+	if err == nil {
+		_, err = client.StatusContext(ctx)
+		if synse.IsTransport(err) {
+			c.disconnect(client)
+		}
+	}
+	if err != nil {
+		c.countError(sourceStatus)
+	} else {
+		up = 1
+	}
+	m.gauge("synse_up", "Whether Synse Server is reachable.", up)
+
+	if client != nil {
+		c.collectReadings(ctx, m, client, registry)
+		c.collectPluginHealth(ctx, m, client)
+	}
+
+	c.mu.Lock()
+	for _, source := range []string{sourceStatus, sourceRead, sourceStream, sourceScan, sourcePluginHealth} {
+		m.counter("synse_exporter_scrape_errors_total", "Number of errors while collecting metrics from Synse Server.",
+			float64(c.errors[source]), label{"source", source})
+	}
+	c.mu.Unlock()
+
+	m.gauge("synse_exporter_scrape_duration_seconds", "Duration of the scrape of Synse Server.",
+		time.Since(start).Seconds())
+	return m.write(w)
+}
+
+// collectReadings adds the readings of the selected devices.
+func (c *collector) collectReadings(ctx context.Context, m *metricSet, client synse.Client, registry *synse.DeviceRegistry) {
+	readings, err := c.readings(ctx, client)
+	if err != nil {
+		c.countError(sourceRead)
+		return
+	}
+
+	devices := map[string]*scheme.Scan{}
+	scanned, err := registry.Devices(ctx)
+	if err != nil {
+		// The readings are still exported, only without the device labels.
+		c.countError(sourceScan)
+	}
+	for _, d := range scanned {
+		devices[d.ID] = d
+	}
+
+	for _, r := range readings {
+		c.addReading(m, r, devices[r.Device])
+	}
+}
+
+// readings returns the latest streamed readings when streaming, or reads
+// them otherwise.
+func (c *collector) readings(ctx context.Context, client synse.Client) ([]*scheme.Read, error) {
+	c.mu.Lock()
+	if c.streaming {
+		defer c.mu.Unlock()
+		keys := make([]string, 0, len(c.latest))
+		for k := range c.latest {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		readings := make([]*scheme.Read, len(keys))
+		for i, k := range keys {
+			readings[i] = c.latest[k]
+		}
+		return readings, nil
+	}
+	c.mu.Unlock()
+
+	return client.ReadContext(ctx, scheme.ReadOptions{Tags: c.tags})
+}
+
+// addReading adds a reading as a sample. The metric name is derived from the
+// device type and the unit of the reading, e.g. `synse_temperature_celsius`.
+// Numeric values are exported as is and bools as 0 or 1. A string value is
+// exported as a `value` label of a sample with the value 1. Readings with
+// other values are skipped.
+func (c *collector) addReading(m *metricSet, r *scheme.Read, device *scheme.Scan) {
+	deviceType := r.DeviceType
+	if deviceType == "" {
+		deviceType = r.Type
+	}
+	name := "synse_" + sanitizeName(deviceType)
+	help := "Synse " + deviceType + " reading"
+	if r.Unit.Name != "" {
+		name += "_" + sanitizeName(r.Unit.Name)
+		help += " in " + r.Unit.Name
+	}
+	help += "."
+
+	labels := []label{{"device", r.Device}}
+	if device != nil {
+		labels = append(labels, label{"alias", device.Alias}, label{"plugin", device.Plugin})
+	}
+	labels = append(labels, label{"reading", r.Type})
+	labels = append(labels, c.tagLabels(device)...)
+
+	if v, err := r.Float64Value(); err == nil {
+		m.gauge(name, help, v, labels...)
+	} else if b, err := r.BoolValue(); err == nil {
+		v := 0.0
+		if b {
+			v = 1
+		}
+		m.gauge(name, help, v, labels...)
+	} else if s, err := r.StringValue(); err == nil {
+		m.gauge(name, help, 1, append(labels, label{"value", s})...)
+	}
+}
+
+// tagLabels returns the labels for the tag annotations which are exported,
+// e.g. `tag_rack="1"` for a `rack:1` tag.
+func (c *collector) tagLabels(device *scheme.Scan) []label {
+	if device == nil || len(c.labelTags) == 0 {
+		return nil
+	}
+
+	values := map[string]string{}
+	for _, s := range device.Tags {
+		t, err := scheme.ParseTag(s)
+		if err == nil && t.Annotation != "" {
+			if _, ok := values[t.Annotation]; !ok {
+				values[t.Annotation] = t.Label
+			}
+		}
+	}
+
+	labels := make([]label, len(c.labelTags))
+	for i, annotation := range c.labelTags {
+		labels[i] = label{"tag_" + sanitizeName(annotation), values[annotation]}
+	}
+	return labels
+}
+
+// collectPluginHealth adds the health of the plugins.
+func (c *collector) collectPluginHealth(ctx context.Context, m *metricSet, client synse.Client) {
+	health, err := client.PluginHealthContext(ctx)
+	if err != nil {
+		c.countError(sourcePluginHealth)
+		return
+	}
+
+	for _, id := range health.Healthy {
+		m.gauge("synse_plugin_healthy", "Whether the plugin is healthy.", 1, label{"plugin", id})
+	}
+	for _, id := range health.Unhealthy {
+		m.gauge("synse_plugin_healthy", "Whether the plugin is healthy.", 0, label{"plugin", id})
+	}
+	m.gauge("synse_plugins", "Number of registered plugins by state.", float64(health.Active), label{"state", "active"})
+	m.gauge("synse_plugins", "Number of registered plugins by state.", float64(health.Inactive), label{"state", "inactive"})
+}
+
+// countError counts a scrape error from the given source.
+func (c *collector) countError(source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.errors[source]++
+}
@@ -0,0 +1,241 @@
+package main
+
+// synse-exporter serves the readings of Synse Server devices, and the health
+// of its plugins, as Prometheus metrics.
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// usage is the help text of the exporter.
+const usage = `Usage: synse-exporter [flags]
+
+Serves the readings of Synse Server devices as Prometheus metrics on /metrics.
+Flags which are not set fall back to the environment variables in brackets.
+Any other client option can be set with its SYNSE_ environment variable, e.g.
+$SYNSE_HTTP_TIMEOUT for http.timeout.
+
+Flags:
+`
+
+// config is the settings of the exporter.
+type config struct {
+	listen  string
+	mode    string
+	timeout time.Duration
+	tags    stringsFlag
+	labels  stringsFlag
+	options synse.Options
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stderr, os.Getenv); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "synse-exporter: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run runs the exporter until the context is done.
+func run(ctx context.Context, args []string, stderr io.Writer, getenv func(string) string) error {
+	cfg, err := parseConfig(args, stderr, getenv)
+	if err != nil {
+		return err
+	}
+
+	// The client connects on the first scrape (or stream), so that the
+	// exporter starts, and reports Synse Server as down, while it is
+	// unreachable.
+	c := newCollector(func(ctx context.Context) (synse.Client, error) {
+		return newClient(ctx, cfg)
+	}, cfg.tags, cfg.labels)
+	defer c.close() // nolint: errcheck
+	if cfg.mode == "stream" {
+		go c.stream(ctx)
+	}
+
+	l, err := net.Listen("tcp", cfg.listen)
+	if err != nil {
+		return errors.Wrap(err, "failed to listen")
+	}
+	fmt.Fprintf(stderr, "synse-exporter: serving metrics of %s on %s\n", cfg.options.Address, l.Addr())
+
+	server := &http.Server{
+		Handler:           newHandler(c, cfg.timeout),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// optionFlags maps the flags of client options to the environment variables
+// of those options, which they override.
+var optionFlags = map[string]string{
+	"address":   "SYNSE_ADDRESS",
+	"transport": "SYNSE_TRANSPORT",
+	"tls":       "SYNSE_TLS_ENABLED",
+	"cert":      "SYNSE_TLS_CERT_FILE",
+	"key":       "SYNSE_TLS_KEY_FILE",
+	"insecure":  "SYNSE_TLS_SKIP_VERIFY",
+}
+
+// parseConfig parses the flags of the exporter, falling back to environment
+// variables for the flags which are not set. The client options are loaded
+// by synse.LoadOptionsFrom, so any of them can be set with its SYNSE_
+// environment variable.
+func parseConfig(args []string, stderr io.Writer, getenv func(string) string) (*config, error) {
+	cfg := &config{}
+
+	fs := flag.NewFlagSet("synse-exporter", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.listen, "listen", ":9743", "address to serve metrics on ($SYNSE_EXPORTER_LISTEN)")
+	address := fs.String("address", "localhost:5000", "address of Synse Server, host[:port] or a URL ($SYNSE_ADDRESS)")
+	fs.String("transport", "", "client transport, http or websocket, taken from the address scheme if not set ($SYNSE_TRANSPORT)")
+	fs.StringVar(&cfg.mode, "mode", "read", "read devices on each scrape (read) or keep streaming readings (stream) ($SYNSE_EXPORTER_MODE)")
+	fs.DurationVar(&cfg.timeout, "timeout", 10*time.Second, "time limit for a scrape ($SYNSE_EXPORTER_TIMEOUT)")
+	fs.Var(&cfg.tags, "tag", "tag group selecting the devices to export, repeatable")
+	fs.Var(&cfg.labels, "label", "tag annotation to export as a label, e.g. rack, repeatable")
+	fs.Bool("tls", false, "use TLS ($SYNSE_TLS_ENABLED)")
+	fs.String("cert", "", "client certificate file ($SYNSE_TLS_CERT_FILE)")
+	fs.String("key", "", "client key file ($SYNSE_TLS_KEY_FILE)")
+	fs.Bool("insecure", false, "skip verification of the server certificate ($SYNSE_TLS_SKIP_VERIFY)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, errors.Errorf("unexpected arguments %q", fs.Args())
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for name, env := range map[string]string{
+		"listen":  "SYNSE_EXPORTER_LISTEN",
+		"mode":    "SYNSE_EXPORTER_MODE",
+		"timeout": "SYNSE_EXPORTER_TIMEOUT",
+	} {
+		if v := getenv(env); v != "" && !set[name] {
+			if err := fs.Set(name, v); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s %q", env, v)
+			}
+		}
+	}
+
+	flagEnv := map[string]string{}
+	for name, env := range optionFlags {
+		if set[name] {
+			flagEnv[env] = fs.Lookup(name).Value.String()
+		}
+	}
+	opts, err := synse.LoadOptionsFrom("", synse.EnvPrefix, func(name string) (string, bool) {
+		if v, ok := flagEnv[name]; ok {
+			return v, true
+		}
+		if v := getenv(name); v != "" {
+			return v, true
+		}
+		// The default address is used if no other is given.
+		if name == "SYNSE_ADDRESS" && getenv("SYNSE_ADDRESSES") == "" {
+			return *address, true
+		}
+		return "", false
+	})
+	if err != nil {
+		return nil, err
+	}
+	cfg.options = *opts
+
+	if cfg.mode != "read" && cfg.mode != "stream" {
+		return nil, errors.Errorf("unknown mode %q, expected read or stream", cfg.mode)
+	}
+	if err := scheme.ValidateTagGroups(cfg.tags); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// newClient creates and opens the client for the configured transport, or
+// the one of the address scheme if it is not set, and checks that Synse
+// Server is compatible with it.
+func newClient(ctx context.Context, cfg *config) (synse.Client, error) {
+	opts := cfg.options
+	return synse.NewClientContext(ctx, &opts)
+}
+
+// newHandler returns the HTTP handler of the exporter, which serves the
+// metrics on /metrics.
+func newHandler(c *collector, timeout time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		var buf bytes.Buffer
+		if err := c.collect(ctx, &buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		_, _ = buf.WriteTo(w)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `<html><body><h1>Synse Exporter</h1><p><a href="/metrics">Metrics</a></p></body></html>`)
+	})
+	return mux
+}
+
+// stringsFlag is a flag which can be repeated, collecting its values.
+type stringsFlag []string
+
+// String implements the flag.Value interface.
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set implements the flag.Value interface.
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/synse"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+// newServer starts a fake Synse Server for the tests.
+func newServer(t *testing.T) *synsetest.Server {
+	server := synsetest.NewServer(synsetest.Config{
+		Plugins: []synsetest.Plugin{{
+			ID: "plugin-1",
+		}, {
+			ID:     "plugin-2",
+			Health: "FAILING",
+		}},
+		Devices: []synsetest.Device{{
+			ID:       "temp-1",
+			Alias:    "inlet",
+			Type:     "temperature",
+			Plugin:   "plugin-1",
+			Tags:     []string{"rack:1"},
+			Readings: []synsetest.Reading{{Type: "temperature", Value: 20.5, Unit: scheme.UnitOptions{Name: "celsius", Symbol: "C"}}},
+		}, {
+			ID:       "led-1",
+			Type:     "led",
+			Plugin:   "plugin-2",
+			Tags:     []string{"rack:2"},
+			Readings: []synsetest.Reading{{Type: "state", Value: "on"}},
+		}, {
+			ID:       "lock-1",
+			Type:     "lock",
+			Plugin:   "plugin-2",
+			Readings: []synsetest.Reading{{Type: "locked", Value: true}},
+		}},
+	})
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestCollector creates a collector for the server, over the given
+// transport.
+func newTestCollector(t *testing.T, server *synsetest.Server, transport string, tags, labels []string) *collector {
+	cfg := &config{
+		options: synse.Options{Address: server.Address, Transport: transport},
+	}
+	c := newCollector(func(ctx context.Context) (synse.Client, error) {
+		return newClient(ctx, cfg)
+	}, tags, labels)
+	t.Cleanup(func() { _ = c.close() })
+	return c
+}
+
+// collectLines collects the metrics, returning the lines which are not
+// comments.
+func collectLines(t *testing.T, c *collector) []string {
+	var buf bytes.Buffer
+	assert.NoError(t, c.collect(context.Background(), &buf))
+
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !strings.HasPrefix(l, "#") && !strings.HasPrefix(l, "synse_exporter_scrape_duration_seconds") {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func TestCollector_Read(t *testing.T) {
+	server := newServer(t)
+
+	for _, transport := range []string{"http", "websocket"} {
+		c := newTestCollector(t, server, transport, nil, []string{"rack"})
+		assert.Equal(t, []string{
+			`synse_exporter_scrape_errors_total{source="plugin_health"} 0`,
+			`synse_exporter_scrape_errors_total{source="read"} 0`,
+			`synse_exporter_scrape_errors_total{source="scan"} 0`,
+			`synse_exporter_scrape_errors_total{source="status"} 0`,
+			`synse_exporter_scrape_errors_total{source="stream"} 0`,
+			`synse_led{device="led-1",plugin="plugin-2",reading="state",tag_rack="2",value="on"} 1`,
+			`synse_lock{device="lock-1",plugin="plugin-2",reading="locked"} 1`,
+			`synse_plugin_healthy{plugin="plugin-1"} 1`,
+			`synse_plugin_healthy{plugin="plugin-2"} 0`,
+			`synse_plugins{state="active"} 2`,
+			`synse_plugins{state="inactive"} 0`,
+			`synse_temperature_celsius{device="temp-1",alias="inlet",plugin="plugin-1",reading="temperature",tag_rack="1"} 20.5`,
+			`synse_up 1`,
+		}, collectLines(t, c), transport)
+	}
+}
+
+func TestCollector_ReadTags(t *testing.T) {
+	server := newServer(t)
+
+	c := newTestCollector(t, server, "http", []string{"rack:1"}, nil)
+	var readings []string
+	for _, l := range collectLines(t, c) {
+		if strings.HasPrefix(l, "synse_temperature") || strings.HasPrefix(l, "synse_led") || strings.HasPrefix(l, "synse_lock") {
+			readings = append(readings, l)
+		}
+	}
+	assert.Equal(t, []string{
+		`synse_temperature_celsius{device="temp-1",alias="inlet",plugin="plugin-1",reading="temperature"} 20.5`,
+	}, readings)
+}
+
+func TestCollector_Stream(t *testing.T) {
+	for _, transport := range []string{"http", "websocket"} {
+		// The readings are changed, so each transport gets its own server.
+		server := newServer(t)
+		c := newTestCollector(t, server, transport, []string{"system/id:temp-1"}, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		go c.stream(ctx)
+
+		assert.Eventually(t, func() bool {
+			return contains(collectLines(t, c), `synse_temperature_celsius{device="temp-1",alias="inlet",plugin="plugin-1",reading="temperature"} 20.5`)
+		}, 5*time.Second, 20*time.Millisecond, transport)
+
+		assert.NoError(t, server.SetReading("temp-1", synsetest.Reading{
+			Type: "temperature", Value: 22, Unit: scheme.UnitOptions{Name: "celsius"},
+		}))
+		assert.Eventually(t, func() bool {
+			return contains(collectLines(t, c), `synse_temperature_celsius{device="temp-1",alias="inlet",plugin="plugin-1",reading="temperature"} 22`)
+		}, 5*time.Second, 20*time.Millisecond, transport)
+
+		cancel()
+	}
+}
+
+func TestCollector_Errors(t *testing.T) {
+	server := newServer(t)
+	c := newTestCollector(t, server, "http", nil, nil)
+	assert.Contains(t, collectLines(t, c), `synse_up 1`)
+	server.Close()
+
+	lines := collectLines(t, c)
+	assert.Contains(t, lines, `synse_up 0`)
+	assert.Contains(t, lines, `synse_exporter_scrape_errors_total{source="read"} 1`)
+	assert.Contains(t, lines, `synse_exporter_scrape_errors_total{source="plugin_health"} 1`)
+
+	// The client is closed after the transport error, and it can not
+	// connect again while the server is down, so nothing else is read.
+	lines = collectLines(t, c)
+	assert.Contains(t, lines, `synse_up 0`)
+	assert.Contains(t, lines, `synse_exporter_scrape_errors_total{source="status"} 2`)
+	assert.Contains(t, lines, `synse_exporter_scrape_errors_total{source="read"} 1`)
+}
+
+func TestCollector_Down(t *testing.T) {
+	server := newServer(t)
+
+	// Synse Server is unreachable at first, the collector connects once it
+	// is up.
+	var connects int
+	c := newCollector(func(ctx context.Context) (synse.Client, error) {
+		connects++
+		if connects == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return newClient(ctx, &config{options: synse.Options{Address: "ws://" + server.Address}})
+	}, nil, nil)
+	t.Cleanup(func() { _ = c.close() })
+
+	lines := collectLines(t, c)
+	assert.Contains(t, lines, `synse_up 0`)
+	assert.Contains(t, lines, `synse_exporter_scrape_errors_total{source="status"} 1`)
+	assert.Contains(t, lines, `synse_exporter_scrape_errors_total{source="read"} 0`)
+
+	lines = collectLines(t, c)
+	assert.Contains(t, lines, `synse_up 1`)
+	assert.Contains(t, lines, `synse_plugin_healthy{plugin="plugin-1"} 1`)
+	assert.Equal(t, 2, connects)
+
+	collectLines(t, c)
+	assert.Equal(t, 2, connects)
+}
+
+func TestCollector_ServerRestarted(t *testing.T) {
+	server := newServer(t)
+
+	// The restarted server is another fake server, which the collector
+	// connects to in place of the first one.
+	address := server.Address
+	var connects int
+	c := newCollector(func(ctx context.Context) (synse.Client, error) {
+		connects++
+		return newClient(ctx, &config{options: synse.Options{Address: "ws://" + address}})
+	}, nil, nil)
+	t.Cleanup(func() { _ = c.close() })
+
+	lines := collectLines(t, c)
+	assert.Contains(t, lines, `synse_up 1`)
+
+	// The websocket connection is closed when the server stops, and the
+	// client does not reconnect on its own.
+	server.Close()
+	address = newServer(t).Address
+
+	lines = collectLines(t, c)
+	assert.Contains(t, lines, `synse_up 0`)
+
+	lines = collectLines(t, c)
+	assert.Contains(t, lines, `synse_up 1`)
+	assert.Contains(t, lines, `synse_plugin_healthy{plugin="plugin-1"} 1`)
+	assert.Equal(t, 2, connects)
+}
+
+func TestHandler(t *testing.T) {
+	server := newServer(t)
+	c := newTestCollector(t, server, "http", nil, nil)
+
+	ts := httptest.NewServer(newHandler(c, time.Second))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, contentType, resp.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), "# TYPE synse_temperature_celsius gauge\n")
+	assert.Contains(t, string(body), "# HELP synse_temperature_celsius Synse temperature reading in celsius.\n")
+
+	resp, err = http.Get(ts.URL + "/other")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestParseConfig(t *testing.T) {
+	env := map[string]string{
+		"SYNSE_ADDRESS":          "synse:5000",
+		"SYNSE_TRANSPORT":        "websocket",
+		"SYNSE_EXPORTER_MODE":    "stream",
+		"SYNSE_HTTP_RETRY_COUNT": "0",
+		"SYNSE_TLS_CERT_FILE":    "cert.pem",
+		"SYNSE_TLS_KEY_FILE":     "key.pem",
+	}
+	cfg, err := parseConfig([]string{"-transport", "http", "-tag", "rack:1", "-label", "rack"}, io.Discard, func(k string) string {
+		return env[k]
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "synse:5000", cfg.options.Address)
+	assert.Equal(t, "http", cfg.options.Transport)
+	assert.Equal(t, "stream", cfg.mode)
+	assert.Equal(t, []string{"rack:1"}, []string(cfg.tags))
+	assert.Equal(t, []string{"rack"}, []string(cfg.labels))
+	assert.Equal(t, uint(0), cfg.options.HTTP.Retry.Count)
+	assert.Equal(t, "cert.pem", cfg.options.TLS.CertFile)
+	assert.Equal(t, "key.pem", cfg.options.TLS.KeyFile)
+
+	noEnv := func(string) string { return "" }
+	cfg, err = parseConfig([]string{"-address", "ws://synse:5000"}, io.Discard, noEnv)
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.options.Transport)
+	cfg, err = parseConfig(nil, io.Discard, noEnv)
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5000", cfg.options.Address)
+
+	_, err = parseConfig([]string{"-mode", "push"}, io.Discard, noEnv)
+	assert.EqualError(t, err, `unknown mode "push", expected read or stream`)
+
+	_, err = parseConfig([]string{"-transport", "grpc"}, io.Discard, noEnv)
+	assert.EqualError(t, err, `invalid options: transport: unknown transport "grpc"`)
+
+	_, err = parseConfig([]string{"-tag", "a/b/c"}, io.Discard, noEnv)
+	assert.Error(t, err)
+
+	_, err = parseConfig(nil, io.Discard, func(k string) string {
+		return map[string]string{"SYNSE_TLS_ENABLED": "maybe"}[k]
+	})
+	assert.EqualError(t, err, `invalid SYNSE_TLS_ENABLED: tls.enabled: invalid boolean "maybe"`)
+}
+
+func TestMetricSet(t *testing.T) {
+	m := newMetricSet()
+	m.gauge("b", "The b\nmetric.", 2, label{"x", `a"b\c`})
+	m.gauge("b", "Ignored.", 1, label{"x", "a"}, label{"y", ""})
+	m.counter("a_total", "The a metric.", 3)
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.write(&buf))
+	assert.Equal(t, `# HELP a_total The a metric.
+# TYPE a_total counter
+a_total 3
+# HELP b The b\nmetric.
+# TYPE b gauge
+b{x="a"} 1
+b{x="a\"b\\c"} 2
+`, buf.String())
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"temperature":      "temperature",
+		"percent humidity": "percent_humidity",
+		"Air-Flow (CFM)":   "air_flow_cfm",
+		"kWh":              "kwh",
+		"__x__":            "x",
+	}
+	for in, expected := range tests {
+		assert.Equal(t, expected, sanitizeName(in), in)
+	}
+}
+
+// contains reports whether the lines contain the given line.
+func contains(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
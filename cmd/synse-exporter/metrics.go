@@ -0,0 +1,156 @@
+package main
+
+// metrics.go writes metrics in the Prometheus text exposition format.
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// contentType is the content type of the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// label is a label of a sample.
+type label struct {
+	name  string
+	value string
+}
+
+// sample is a single value of a metric.
+type sample struct {
+	labels []label
+	value  float64
+}
+
+// family is a metric with its samples.
+type family struct {
+	help    string
+	typ     string
+	samples []sample
+}
+
+// metricSet collects the metric families of a scrape.
+type metricSet struct {
+	families map[string]*family
+}
+
+// newMetricSet creates an empty metric set.
+func newMetricSet() *metricSet {
+	return &metricSet{
+		families: map[string]*family{},
+	}
+}
+
+// gauge adds a gauge sample.
+func (m *metricSet) gauge(name, help string, value float64, labels ...label) {
+	m.add(name, help, "gauge", value, labels)
+}
+
+// counter adds a counter sample.
+func (m *metricSet) counter(name, help string, value float64, labels ...label) {
+	m.add(name, help, "counter", value, labels)
+}
+
+// add adds a sample to the family of the given name. The help and type of a
+// family are those of its first sample.
+func (m *metricSet) add(name, help, typ string, value float64, labels []label) {
+	f, ok := m.families[name]
+	if !ok {
+		f = &family{help: help, typ: typ}
+		m.families[name] = f
+	}
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+// write writes the metrics in the text exposition format, with the families
+// sorted by name and the samples sorted by labels, so that the output is
+// stable between scrapes.
+func (m *metricSet) write(w io.Writer) error {
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := m.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, escapeHelp(f.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.typ)
+
+		lines := make([]string, len(f.samples))
+		for i, s := range f.samples {
+			lines[i] = name + formatLabels(s.labels) + " " + formatValue(s.value)
+		}
+		sort.Strings(lines)
+		for _, l := range lines {
+			b.WriteString(l)
+			b.WriteByte('\n')
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatLabels formats the labels of a sample, skipping empty ones.
+func formatLabels(labels []label) string {
+	var parts []string
+	for _, l := range labels {
+		if l.value != "" {
+			parts = append(parts, l.name+`="`+escapeLabel(l.value)+`"`)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// formatValue formats the value of a sample.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// escapeLabel escapes a label value.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp escapes a help text.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// sanitizeName turns a string into a valid metric or label name component:
+// lowercase, with any run of characters other than letters, digits and
+// underscores replaced by a single underscore.
+func sanitizeName(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+			underscore = false
+			continue
+		}
+		if !underscore {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
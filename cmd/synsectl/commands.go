@@ -0,0 +1,403 @@
+package main
+
+// commands.go defines the commands of the tool, one for each part of the
+// client API.
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// commands returns all commands of the tool.
+func commands() []command {
+	return []command{
+		{name: "status", help: "Check that Synse Server is reachable", run: runStatus},
+		{name: "version", help: "Show the version of Synse Server", run: runVersion},
+		{name: "config", help: "Show the config of Synse Server (as YAML for table output)", run: runConfig},
+		{name: "plugins", args: "[plugin]", help: "List the plugins, or show the info of a plugin", run: runPlugins},
+		{name: "plugin-health", help: "Show the health summary of the plugins", run: runPluginHealth},
+		{name: "scan", help: "List the devices", run: runScan, flags: scanFlags},
+		{name: "tags", help: "List the device tags", run: runTags, flags: tagsFlags},
+		{name: "info", args: "<device>", help: "Show the info of a device", run: runInfo},
+		{name: "read", args: "[device...]", help: "Read the devices, or the given devices", run: runRead, flags: readFlags},
+		{name: "read-cache", help: "Read the cached readings over a time range", run: runReadCache, flags: readCacheFlags, stream: true},
+		{name: "stream", help: "Stream readings until interrupted", run: runStream, flags: streamFlags, stream: true},
+		{name: "write", args: "<device> <action> [data]", help: "Write to a device", run: runWrite, flags: writeFlags},
+		{name: "transactions", args: "[transaction]", help: "List the transactions, or show the status of a transaction", run: runTransactions},
+	}
+}
+
+func runStatus(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	status, err := env.client.StatusContext(ctx)
+	if err != nil {
+		return err
+	}
+	return env.printer.print(status, &table{
+		header: []string{"STATUS", "TIMESTAMP"},
+		rows:   [][]string{{status.Status, status.Timestamp}},
+	})
+}
+
+func runVersion(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	version, err := env.client.VersionContext(ctx)
+	if err != nil {
+		return err
+	}
+	return env.printer.print(version, &table{
+		header: []string{"VERSION", "API VERSION"},
+		rows:   [][]string{{version.Version, version.APIVersion}},
+	})
+}
+
+func runConfig(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	cfg, err := env.client.ConfigContext(ctx)
+	if err != nil {
+		return err
+	}
+	return env.printer.print(cfg, nil)
+}
+
+func runPlugins(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	if fs.NArg() > 0 {
+		plugin, err := env.client.PluginContext(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		return env.printer.print(plugin, fieldTable(
+			"ID", plugin.ID,
+			"NAME", plugin.Name,
+			"DESCRIPTION", plugin.Description,
+			"MAINTAINER", plugin.Maintainer,
+			"VCS", plugin.VCS,
+			"VERSION", plugin.Version.PluginVersion,
+			"SDK VERSION", plugin.Version.SDKVersion,
+			"ACTIVE", strconv.FormatBool(plugin.Active),
+			"NETWORK", plugin.Network.Protocol+" "+plugin.Network.Address,
+			"HEALTH", plugin.Health.Status,
+		))
+	}
+
+	plugins, err := env.client.PluginsContext(ctx)
+	if err != nil {
+		return err
+	}
+	t := &table{header: []string{"ID", "NAME", "VERSION", "ACTIVE"}}
+	for _, p := range plugins {
+		t.rows = append(t.rows, []string{p.ID, p.Name, p.Version.PluginVersion, strconv.FormatBool(p.Active)})
+	}
+	return env.printer.print(plugins, t)
+}
+
+func runPluginHealth(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	health, err := env.client.PluginHealthContext(ctx)
+	if err != nil {
+		return err
+	}
+	return env.printer.print(health, &table{
+		header: []string{"STATUS", "HEALTHY", "UNHEALTHY", "ACTIVE", "INACTIVE", "UPDATED"},
+		rows: [][]string{{
+			health.Status,
+			strconv.Itoa(len(health.Healthy)),
+			strconv.Itoa(len(health.Unhealthy)),
+			strconv.Itoa(health.Active),
+			strconv.Itoa(health.Inactive),
+			health.Updated,
+		}},
+	})
+}
+
+func scanFlags(fs *flag.FlagSet) {
+	fs.String("ns", "", "default namespace of the tags")
+	fs.Var(&stringsFlag{}, "tag", "select devices with the tags, comma separated (repeatable, any of)")
+	fs.String("sort", "", "sort keys, comma separated")
+	fs.Bool("force", false, "force a rebuild of the device cache")
+}
+
+func runScan(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	opts := scheme.ScanOptions{
+		NS:    stringFlag(fs, "ns"),
+		Tags:  stringsFlagValue(fs, "tag"),
+		Force: boolFlag(fs, "force"),
+	}
+	if s := stringFlag(fs, "sort"); s != "" {
+		opts.Sort = strings.Split(s, ",")
+	}
+
+	devices, err := env.client.ScanContext(ctx, opts)
+	if err != nil {
+		return err
+	}
+	t := &table{header: []string{"ID", "ALIAS", "TYPE", "INFO", "PLUGIN"}}
+	for _, d := range devices {
+		t.rows = append(t.rows, []string{d.ID, d.Alias, d.Type, d.Info, d.Plugin})
+	}
+	return env.printer.print(devices, t)
+}
+
+func tagsFlags(fs *flag.FlagSet) {
+	fs.Var(&stringsFlag{}, "ns", "namespace of the tags (repeatable)")
+	fs.Bool("ids", false, "include device ID tags")
+}
+
+func runTags(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	tags, err := env.client.TagsContext(ctx, scheme.TagsOptions{
+		NS:  stringsFlagValue(fs, "ns"),
+		IDs: boolFlag(fs, "ids"),
+	})
+	if err != nil {
+		return err
+	}
+	t := &table{header: []string{"TAG"}}
+	for _, tag := range tags {
+		t.rows = append(t.rows, []string{tag})
+	}
+	return env.printer.print(tags, t)
+}
+
+func runInfo(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	if fs.NArg() != 1 {
+		return errors.New("info requires a device ID")
+	}
+
+	info, err := env.client.InfoContext(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var outputs []string
+	for _, o := range info.Outputs {
+		outputs = append(outputs, o.Name)
+	}
+	return env.printer.print(info, fieldTable(
+		"ID", info.ID,
+		"ALIAS", info.Alias,
+		"TYPE", info.Type,
+		"INFO", info.Info,
+		"PLUGIN", info.Plugin,
+		"TAGS", strings.Join(info.Tags, ", "),
+		"MODE", info.Capabilities.Mode,
+		"ACTIONS", strings.Join(info.Capabilities.Write.Actions, ", "),
+		"OUTPUTS", strings.Join(outputs, ", "),
+	))
+}
+
+func readFlags(fs *flag.FlagSet) {
+	fs.String("ns", "", "default namespace of the tags")
+	fs.Var(&stringsFlag{}, "tag", "select devices with the tags, comma separated (repeatable, any of)")
+}
+
+func runRead(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	var reads []*scheme.Read
+	if fs.NArg() > 0 {
+		for _, id := range fs.Args() {
+			r, err := env.client.ReadDeviceContext(ctx, id)
+			if err != nil {
+				return err
+			}
+			reads = append(reads, r...)
+		}
+	} else {
+		var err error
+		reads, err = env.client.ReadContext(ctx, scheme.ReadOptions{
+			NS:   stringFlag(fs, "ns"),
+			Tags: stringsFlagValue(fs, "tag"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	t := &table{header: readHeader}
+	for _, r := range reads {
+		t.rows = append(t.rows, readRow(r))
+	}
+	return env.printer.print(reads, t)
+}
+
+// readHeader is the table header of readings.
+var readHeader = []string{"DEVICE", "TYPE", "VALUE", "UNIT", "TIMESTAMP"}
+
+// readRow returns the table row of a reading.
+func readRow(r *scheme.Read) []string {
+	return []string{r.Device, r.Type, fmt.Sprint(r.Value), r.Unit.Symbol, r.Timestamp}
+}
+
+func readCacheFlags(fs *flag.FlagSet) {
+	fs.String("start", "", "start of the time range, an RFC3339 timestamp or a duration relative to now, e.g. -10m")
+	fs.String("end", "", "end of the time range, an RFC3339 timestamp or a duration relative to now")
+}
+
+func runReadCache(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	now := time.Now()
+	start, err := parseTime(stringFlag(fs, "start"), now)
+	if err != nil {
+		return err
+	}
+	end, err := parseTime(stringFlag(fs, "end"), now)
+	if err != nil {
+		return err
+	}
+
+	return streamReads(ctx, env, func(ctx context.Context, out chan<- *scheme.Read) error {
+		return env.client.ReadCacheContext(ctx, scheme.ReadCacheOptions{Start: start, End: end}, out)
+	})
+}
+
+func streamFlags(fs *flag.FlagSet) {
+	fs.Var(&stringsFlag{}, "id", "stream the readings of the device (repeatable)")
+	fs.Var(&stringsFlag{}, "tag", "stream the readings of devices with the tags, comma separated (repeatable)")
+	fs.Duration("duration", 0, "stop streaming after the duration, instead of when interrupted")
+}
+
+func runStream(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	if d := durationFlag(fs, "duration"); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	opts := scheme.ReadStreamOptions{
+		Ids:  stringsFlagValue(fs, "id"),
+		Tags: stringsFlagValue(fs, "tag"),
+	}
+	err := streamReads(ctx, env, func(ctx context.Context, out chan<- *scheme.Read) error {
+		defer close(out)
+		return env.client.ReadStreamContext(ctx, opts, out, make(chan struct{}))
+	})
+
+	// The stream ends when it is interrupted or its duration elapsed.
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return nil
+	}
+	return err
+}
+
+// streamReads prints the readings sent by the read function as they come in,
+// until it closes the channel.
+func streamReads(ctx context.Context, env *environment, read func(context.Context, chan<- *scheme.Read) error) error {
+	out := make(chan *scheme.Read)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- read(ctx, out)
+	}()
+
+	for r := range out {
+		if err := env.printer.printItem(r, readHeader, readRow(r)); err != nil {
+			return err
+		}
+	}
+	return <-errs
+}
+
+func writeFlags(fs *flag.FlagSet) {
+	fs.Bool("sync", false, "wait for the write to complete on the server side")
+	fs.Bool("wait", false, "wait for the write transaction to complete, reporting its status changes")
+}
+
+func runWrite(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	if fs.NArg() < 2 || fs.NArg() > 3 {
+		return errors.New("write requires a device ID, an action and optionally data")
+	}
+	device := fs.Arg(0)
+	data := []scheme.WriteData{{Action: fs.Arg(1), Data: fs.Arg(2)}}
+
+	if boolFlag(fs, "sync") {
+		txns, err := env.client.WriteSyncContext(ctx, device, data)
+		if err != nil {
+			return err
+		}
+		return env.printer.print(txns, transactionTable(txns...))
+	}
+
+	writes, err := env.client.WriteAsyncContext(ctx, device, data)
+	if err != nil {
+		return err
+	}
+	if !boolFlag(fs, "wait") {
+		t := &table{header: []string{"TRANSACTION", "DEVICE", "ACTION", "TIMEOUT"}}
+		for _, w := range writes {
+			t.rows = append(t.rows, []string{w.ID, w.Device, w.Context.Action, w.Timeout})
+		}
+		return env.printer.print(writes, t)
+	}
+
+	var printErr error
+	_, err = synse.WaitTransactions(ctx, env.client, writes, &synse.WaitOptions{
+		OnChange: func(t *scheme.Transaction) {
+			if printErr == nil {
+				printErr = env.printer.printItem(t, transactionHeader, transactionRow(t))
+			}
+		},
+	})
+	if printErr != nil {
+		return printErr
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func runTransactions(ctx context.Context, env *environment, fs *flag.FlagSet) error {
+	if fs.NArg() > 0 {
+		txn, err := env.client.TransactionContext(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		return env.printer.print(txn, transactionTable(txn))
+	}
+
+	ids, err := env.client.TransactionsContext(ctx)
+	if err != nil {
+		return err
+	}
+	t := &table{header: []string{"TRANSACTION"}}
+	for _, id := range ids {
+		t.rows = append(t.rows, []string{id})
+	}
+	return env.printer.print(ids, t)
+}
+
+// transactionHeader is the table header of transactions.
+var transactionHeader = []string{"TRANSACTION", "DEVICE", "STATUS", "MESSAGE", "UPDATED"}
+
+// transactionRow returns the table row of a transaction.
+func transactionRow(t *scheme.Transaction) []string {
+	return []string{t.ID, t.Device, t.Status, t.Message, t.Updated}
+}
+
+// transactionTable returns the table of transactions.
+func transactionTable(txns ...*scheme.Transaction) *table {
+	t := &table{header: transactionHeader}
+	for _, txn := range txns {
+		t.rows = append(t.rows, transactionRow(txn))
+	}
+	return t
+}
+
+// stringFlag returns the value of a string flag.
+func stringFlag(fs *flag.FlagSet, name string) string {
+	return fs.Lookup(name).Value.String()
+}
+
+// stringsFlagValue returns the values of a repeatable flag.
+func stringsFlagValue(fs *flag.FlagSet, name string) []string {
+	return []string(*fs.Lookup(name).Value.(*stringsFlag))
+}
+
+// boolFlag returns the value of a bool flag.
+func boolFlag(fs *flag.FlagSet, name string) bool {
+	return fs.Lookup(name).Value.(flag.Getter).Get().(bool)
+}
+
+// durationFlag returns the value of a duration flag.
+func durationFlag(fs *flag.FlagSet, name string) time.Duration {
+	return fs.Lookup(name).Value.(flag.Getter).Get().(time.Duration)
+}
@@ -0,0 +1,120 @@
+package main
+
+// config.go loads the connection settings of the tool from a client options
+// file, environment variables and flags.
+
+import (
+	"flag"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse"
+)
+
+// config is the settings of the tool.
+type config struct {
+	// Output is the output format, either table, json or yaml.
+	Output string
+
+	// Timeout bounds each command, except for streams.
+	Timeout time.Duration
+
+	// Options holds the client options.
+	synse.Options
+}
+
+// flags holds the global flags of the tool.
+type flags struct {
+	config     string
+	address    string
+	transport  string
+	output     string
+	timeout    time.Duration
+	tls        bool
+	cert       string
+	key        string
+	skipVerify bool
+}
+
+// optionFlags maps the flags of client options to the environment variables
+// of those options, which they override.
+var optionFlags = map[string]string{
+	"address":   "SYNSE_ADDRESS",
+	"transport": "SYNSE_TRANSPORT",
+	"tls":       "SYNSE_TLS_ENABLED",
+	"cert":      "SYNSE_TLS_CERT_FILE",
+	"key":       "SYNSE_TLS_KEY_FILE",
+	"insecure":  "SYNSE_TLS_SKIP_VERIFY",
+}
+
+// registerFlags registers the global flags of the tool.
+func registerFlags(fs *flag.FlagSet) *flags {
+	f := &flags{}
+	fs.StringVar(&f.config, "config", "", "path to a YAML client options file ($SYNSE_CONFIG)")
+	fs.StringVar(&f.address, "address", "localhost:5000", "address of Synse Server, host[:port] or a URL ($SYNSE_ADDRESS)")
+	fs.StringVar(&f.transport, "transport", "", "client transport, http or websocket, taken from the address scheme if not set ($SYNSE_TRANSPORT)")
+	fs.StringVar(&f.output, "o", "table", "output format, table, json or yaml ($SYNSE_OUTPUT)")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "time limit for a command, except streams ($SYNSE_TIMEOUT)")
+	fs.BoolVar(&f.tls, "tls", false, "use TLS ($SYNSE_TLS_ENABLED)")
+	fs.StringVar(&f.cert, "cert", "", "client certificate file ($SYNSE_TLS_CERT_FILE)")
+	fs.StringVar(&f.key, "key", "", "client key file ($SYNSE_TLS_KEY_FILE)")
+	fs.BoolVar(&f.skipVerify, "insecure", false, "skip verification of the server certificate ($SYNSE_TLS_SKIP_VERIFY)")
+	return f
+}
+
+// loadConfig loads the settings of the tool. The client options are loaded
+// by synse.LoadOptionsFrom: defaults are overridden by the options file,
+// which is overridden by the SYNSE_ environment variables, which are
+// overridden by flags set on the command line.
+func loadConfig(fs *flag.FlagSet, f *flags, getenv func(string) string) (*config, error) {
+	cfg := &config{
+		Output:  f.output,
+		Timeout: f.timeout,
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) {
+		set[fl.Name] = true
+	})
+
+	path := getenv("SYNSE_CONFIG")
+	if set["config"] {
+		path = f.config
+	}
+
+	flagEnv := map[string]string{}
+	for name, env := range optionFlags {
+		if set[name] {
+			flagEnv[env] = fs.Lookup(name).Value.String()
+		}
+	}
+	opts, err := synse.LoadOptionsFrom(path, synse.EnvPrefix, func(name string) (string, bool) {
+		if v, ok := flagEnv[name]; ok {
+			return v, true
+		}
+		if v := getenv(name); v != "" {
+			return v, true
+		}
+		// The default address is used if no other is given.
+		if name == "SYNSE_ADDRESS" && path == "" && getenv("SYNSE_ADDRESSES") == "" {
+			return f.address, true
+		}
+		return "", false
+	})
+	if err != nil {
+		return nil, err
+	}
+	cfg.Options = *opts
+
+	if v := getenv("SYNSE_OUTPUT"); v != "" && !set["o"] {
+		cfg.Output = v
+	}
+	if v := getenv("SYNSE_TIMEOUT"); v != "" && !set["timeout"] {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid SYNSE_TIMEOUT %q", v)
+		}
+		cfg.Timeout = d
+	}
+	return cfg, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+// synsectl is a command-line tool for Synse Server, built on the client
+// library. It covers the whole client API, over either HTTP or WebSocket.
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse"
+)
+
+// usage is the help text of the tool, followed by the list of commands.
+const usage = `Usage: synsectl [flags] <command> [command flags] [args]
+
+Client options are read from a YAML options file (-config or $SYNSE_CONFIG),
+then from SYNSE_ environment variables, e.g. $SYNSE_HTTP_TIMEOUT for
+http.timeout, then from flags, each overriding the last.
+
+Flags:
+`
+
+// command is a subcommand of the tool.
+type command struct {
+	// name is the name of the command.
+	name string
+
+	// args describes the arguments of the command.
+	args string
+
+	// help is a short description of the command.
+	help string
+
+	// run runs the command, with its flags already parsed.
+	run func(ctx context.Context, env *environment, fs *flag.FlagSet) error
+
+	// flags registers the flags of the command.
+	flags func(fs *flag.FlagSet)
+
+	// stream marks a command which is not bound by the request timeout.
+	stream bool
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "synsectl: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run runs the tool with the given arguments, writing its output to stdout.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) error {
+	fs := flag.NewFlagSet("synsectl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	f := registerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+		fmt.Fprintln(stderr, "\nCommands:")
+		for _, c := range commands() {
+			fmt.Fprintf(stderr, "  %-14s %s\n", c.name, c.help)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	cmd, ok := findCommand(fs.Arg(0))
+	if !ok {
+		return errors.Errorf("unknown command %q, see synsectl -help", fs.Arg(0))
+	}
+
+	cfg, err := loadConfig(fs, f, getenv)
+	if err != nil {
+		return err
+	}
+
+	cmdFlags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	cmdFlags.SetOutput(stderr)
+	cmdFlags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: synsectl %s [flags] %s\n\n%s\n", cmd.name, cmd.args, cmd.help)
+		cmdFlags.PrintDefaults()
+	}
+	if cmd.flags != nil {
+		cmd.flags(cmdFlags)
+	}
+	if err := cmdFlags.Parse(fs.Args()[1:]); err != nil {
+		return err
+	}
+
+	p, err := newPrinter(cfg.Output, stdout)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close() // nolint: errcheck
+
+	if !cmd.stream && cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	return cmd.run(ctx, &environment{client: client, printer: p}, cmdFlags)
+}
+
+// environment is what a command runs with.
+type environment struct {
+	client  synse.Client
+	printer *printer
+}
+
+// newClient creates and opens the client for the configured transport, or
+// the one of the address scheme if it is not set.
+func newClient(cfg *config) (synse.Client, error) {
+	return synse.NewClient(&cfg.Options)
+}
+
+// findCommand returns the command with the given name.
+func findCommand(name string) (command, bool) {
+	for _, c := range commands() {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// stringsFlag is a flag which can be repeated, collecting its values.
+type stringsFlag []string
+
+// String implements the flag.Value interface.
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set implements the flag.Value interface.
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// parseTime parses a time flag, either as an RFC3339 timestamp or as a
+// duration relative to now, e.g. `-10m`.
+func parseTime(s string, now time.Time) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(d).UTC().Format(time.RFC3339Nano), nil
+	}
+	if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+		return "", errors.Errorf("invalid time %q, expected an RFC3339 timestamp or a duration", s)
+	}
+	return s, nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+	"gopkg.in/yaml.v3"
+)
+
+// newServer starts a fake Synse Server for the tests.
+func newServer(t *testing.T) *synsetest.Server {
+	server := synsetest.NewServer(synsetest.Config{
+		TransactionStep: 10 * time.Millisecond,
+		Plugins: []synsetest.Plugin{{
+			ID:   "plugin-1",
+			Name: "emulator plugin",
+		}},
+		Devices: []synsetest.Device{{
+			ID:       "temp-1",
+			Type:     "temperature",
+			Plugin:   "plugin-1",
+			Tags:     []string{"rack:1"},
+			Readings: []synsetest.Reading{{Type: "temperature", Value: 20.5, Unit: scheme.UnitOptions{Symbol: "C"}}},
+		}, {
+			ID:       "led-1",
+			Type:     "led",
+			Plugin:   "plugin-1",
+			Actions:  []string{"state"},
+			Readings: []synsetest.Reading{{Type: "state", Value: "off"}},
+		}},
+	})
+	t.Cleanup(server.Close)
+	return server
+}
+
+// runTool runs the tool with the given arguments and environment.
+func runTool(t *testing.T, env map[string]string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), args, &stdout, &stderr, func(k string) string {
+		return env[k]
+	})
+	return stdout.String(), err
+}
+
+func TestRun_Table(t *testing.T) {
+	server := newServer(t)
+
+	for _, transport := range []string{"http", "websocket"} {
+		out, err := runTool(t, nil, "-address", server.Address, "-transport", transport, "scan")
+		assert.NoError(t, err, transport)
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		assert.Len(t, lines, 3, transport)
+		assert.Equal(t, []string{"ID", "ALIAS", "TYPE", "INFO", "PLUGIN"}, strings.Fields(lines[0]), transport)
+		assert.Equal(t, []string{"led-1", "led", "plugin-1"}, strings.Fields(lines[1]), transport)
+
+		out, err = runTool(t, nil, "-address", server.Address, "-transport", transport, "read", "-tag", "rack:1")
+		assert.NoError(t, err, transport)
+		assert.Contains(t, out, "temp-1", transport)
+		assert.Contains(t, out, "20.5", transport)
+		assert.NotContains(t, out, "led-1", transport)
+
+		out, err = runTool(t, nil, "-address", server.Address, "-transport", transport, "plugins", "plugin-1")
+		assert.NoError(t, err, transport)
+		assert.Contains(t, out, "emulator plugin", transport)
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	server := newServer(t)
+
+	out, err := runTool(t, nil, "-address", server.Address, "-o", "json", "version")
+	assert.NoError(t, err)
+
+	var version scheme.Version
+	assert.NoError(t, json.Unmarshal([]byte(out), &version))
+	assert.Equal(t, "v3", version.APIVersion)
+}
+
+func TestRun_YAML(t *testing.T) {
+	server := newServer(t)
+
+	out, err := runTool(t, nil, "-address", server.Address, "-o", "yaml", "info", "led-1")
+	assert.NoError(t, err)
+
+	var info scheme.Info
+	assert.NoError(t, yaml.Unmarshal([]byte(out), &info))
+	assert.Equal(t, "led-1", info.ID)
+	assert.Equal(t, []string{"state"}, info.Capabilities.Write.Actions)
+}
+
+func TestRun_Write(t *testing.T) {
+	server := newServer(t)
+
+	for _, transport := range []string{"http", "websocket"} {
+		out, err := runTool(t, nil, "-address", server.Address, "-transport", transport, "write", "-wait", "led-1", "state", "on")
+		assert.NoError(t, err, transport)
+		assert.Contains(t, out, "pending", transport)
+		assert.Contains(t, out, "done", transport)
+
+		out, err = runTool(t, nil, "-address", server.Address, "-transport", transport, "-o", "json", "write", "-sync", "led-1", "state", "off")
+		assert.NoError(t, err, transport)
+		var txns []scheme.Transaction
+		assert.NoError(t, json.Unmarshal([]byte(out), &txns))
+		assert.Equal(t, "done", txns[0].Status, transport)
+
+		_, err = runTool(t, nil, "-address", server.Address, "-transport", transport, "write", "temp-1", "state", "on")
+		assert.Error(t, err, transport)
+	}
+}
+
+func TestRun_Stream(t *testing.T) {
+	server := newServer(t)
+
+	for _, transport := range []string{"http", "websocket"} {
+		out, err := runTool(t, nil, "-address", server.Address, "-transport", transport, "-o", "json",
+			"stream", "-id", "temp-1", "-duration", "200ms")
+		assert.NoError(t, err, transport)
+
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		assert.Len(t, lines, 1, transport)
+		var r scheme.Read
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &r))
+		assert.Equal(t, "temp-1", r.Device, transport)
+	}
+}
+
+func TestRun_ReadCache(t *testing.T) {
+	server := newServer(t)
+
+	out, err := runTool(t, nil, "-address", server.Address, "-o", "json", "read-cache", "-start", "-1h")
+	assert.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(out), "\n"), 2)
+
+	_, err = runTool(t, nil, "-address", server.Address, "read-cache", "-start", "yesterday")
+	assert.EqualError(t, err, `invalid time "yesterday", expected an RFC3339 timestamp or a duration`)
+}
+
+func TestRun_Config(t *testing.T) {
+	server := newServer(t)
+
+	path := filepath.Join(t.TempDir(), "synsectl.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+transport: websocket
+address: localhost:1
+websocket:
+  request_timeout: 5s
+http:
+  retry:
+    count: 0
+`), 0600))
+
+	// The address from the environment overrides the one from the options
+	// file.
+	env := map[string]string{
+		"SYNSE_CONFIG":          path,
+		"SYNSE_ADDRESS":         server.Address,
+		"SYNSE_OUTPUT":          "json",
+		"SYNSE_TLS_SKIP_VERIFY": "true",
+	}
+	out, err := runTool(t, env, "status")
+	assert.NoError(t, err)
+	assert.Contains(t, out, `"status": "ok"`)
+
+	fs, f := newFlags(t, "-config", path, "-transport", "http", "-o", "yaml")
+	cfg, err := loadConfig(fs, f, func(k string) string { return env[k] })
+	assert.NoError(t, err)
+	assert.Equal(t, "http", cfg.Transport)
+	assert.Equal(t, "yaml", cfg.Output)
+	assert.Equal(t, server.Address, cfg.Address)
+	assert.Equal(t, 5*time.Second, cfg.WebSocket.RequestTimeout)
+	assert.Equal(t, uint(0), cfg.HTTP.Retry.Count)
+	assert.True(t, cfg.TLS.SkipVerify)
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+
+	// Flags override the environment.
+	fs, f = newFlags(t, "-config", path, "-insecure=false", "-tls", "-cert", "cert.pem", "-key", "key.pem")
+	cfg, err = loadConfig(fs, f, func(k string) string { return env[k] })
+	assert.NoError(t, err)
+	assert.False(t, cfg.TLS.SkipVerify)
+	assert.True(t, cfg.TLS.Enabled)
+	assert.Equal(t, "cert.pem", cfg.TLS.CertFile)
+	assert.Equal(t, "key.pem", cfg.TLS.KeyFile)
+
+	fs, f = newFlags(t, "status")
+	cfg, err = loadConfig(fs, f, func(string) string { return "" })
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5000", cfg.Address)
+	assert.Equal(t, "", cfg.Transport)
+}
+
+func TestRun_TransportFromAddress(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{
+		Server: scheme.Config{Transport: scheme.TransportOptions{WebSocket: true}},
+	})
+	defer server.Close()
+
+	// The transport is taken from the address scheme when it is not set.
+	out, err := runTool(t, nil, "-address", "ws://"+server.Address, "-o", "json", "status")
+	assert.NoError(t, err)
+	assert.Contains(t, out, `"status": "ok"`)
+
+	_, err = runTool(t, nil, "-address", server.Address, "status")
+	assert.EqualError(t, err, "synse: synse server does not serve the http transport")
+}
+
+func TestRun_Errors(t *testing.T) {
+	_, err := runTool(t, nil, "frobnicate")
+	assert.EqualError(t, err, `unknown command "frobnicate", see synsectl -help`)
+
+	_, err = runTool(t, nil, "-transport", "smoke-signals", "status")
+	assert.EqualError(t, err, `invalid options: transport: unknown transport "smoke-signals"`)
+
+	_, err = runTool(t, map[string]string{"SYNSE_TLS_ENABLED": "maybe"}, "status")
+	assert.EqualError(t, err, `invalid SYNSE_TLS_ENABLED: tls.enabled: invalid boolean "maybe"`)
+
+	_, err = runTool(t, nil, "-o", "xml", "status")
+	assert.EqualError(t, err, `unknown output format "xml", expected table, json or yaml`)
+
+	_, err = runTool(t, map[string]string{"SYNSE_TIMEOUT": "soon"}, "status")
+	assert.Error(t, err)
+
+	server := newServer(t)
+	_, err = runTool(t, nil, "-address", server.Address, "info", "led-2")
+	assert.Error(t, err)
+}
+
+// newFlags parses the global flags from the arguments.
+func newFlags(t *testing.T, args ...string) (*flag.FlagSet, *flags) {
+	fs := flag.NewFlagSet("synsectl", flag.ContinueOnError)
+	f := registerFlags(fs)
+	assert.NoError(t, fs.Parse(args))
+	return fs, f
+}
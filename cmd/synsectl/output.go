@@ -0,0 +1,118 @@
+package main
+
+// output.go prints command results as a table, JSON or YAML.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// printer prints command results in the configured output format.
+type printer struct {
+	// format is the output format, either table, json or yaml.
+	format string
+
+	// w is where the output is written.
+	w io.Writer
+
+	// yaml is the encoder for YAML output, which separates the documents of
+	// a stream.
+	yaml *yaml.Encoder
+
+	// header is set once the header of a streamed table was printed.
+	header bool
+}
+
+// table is the tabular form of a command result.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// newPrinter creates a printer for the output format.
+func newPrinter(format string, w io.Writer) (*printer, error) {
+	switch format {
+	case "table", "json", "yaml":
+	default:
+		return nil, errors.Errorf("unknown output format %q, expected table, json or yaml", format)
+	}
+	return &printer{
+		format: format,
+		w:      w,
+		yaml:   yaml.NewEncoder(w),
+	}, nil
+}
+
+// print prints a command result. The table is used for table output, whereas
+// the value is used for JSON and YAML output. A nil table prints the value as
+// YAML for table output as well.
+func (p *printer) print(v interface{}, t *table) error {
+	switch {
+	case p.format == "json":
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to encode output")
+		}
+		_, err = fmt.Fprintln(p.w, string(out))
+		return err
+	case p.format == "yaml" || t == nil:
+		return p.encodeYAML(v)
+	default:
+		tw := tabwriter.NewWriter(p.w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// printItem prints a single item of a streamed result, as soon as it comes in.
+// JSON output has an item per line, YAML output a document per item and table
+// output a row per item.
+func (p *printer) printItem(v interface{}, header, row []string) error {
+	switch p.format {
+	case "json":
+		out, err := json.Marshal(v)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode output")
+		}
+		_, err = fmt.Fprintln(p.w, string(out))
+		return err
+	case "yaml":
+		return p.encodeYAML(v)
+	default:
+		// Rows can not be aligned with rows yet to come, so columns are
+		// padded to a minimum width instead.
+		tw := tabwriter.NewWriter(p.w, 16, 8, 2, ' ', 0)
+		if !p.header {
+			p.header = true
+			fmt.Fprintln(tw, strings.Join(header, "\t"))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+		return tw.Flush()
+	}
+}
+
+// encodeYAML writes the value as a YAML document.
+func (p *printer) encodeYAML(v interface{}) error {
+	if err := p.yaml.Encode(v); err != nil {
+		return errors.Wrap(err, "failed to encode output")
+	}
+	return nil
+}
+
+// fieldTable creates the table of a single object, with a row per field.
+func fieldTable(fields ...string) *table {
+	t := &table{header: []string{"FIELD", "VALUE"}}
+	for i := 0; i+1 < len(fields); i += 2 {
+		t.rows = append(t.rows, []string{fields[i], fields[i+1]})
+	}
+	return t
+}
@@ -67,6 +67,18 @@ func (s *HTTPServer) ServeVersioned(t *testing.T, uri string, statusCode int, re
 	serve(s.mux, t, fmt.Sprintf("/%v%v", s.version, uri), statusCode, response)
 }
 
+// HandleUnversioned registers a handler for an unversioned endpoint, for
+// tests which need more than a canned response.
+func (s *HTTPServer) HandleUnversioned(uri string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(uri, handler)
+}
+
+// HandleVersioned registers a handler for a versioned endpoint, for tests
+// which need more than a canned response.
+func (s *HTTPServer) HandleVersioned(uri string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(fmt.Sprintf("/%v%v", s.version, uri), handler)
+}
+
 // SetTLS starts TLS using the configured options.
 func (s *HTTPServer) SetTLS(cfg *tls.Config) {
 	s.tls = cfg
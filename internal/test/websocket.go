@@ -6,8 +6,10 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 
 	"github.com/gorilla/websocket"
 )
@@ -36,34 +38,60 @@ type WebSocketServer struct {
 
 	// entryRoute is the entry route to start the websocket connection.
 	entryRoute string
+
+	// mu guards conns and authorize.
+	mu sync.Mutex
+
+	// conns holds the open connections served by ServeFunc.
+	conns map[*websocket.Conn]struct{}
+
+	// authorize, if set, reports whether a handshake is authorized.
+	authorize func(r *http.Request) bool
 }
 
 // NewWebSocketServerV3 returns an instance of a mock websocket server for v3 API.
 func NewWebSocketServerV3() *WebSocketServer {
-	m := http.NewServeMux()
-	s := httptest.NewServer(m)
-
-	return &WebSocketServer{
-		URL:        s.URL[7:], // remove `http://` prefix
-		server:     s,
-		mux:        m,
+	s := &WebSocketServer{
+		mux:        http.NewServeMux(),
 		version:    "v3",
 		entryRoute: "connect",
 	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL[7:] // remove `http://` prefix
+	return s
 }
 
 // NewWebSocketTLSServerV3 returns an instance of a mock websocket tls server for v3 API.
 func NewWebSocketTLSServerV3() *WebSocketServer {
-	m := http.NewServeMux()
-	s := httptest.NewTLSServer(m)
-
-	return &WebSocketServer{
-		URL:        s.URL[8:], // remove `https://` prefix
-		server:     s,
-		mux:        m,
+	s := &WebSocketServer{
+		mux:        http.NewServeMux(),
 		version:    "v3",
 		entryRoute: "connect",
 	}
+	s.server = httptest.NewTLSServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL[8:] // remove `https://` prefix
+	return s
+}
+
+// Authorize makes the server reject the handshakes for which authorize
+// returns false with a 401 response.
+func (s *WebSocketServer) Authorize(authorize func(r *http.Request) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authorize = authorize
+}
+
+// handle serves a request, if it is authorized.
+func (s *WebSocketServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	authorize := s.authorize
+	s.mu.Unlock()
+
+	if authorize != nil && !authorize(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
 }
 
 // Serve reads a request event and writes back a given response.
@@ -135,6 +163,99 @@ func (s *WebSocketServer) Stream(responses []string) {
 	)
 }
 
+// ServeFunc reads request events and writes back the responses returned by
+// the handler for each of them. Every request is handled in its own goroutine,
+// so responses may be written in a different order than the requests were
+// read.
+func (s *WebSocketServer) ServeFunc(handler func(request []byte) []string) {
+	s.mux.HandleFunc(
+		fmt.Sprintf("/%s/%s", s.version, s.entryRoute),
+		func(w http.ResponseWriter, r *http.Request) {
+			c, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+
+			s.track(c)
+			defer func() {
+				s.untrack(c)
+				err := c.Close()
+				if err != nil {
+					return
+				}
+			}()
+
+			var mu sync.Mutex
+			for {
+				_, req, err := c.ReadMessage()
+				if err != nil {
+					return
+				}
+
+				go func() {
+					for _, resp := range handler(req) {
+						mu.Lock()
+						err := c.WriteMessage(websocket.TextMessage, []byte(resp))
+						mu.Unlock()
+						if err != nil {
+							return
+						}
+					}
+				}()
+			}
+		},
+	)
+}
+
+// Stall accepts connections but never reads from or writes to them, like a
+// peer which silently went away. Pings sent to it are never answered.
+func (s *WebSocketServer) Stall() {
+	s.mux.HandleFunc(
+		fmt.Sprintf("/%s/%s", s.version, s.entryRoute),
+		func(w http.ResponseWriter, r *http.Request) {
+			c, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+
+			// Drain the raw connection without handling any frames, until
+			// the client goes away.
+			_, _ = io.Copy(io.Discard, c.UnderlyingConn())
+			_ = c.Close()
+		},
+	)
+}
+
+// DropConnections abruptly closes all of the open connections served by
+// ServeFunc, without a close handshake, as if the server went away.
+func (s *WebSocketServer) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		_ = c.Close()
+	}
+}
+
+// track records an open connection.
+func (s *WebSocketServer) track(c *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conns == nil {
+		s.conns = make(map[*websocket.Conn]struct{})
+	}
+	s.conns[c] = struct{}{}
+}
+
+// untrack removes a closed connection.
+func (s *WebSocketServer) untrack(c *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conns, c)
+}
+
 // SetTLS starts TLS using the configured options.
 func (s *WebSocketServer) SetTLS(cfg *tls.Config) {
 	s.tls = cfg
@@ -0,0 +1,136 @@
+package synse
+
+// address.go parses the Synse Server addresses shared by the clients.
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// address is a parsed Synse Server address.
+type address struct {
+	// scheme is `http` or `https` if the address has a scheme, or empty if
+	// it does not. The `ws` and `wss` schemes are the same as `http` and
+	// `https`, so that an address can be shared by both clients.
+	scheme string
+
+	// host is the `host[:port]` of the address. An IPv6 literal is in
+	// brackets.
+	host string
+
+	// path is the base path Synse Server is served under, e.g. behind a
+	// reverse proxy. It is empty or starts with a slash, and has no trailing
+	// slash.
+	path string
+
+	// socket is the path of the unix socket of a `unix://` address. Its
+	// host then stands in for the socket (see unixHost).
+	socket string
+}
+
+// addressSchemes maps the schemes an address can have to the scheme of the
+// parsed address.
+var addressSchemes = map[string]string{
+	"http":  "http",
+	"ws":    "http",
+	"https": "https",
+	"wss":   "https",
+}
+
+// parseAddress parses a Synse Server address. It is either a `host[:port]`,
+// e.g. `localhost:5000` or `[::1]:5000`, or a URL with the http, https, ws or
+// wss scheme, e.g. `https://gw.example/synse`. Both forms can have a base
+// path. A unix socket is given as `unix://` followed by its path, e.g.
+// `unix:///run/synse.sock`.
+func parseAddress(s string) (address, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return address{}, errors.New("address is empty")
+	}
+
+	if len(s) >= len("unix:") && strings.EqualFold(s[:len("unix:")], "unix:") {
+		socket := strings.TrimPrefix(s[len("unix:"):], "//")
+		if socket == "" {
+			return address{}, errors.Errorf("invalid address %q: no socket path", s)
+		}
+		return address{host: unixHost(socket), socket: socket}, nil
+	}
+
+	raw := s
+	// A bare IPv6 literal is bracketed, so that it is not read as having a
+	// port.
+	if ip := net.ParseIP(s); ip != nil && strings.Contains(s, ":") {
+		raw = "[" + s + "]"
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "//" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return address{}, errors.Errorf("invalid address %q", s)
+	}
+
+	a := address{host: u.Host}
+	if u.Scheme != "" {
+		scheme, ok := addressSchemes[u.Scheme]
+		if !ok {
+			return address{}, errors.Errorf("invalid address %q: unsupported scheme %q", s, u.Scheme)
+		}
+		a.scheme = scheme
+	}
+	if u.Hostname() == "" {
+		return address{}, errors.Errorf("invalid address %q: no host", s)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return address{}, errors.Errorf("invalid address %q: only a scheme, host, port and path can be given", s)
+	}
+
+	a.path = strings.TrimRight(u.Path, "/")
+	return a, nil
+}
+
+// parseAddresses parses the addresses of the options, keyed by the address
+// they were parsed from. An address with the https or wss scheme enables TLS.
+func parseAddresses(opts *Options) (map[string]address, error) {
+	if opts == nil {
+		return nil, nil
+	}
+
+	addresses := map[string]address{}
+	for _, s := range append([]string{opts.Address}, opts.Addresses...) {
+		if s == "" {
+			continue
+		}
+		a, err := parseAddress(s)
+		if err != nil {
+			return nil, err
+		}
+		if a.scheme == "https" {
+			opts.TLS.Enabled = true
+		}
+		addresses[s] = a
+	}
+	return addresses, nil
+}
+
+// url returns the URL of the path under the address. The scheme of the
+// address is used if it has one, otherwise the secure scheme is used if TLS
+// is enabled and the plain one if not.
+func (a address) url(plain, secure string, tls bool, path ...string) string {
+	scheme := plain
+	if a.scheme == "https" || (a.scheme == "" && tls) {
+		scheme = secure
+	}
+
+	p := a.path
+	for _, c := range path {
+		if c = strings.Trim(c, "/"); c != "" {
+			p += "/" + c
+		}
+	}
+	return buildURL(scheme, a.host, p)
+}
@@ -0,0 +1,114 @@
+package synse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected address
+	}{
+		{in: "localhost:5000", expected: address{host: "localhost:5000"}},
+		{in: " localhost ", expected: address{host: "localhost"}},
+		{in: "10.1.1.10:5000/synse/", expected: address{host: "10.1.1.10:5000", path: "/synse"}},
+		{in: "[::1]:5000", expected: address{host: "[::1]:5000"}},
+		{in: "::1", expected: address{host: "[::1]"}},
+		{in: "fe80::1", expected: address{host: "[fe80::1]"}},
+		{in: "http://localhost:5000", expected: address{scheme: "http", host: "localhost:5000"}},
+		{in: "ws://localhost:5000/", expected: address{scheme: "http", host: "localhost:5000"}},
+		{in: "https://gw.example/synse", expected: address{scheme: "https", host: "gw.example", path: "/synse"}},
+		{in: "WSS://[::1]:5000/a/b", expected: address{scheme: "https", host: "[::1]:5000", path: "/a/b"}},
+	}
+
+	for _, tt := range tests {
+		a, err := parseAddress(tt.in)
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.expected, a, tt.in)
+	}
+}
+
+func TestParseAddress_Error(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{in: "", expected: `address is empty`},
+		{in: "grpc://localhost:5000", expected: `invalid address "grpc://localhost:5000": unsupported scheme "grpc"`},
+		{in: "http://", expected: `invalid address "http://": no host`},
+		{in: "http:///synse", expected: `invalid address "http:///synse": no host`},
+		{in: "localhost:port", expected: `invalid address "localhost:port"`},
+		{in: "localhost:5000?a=b", expected: `invalid address "localhost:5000?a=b": only a scheme, host, port and path can be given`},
+		{in: "user:pass@localhost:5000", expected: `invalid address "user:pass@localhost:5000": only a scheme, host, port and path can be given`},
+	}
+
+	for _, tt := range tests {
+		_, err := parseAddress(tt.in)
+		assert.EqualError(t, err, tt.expected, tt.in)
+	}
+}
+
+func TestAddress_URL(t *testing.T) {
+	a := address{host: "gw.example", path: "/synse"}
+	assert.Equal(t, "http://gw.example/synse/test", a.url("http", "https", false, testURI))
+	assert.Equal(t, "https://gw.example/synse/v3/scan", a.url("http", "https", true, "v3", scanURI))
+	assert.Equal(t, "wss://gw.example/synse/v3/connect", a.url("ws", "wss", true, "v3", "connect"))
+	assert.Equal(t, "http://gw.example/synse", a.url("http", "https", false))
+
+	// The scheme of the address overrides the TLS configuration.
+	a = address{scheme: "http", host: "[::1]:5000"}
+	assert.Equal(t, "ws://[::1]:5000/v3/connect", a.url("ws", "wss", true, "v3", "connect"))
+	a = address{scheme: "https", host: "localhost:5000"}
+	assert.Equal(t, "https://localhost:5000/test", a.url("http", "https", false, testURI))
+}
+
+func TestParseAddresses(t *testing.T) {
+	opts := &Options{
+		Address:   "localhost:5000",
+		Addresses: []string{"", "wss://gw.example/synse"},
+	}
+	addresses, err := parseAddresses(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]address{
+		"localhost:5000":         {host: "localhost:5000"},
+		"wss://gw.example/synse": {scheme: "https", host: "gw.example", path: "/synse"},
+	}, addresses)
+	assert.True(t, opts.TLS.Enabled)
+
+	_, err = NewWebSocketClientV3(&Options{Address: "ftp://localhost"})
+	assert.EqualError(t, err, `failed to create a websocket client: invalid address "ftp://localhost": unsupported scheme "ftp"`)
+}
+
+func TestClients_PathPrefix(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{})
+	defer server.Close()
+
+	// Synse Server is served under `/synse` by a reverse proxy.
+	target := &url.URL{Scheme: "http", Host: server.Address}
+	proxy := httptest.NewServer(http.StripPrefix("/synse", httputil.NewSingleHostReverseProxy(target)))
+	defer proxy.Close()
+
+	httpClient, err := NewHTTPClientV3(&Options{Address: proxy.URL + "/synse/"})
+	assert.NoError(t, err)
+	status, err := httpClient.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", status.Status)
+	version, err := httpClient.Version()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, version.APIVersion)
+
+	wsClient, err := NewWebSocketClientV3(&Options{Address: "ws" + proxy.URL[4:] + "/synse"})
+	assert.NoError(t, err)
+	assert.NoError(t, wsClient.Open())
+	defer wsClient.Close()
+	status, err = wsClient.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", status.Status)
+}
@@ -0,0 +1,165 @@
+package synse
+
+// auth.go provides the authentication of the requests made to Synse Server.
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tokenExpiryDelta is how long before its expiry a token is refreshed, so
+// that it does not expire in flight.
+const tokenExpiryDelta = 10 * time.Second
+
+// AuthProvider provides the credentials of the requests made to Synse
+// Server, e.g. for an authenticating ingress in front of it. The credentials
+// are added to every HTTP request, and to the handshake of the websocket
+// connection.
+type AuthProvider interface {
+	// Apply adds the credentials to the header of a request.
+	Apply(ctx context.Context, header http.Header) error
+}
+
+// AuthRefresher is an AuthProvider whose credentials can be refreshed. A
+// request which is rejected as unauthorized (401) is made once more after
+// refreshing the credentials.
+type AuthRefresher interface {
+	AuthProvider
+
+	// Refresh refreshes the credentials.
+	Refresh(ctx context.Context) error
+}
+
+// headerAuth is an AuthProvider which sets static header values.
+type headerAuth http.Header
+
+// Apply implements the AuthProvider interface.
+func (a headerAuth) Apply(_ context.Context, header http.Header) error {
+	for k, v := range a {
+		header[k] = append([]string(nil), v...)
+	}
+	return nil
+}
+
+// HeaderAuth returns an AuthProvider which sets the given header values,
+// e.g. for an API key.
+func HeaderAuth(header http.Header) AuthProvider {
+	return headerAuth(header.Clone())
+}
+
+// BearerToken returns an AuthProvider which authenticates with a static
+// bearer token.
+func BearerToken(token string) AuthProvider {
+	return HeaderAuth(http.Header{"Authorization": {"Bearer " + token}})
+}
+
+// BasicAuth returns an AuthProvider which authenticates with HTTP basic
+// authentication.
+func BasicAuth(username, password string) AuthProvider {
+	creds := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return HeaderAuth(http.Header{"Authorization": {"Basic " + creds}})
+}
+
+// Token is a bearer token.
+type Token struct {
+	// Value is the value of the token.
+	Value string
+
+	// Expiry is the time the token expires. Zero value means the token does
+	// not expire.
+	Expiry time.Time
+}
+
+// TokenSource returns a new bearer token, e.g. from an identity provider.
+type TokenSource func(ctx context.Context) (*Token, error)
+
+// tokenAuth is an AuthRefresher for the tokens of a TokenSource.
+type tokenAuth struct {
+	// source is the source of the tokens.
+	source TokenSource
+
+	// now returns the current time.
+	now func() time.Time
+
+	// mu guards token.
+	mu sync.Mutex
+
+	// token is the current token.
+	token *Token
+}
+
+// TokenAuth returns an AuthRefresher which authenticates with the bearer
+// tokens of the source. A token is used until shortly before it expires, or
+// until a request is rejected as unauthorized, and a new one is then taken
+// from the source.
+func TokenAuth(source TokenSource) AuthRefresher {
+	return &tokenAuth{
+		source: source,
+		now:    time.Now,
+	}
+}
+
+// Apply implements the AuthProvider interface.
+func (a *tokenAuth) Apply(ctx context.Context, header http.Header) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token == nil || (!a.token.Expiry.IsZero() && !a.now().Add(tokenExpiryDelta).Before(a.token.Expiry)) {
+		if err := a.fetch(ctx); err != nil {
+			return err
+		}
+	}
+	header.Set("Authorization", "Bearer "+a.token.Value)
+	return nil
+}
+
+// Refresh implements the AuthRefresher interface.
+func (a *tokenAuth) Refresh(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.fetch(ctx)
+}
+
+// fetch takes a new token from the source. It must be called with mu held.
+func (a *tokenAuth) fetch(ctx context.Context) error {
+	token, err := a.source(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get a token")
+	}
+	if token == nil {
+		return errors.New("failed to get a token: token source returned no token")
+	}
+	a.token = token
+	return nil
+}
+
+// applyAuth adds the credentials of the provider, if any, to the header.
+func applyAuth(ctx context.Context, auth AuthProvider, header http.Header) error {
+	if auth == nil {
+		return nil
+	}
+	if err := auth.Apply(ctx, header); err != nil {
+		return errors.Wrap(err, "failed to apply credentials")
+	}
+	return nil
+}
+
+// refreshAuth refreshes the credentials of the provider after a request was
+// rejected as unauthorized. It reports whether the request should be made
+// again, which is when the provider could refresh them.
+func refreshAuth(ctx context.Context, auth AuthProvider) (bool, error) {
+	r, ok := auth.(AuthRefresher)
+	if !ok {
+		return false, nil
+	}
+	if err := r.Refresh(ctx); err != nil {
+		return false, errors.Wrap(err, "failed to refresh credentials")
+	}
+	return true, nil
+}
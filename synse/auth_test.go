@@ -0,0 +1,198 @@
+package synse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/internal/test"
+)
+
+func TestStaticAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     AuthProvider
+		expected http.Header
+	}{
+		{
+			name:     "bearer token",
+			auth:     BearerToken("abc"),
+			expected: http.Header{"Authorization": {"Bearer abc"}},
+		},
+		{
+			name:     "basic auth",
+			auth:     BasicAuth("user", "pass"),
+			expected: http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}},
+		},
+		{
+			name:     "header",
+			auth:     HeaderAuth(http.Header{"X-Api-Key": {"key"}}),
+			expected: http.Header{"X-Api-Key": {"key"}},
+		},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{}
+		assert.NoError(t, tt.auth.Apply(context.Background(), header), tt.name)
+		assert.Equal(t, tt.expected, header, tt.name)
+
+		// The static providers can not be refreshed.
+		_, ok := tt.auth.(AuthRefresher)
+		assert.False(t, ok, tt.name)
+	}
+}
+
+func TestTokenAuth(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	var fetched int32
+	auth := TokenAuth(func(ctx context.Context) (*Token, error) {
+		n := atomic.AddInt32(&fetched, 1)
+		return &Token{
+			Value:  fmt.Sprintf("token-%d", n),
+			Expiry: clock.Now().Add(time.Minute),
+		}, nil
+	})
+	auth.(*tokenAuth).now = clock.Now
+
+	apply := func() string {
+		header := http.Header{}
+		assert.NoError(t, auth.Apply(context.Background(), header))
+		return header.Get("Authorization")
+	}
+
+	// The token is used until shortly before it expires.
+	assert.Equal(t, "Bearer token-1", apply())
+	assert.Equal(t, "Bearer token-1", apply())
+	clock.Advance(time.Minute - tokenExpiryDelta)
+	assert.Equal(t, "Bearer token-2", apply())
+
+	// A refresh takes a new token.
+	assert.NoError(t, auth.Refresh(context.Background()))
+	assert.Equal(t, "Bearer token-3", apply())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&fetched))
+}
+
+func TestTokenAuth_Error(t *testing.T) {
+	auth := TokenAuth(func(ctx context.Context) (*Token, error) {
+		return nil, errors.New("identity provider unavailable")
+	})
+
+	err := auth.Apply(context.Background(), http.Header{})
+	assert.EqualError(t, err, "failed to get a token: identity provider unavailable")
+
+	auth = TokenAuth(func(ctx context.Context) (*Token, error) {
+		return nil, nil
+	})
+	assert.Error(t, auth.Apply(context.Background(), http.Header{}))
+}
+
+// authorizeToken returns a function which reports whether a request carries
+// the current token, and a TokenSource whose first token is stale.
+func authorizeToken() (func(r *http.Request) bool, TokenSource) {
+	var fetched int32
+	source := func(ctx context.Context) (*Token, error) {
+		return &Token{Value: fmt.Sprintf("token-%d", atomic.AddInt32(&fetched, 1))}, nil
+	}
+	authorize := func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer token-2"
+	}
+	return authorize, source
+}
+
+func TestHTTPClientV3_Auth(t *testing.T) {
+	authorize, source := authorizeToken()
+	var requests int32
+
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+	server.HandleUnversioned("/test", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if !authorize(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fprintf(t, w, `{"http_code":401,"description":"unauthorized","context":"invalid token"}`)
+			return
+		}
+		fprintf(t, w, `{"status":"ok","timestamp":"2019-01-24T14:34:24Z"}`)
+	})
+
+	// The request is made once more with a refreshed token.
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+		Auth:    TokenAuth(source),
+	})
+	assert.NoError(t, err)
+
+	status, err := client.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", status.Status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// Credentials which can not be refreshed fail the request.
+	client, err = NewHTTPClientV3(&Options{
+		Address: server.URL,
+		Auth:    BearerToken("token-1"),
+	})
+	assert.NoError(t, err)
+
+	status, err = client.Status()
+	assert.Nil(t, status)
+	assert.True(t, IsUnauthorized(err))
+	assert.False(t, IsTransport(err))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestHTTPClientV3_AuthError(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+		Auth: TokenAuth(func(ctx context.Context) (*Token, error) {
+			return nil, errors.New("identity provider unavailable")
+		}),
+	})
+	assert.NoError(t, err)
+
+	status, err := client.Status()
+	assert.Nil(t, status)
+	assert.EqualError(t, err, "failed to request `/test` endpoint: failed to apply credentials: failed to get a token: identity provider unavailable")
+}
+
+func TestWebSocketClientV3_Auth(t *testing.T) {
+	authorize, source := authorizeToken()
+
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+	server.Authorize(authorize)
+	server.Serve(`{"id":1,"event":"response/status","data":{"status":"ok","timestamp":"2019-03-20T17:37:07Z"}}`)
+
+	// The handshake is made once more with a refreshed token.
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+		Auth:    TokenAuth(source),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Open())
+	defer client.Close()
+
+	status, err := client.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", status.Status)
+
+	// Credentials which can not be refreshed fail the handshake.
+	client, err = NewWebSocketClientV3(&Options{
+		Address: server.URL,
+		Auth:    BasicAuth("user", "pass"),
+	})
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.True(t, IsUnauthorized(err))
+	assert.False(t, IsTransport(err))
+}
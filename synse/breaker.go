@@ -0,0 +1,219 @@
+package synse
+
+// breaker.go provides the circuit breaker of a client.
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+// States of a circuit breaker.
+const (
+	// BreakerClosed lets all operations through.
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen fails all operations immediately.
+	BreakerOpen
+
+	// BreakerHalfOpen lets a limited number of trial operations through.
+	BreakerHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitState returns the state of the circuit breaker of the client. It
+// returns false if the client has no circuit breaker.
+func CircuitState(client Client) (BreakerState, bool) {
+	c, ok := client.(*middlewareClient)
+	if !ok || c.breaker == nil {
+		return BreakerClosed, false
+	}
+	return c.breaker.State(), true
+}
+
+// breaker is a circuit breaker.
+type breaker struct {
+	// options holds the breaker options.
+	options BreakerOptions
+
+	// now returns the current time.
+	now func() time.Time
+
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// state is the state of the breaker.
+	state BreakerState
+
+	// failures counts the consecutive failures while closed.
+	failures uint
+
+	// opened is the time the breaker last opened.
+	opened time.Time
+
+	// trials counts the trial operations in flight while half-open.
+	trials uint
+}
+
+// newBreaker creates a closed circuit breaker. It returns nil if the breaker
+// is not enabled.
+func newBreaker(opts BreakerOptions) *breaker {
+	if !opts.Enabled {
+		return nil
+	}
+	// A trial operation has to be let through, or a half-open breaker would
+	// never close again.
+	if opts.HalfOpenRequests == 0 {
+		opts.HalfOpenRequests = 1
+	}
+	return &breaker{
+		options: opts,
+		now:     time.Now,
+	}
+}
+
+// State returns the state of the breaker. An open breaker whose cool-down is
+// over is reported as half-open.
+func (b *breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.opened) >= b.options.CoolDown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// middleware returns the middleware which fails the operations while the
+// breaker is open, and records their outcome.
+func (b *breaker) middleware(next Handler) Handler {
+	return func(ctx context.Context, op *Operation) (interface{}, error) {
+		trial, err := b.allow(op.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next(ctx, op)
+		b.record(trial, err)
+		return resp, err
+	}
+}
+
+// allow reports whether an operation may be attempted, and whether it is a
+// trial operation.
+func (b *breaker) allow(operation string) (bool, error) {
+	b.mu.Lock()
+	halfOpened := false
+	defer func() {
+		b.mu.Unlock()
+		if halfOpened {
+			b.notify(BreakerOpen, BreakerHalfOpen)
+		}
+	}()
+
+	switch b.state {
+	case BreakerClosed:
+		return false, nil
+
+	case BreakerOpen:
+		if wait := b.options.CoolDown - b.now().Sub(b.opened); wait > 0 {
+			return false, errors.Wrapf(ErrCircuitOpen, "%s was not attempted, retry after %v", operation, wait)
+		}
+		b.state = BreakerHalfOpen
+		b.trials = 0
+		halfOpened = true
+	}
+
+	// A read stream may never end, so it is not a trial: it would hold its
+	// place for as long as it runs.
+	if operation == "ReadStream" {
+		return false, nil
+	}
+	if b.trials >= b.options.HalfOpenRequests {
+		return false, errors.Wrapf(ErrCircuitOpen, "%s was not attempted while trial operations are in flight", operation)
+	}
+	b.trials++
+	return true, nil
+}
+
+// record records the outcome of an operation.
+func (b *breaker) record(trial bool, err error) {
+	b.mu.Lock()
+	from := b.state
+	failed := isFailure(err)
+
+	switch b.state {
+	case BreakerClosed:
+		if failed {
+			b.failures++
+			if b.failures >= b.options.FailureThreshold {
+				b.open()
+			}
+		} else if err == nil {
+			b.failures = 0
+		}
+
+	case BreakerHalfOpen:
+		// Only the trial operations decide whether the breaker closes.
+		if !trial {
+			break
+		}
+		// A trial from before the breaker last opened may end while a
+		// later round of trials is in flight.
+		if b.trials > 0 {
+			b.trials--
+		}
+		if failed {
+			b.open()
+		} else if err == nil {
+			b.state = BreakerClosed
+			b.failures = 0
+		}
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	if from != to {
+		b.notify(from, to)
+	}
+}
+
+// open opens the breaker. It must be called with mu held.
+func (b *breaker) open() {
+	b.state = BreakerOpen
+	b.opened = b.now()
+	b.failures = 0
+}
+
+// notify calls the state change callback, if any.
+func (b *breaker) notify(from, to BreakerState) {
+	if b.options.OnStateChange != nil {
+		b.options.OnStateChange(from, to)
+	}
+}
+
+// isFailure reports whether the error shows that Synse Server is failing,
+// as opposed to an error for the request itself, or a cancellation or
+// deadline of the caller's context.
+func isFailure(err error) bool {
+	return IsTransport(err) || errors.Is(err, ErrTimeout) || IsServerError(err)
+}
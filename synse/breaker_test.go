@@ -0,0 +1,227 @@
+package synse
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/internal/test"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Now returns the time of the clock.
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestBreaker(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	var failing atomic.Bool
+	var hits int32
+	server.HandleVersioned("/scan", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			fprintf(t, w, `{"http_code":500,"description":"unknown","context":"plugin failure"}`)
+			return
+		}
+		fprintf(t, w, `[]`)
+	})
+	server.ServeVersioned(t, "/info/dev-1", 404, `{"http_code":404,"description":"not found","context":"no device"}`)
+
+	var transitions []string
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+		Breaker: BreakerOptions{
+			Enabled:          true,
+			FailureThreshold: 2,
+			CoolDown:         time.Minute,
+			OnStateChange: func(from, to BreakerState) {
+				transitions = append(transitions, from.String()+" -> "+to.String())
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	clock := &fakeClock{now: time.Now()}
+	client.(*middlewareClient).breaker.now = clock.Now
+
+	state, ok := CircuitState(client)
+	assert.True(t, ok)
+	assert.Equal(t, BreakerClosed, state)
+
+	// API errors other than server errors do not count as failures.
+	failing.Store(true)
+	_, err = client.Scan(scheme.ScanOptions{})
+	assert.True(t, IsServerError(err))
+	_, err = client.Info("dev-1")
+	assert.True(t, IsNotFound(err))
+	state, _ = CircuitState(client)
+	assert.Equal(t, BreakerClosed, state)
+
+	_, err = client.Scan(scheme.ScanOptions{})
+	assert.True(t, IsServerError(err))
+	state, _ = CircuitState(client)
+	assert.Equal(t, BreakerOpen, state)
+
+	// While open, operations fail without reaching the server.
+	before := atomic.LoadInt32(&hits)
+	_, err = client.Scan(scheme.ScanOptions{})
+	assert.True(t, IsCircuitOpen(err))
+	assert.EqualError(t, err, "Scan was not attempted, retry after 1m0s: synse: circuit breaker is open")
+	assert.Equal(t, before, atomic.LoadInt32(&hits))
+
+	// After the cool-down, a failed trial opens the breaker again.
+	clock.Advance(time.Minute)
+	state, _ = CircuitState(client)
+	assert.Equal(t, BreakerHalfOpen, state)
+	_, err = client.Scan(scheme.ScanOptions{})
+	assert.True(t, IsServerError(err))
+	state, _ = CircuitState(client)
+	assert.Equal(t, BreakerOpen, state)
+
+	// A successful trial closes it.
+	clock.Advance(time.Minute)
+	failing.Store(false)
+	_, err = client.Scan(scheme.ScanOptions{})
+	assert.NoError(t, err)
+	state, _ = CircuitState(client)
+	assert.Equal(t, BreakerClosed, state)
+
+	assert.Equal(t, []string{
+		"closed -> open",
+		"open -> half-open",
+		"half-open -> open",
+		"open -> half-open",
+		"half-open -> closed",
+	}, transitions)
+}
+
+func TestBreaker_Transport(t *testing.T) {
+	// Nothing listens on the address.
+	client, err := NewHTTPClientV3(&Options{
+		Address: "localhost:1",
+		HTTP: HTTPOptions{
+			Retry: RetryOptions{WaitTime: time.Millisecond, MaxWaitTime: time.Millisecond},
+		},
+		Breaker: BreakerOptions{
+			Enabled:          true,
+			FailureThreshold: 1,
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = client.Status()
+	assert.True(t, IsTransport(err))
+
+	_, err = client.Status()
+	assert.True(t, IsCircuitOpen(err))
+	assert.False(t, IsTransport(err))
+}
+
+func TestBreaker_HalfOpenRequests(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	b := newBreaker(BreakerOptions{Enabled: true, FailureThreshold: 1, CoolDown: time.Second, HalfOpenRequests: 1})
+	b.now = clock.Now
+
+	failure := newTransportError(errors.New("connection refused"), "failed")
+	_, err := b.allow("Status")
+	assert.NoError(t, err)
+	b.record(false, failure)
+	assert.Equal(t, BreakerOpen, b.State())
+
+	clock.Advance(time.Second)
+	trial, err := b.allow("Status")
+	assert.NoError(t, err)
+	assert.True(t, trial)
+
+	// Only one trial may be in flight.
+	_, err = b.allow("Scan")
+	assert.EqualError(t, err, "Scan was not attempted while trial operations are in flight: synse: circuit breaker is open")
+
+	// A trial which neither failed nor succeeded frees its place.
+	b.record(true, context.Canceled)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	trial, err = b.allow("Scan")
+	assert.NoError(t, err)
+	assert.True(t, trial)
+	b.record(true, nil)
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestBreaker_ReadStreamIsNotTrial(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	b := newBreaker(BreakerOptions{Enabled: true, FailureThreshold: 1, CoolDown: time.Second, HalfOpenRequests: 1})
+	b.now = clock.Now
+
+	_, err := b.allow("Status")
+	assert.NoError(t, err)
+	b.record(false, newTransportError(errors.New("connection refused"), "failed"))
+
+	// A stream still fails while the breaker is open.
+	_, err = b.allow("ReadStream")
+	assert.True(t, IsCircuitOpen(err))
+
+	// Once half-open, a stream does not take the place of a trial.
+	clock.Advance(time.Second)
+	trial, err := b.allow("ReadStream")
+	assert.NoError(t, err)
+	assert.False(t, trial)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+
+	// A stream failing to open does not decide the state either.
+	b.record(false, newTransportError(errors.New("connection refused"), "failed"))
+	assert.Equal(t, BreakerHalfOpen, b.State())
+
+	trial, err = b.allow("Status")
+	assert.NoError(t, err)
+	assert.True(t, trial)
+	b.record(true, nil)
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestIsFailure(t *testing.T) {
+	assert.False(t, isFailure(nil))
+	assert.False(t, isFailure(context.Canceled))
+	assert.False(t, isFailure(&LimitError{Operation: "Read", Budget: "all"}))
+	assert.False(t, isFailure(newAPIError(scheme.Error{HTTPCode: 404})))
+	assert.True(t, isFailure(newAPIError(scheme.Error{HTTPCode: 503})))
+	assert.False(t, isFailure(context.DeadlineExceeded))
+	assert.False(t, isFailure(errors.Wrap(context.DeadlineExceeded, "failed to request `/scan` endpoint")))
+	assert.True(t, isFailure(newAPIError(scheme.Error{HTTPCode: 408})))
+	assert.True(t, isFailure(errors.Wrap(ErrTimeout, "no response for request 3")))
+	assert.True(t, isFailure(newTransportError(errors.New("EOF"), "failed")))
+}
+
+func TestCircuitState_NoBreaker(t *testing.T) {
+	client, err := NewHTTPClientV3(&Options{
+		Address: "localhost:5000",
+	})
+	assert.NoError(t, err)
+
+	_, ok := CircuitState(client)
+	assert.False(t, ok)
+}
@@ -0,0 +1,166 @@
+package synse
+
+// client.go creates a client for the transport and API version of Synse
+// Server.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Transports of the clients.
+const (
+	// TransportHTTP is the transport of the HTTP client.
+	TransportHTTP = "http"
+
+	// TransportWebSocket is the transport of the websocket client.
+	TransportWebSocket = "websocket"
+)
+
+// supportedAPIVersions holds the versions of the Synse Server API the
+// clients support.
+var supportedAPIVersions = []string{"v3"}
+
+// IncompatibleError is returned by NewClient for a Synse Server the client
+// can not be used with.
+type IncompatibleError struct {
+	// APIVersion is the API version reported by Synse Server, if the client
+	// does not support it.
+	APIVersion string
+
+	// Transport is the transport of the client, if Synse Server does not
+	// serve it.
+	Transport string
+
+	// Err is the error which showed the incompatibility, if any, e.g. the
+	// rejected websocket handshake.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *IncompatibleError) Error() string {
+	if e.Transport != "" {
+		msg := fmt.Sprintf("synse: synse server does not serve the %s transport", e.Transport)
+		if e.Err != nil {
+			msg += ": " + e.Err.Error()
+		}
+		return msg
+	}
+	return fmt.Sprintf("synse: synse server API version %q is not supported, the client supports %s",
+		e.APIVersion, strings.Join(supportedAPIVersions, ", "))
+}
+
+// Is reports whether the target is ErrIncompatible.
+func (e *IncompatibleError) Is(target error) bool {
+	return target == ErrIncompatible
+}
+
+// Unwrap returns the error which showed the incompatibility.
+func (e *IncompatibleError) Unwrap() error {
+	return e.Err
+}
+
+// NewClient creates a client for Synse Server and opens it. Its transport is
+// the one of Options.Transport, or the one of the scheme of the primary
+// address if it is not set.
+//
+// Synse Server is then checked for compatibility: an IncompatibleError is
+// returned if its API version is not supported, or if its config reports
+// that the transport of the client is not enabled.
+func NewClient(opts *Options) (Client, error) {
+	return NewClientContext(context.Background(), opts)
+}
+
+// NewClientContext is like NewClient, but uses the given context to open the
+// client and for the compatibility checks.
+func NewClientContext(ctx context.Context, opts *Options) (Client, error) {
+	transport := clientTransport("", opts)
+	client, err := newTransportClient(transport, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.OpenContext(ctx); err != nil {
+		// Synse Server has no websocket route for an unsupported API version.
+		if transport == TransportWebSocket && IsNotFound(err) {
+			return nil, &IncompatibleError{Transport: transport, Err: err}
+		}
+		return nil, err
+	}
+
+	if err := checkCompatible(ctx, client, transport); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// clientTransport returns the transport of a client: the given one if it is
+// set, otherwise the one of the options, otherwise the one of the scheme of
+// the primary address.
+func clientTransport(transport string, opts *Options) string {
+	if transport == "" && opts != nil {
+		transport = opts.Transport
+	}
+	if transport == "ws" {
+		return TransportWebSocket
+	}
+	if transport != "" || opts == nil {
+		return transport
+	}
+
+	primary := opts.Address
+	if primary == "" && len(opts.Addresses) > 0 {
+		primary = opts.Addresses[0]
+	}
+	primary = strings.ToLower(strings.TrimSpace(primary))
+	if strings.HasPrefix(primary, "ws://") || strings.HasPrefix(primary, "wss://") {
+		return TransportWebSocket
+	}
+	return TransportHTTP
+}
+
+// newTransportClient creates a client with the given transport, which
+// defaults to that of the options (see clientTransport).
+func newTransportClient(transport string, opts *Options) (Client, error) {
+	switch transport = clientTransport(transport, opts); transport {
+	case "", TransportHTTP:
+		return NewHTTPClientV3(opts)
+	case TransportWebSocket:
+		return NewWebSocketClientV3(opts)
+	default:
+		return nil, errors.Errorf("unknown transport %q", transport)
+	}
+}
+
+// checkCompatible checks that the client can be used with Synse Server.
+func checkCompatible(ctx context.Context, client Client, transport string) error {
+	version, err := client.VersionContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the version of synse server")
+	}
+	supported := false
+	for _, v := range supportedAPIVersions {
+		supported = supported || v == version.APIVersion
+	}
+	if !supported {
+		return &IncompatibleError{APIVersion: version.APIVersion}
+	}
+
+	cfg, err := client.ConfigContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the config of synse server")
+	}
+	// A config which enables neither transport does not report them.
+	enabled := cfg.Transport
+	if !enabled.HTTP && !enabled.WebSocket {
+		return nil
+	}
+	if (transport == TransportHTTP && !enabled.HTTP) || (transport == TransportWebSocket && !enabled.WebSocket) {
+		return &IncompatibleError{Transport: transport}
+	}
+	return nil
+}
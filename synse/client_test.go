@@ -0,0 +1,146 @@
+package synse
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/internal/test"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+// transportOf returns the transport of the client.
+func transportOf(client Client) string {
+	if c, ok := client.(*middlewareClient); ok {
+		client = c.Client
+	}
+	switch client.(type) {
+	case *httpClient:
+		return "http"
+	case *websocketClient:
+		return "websocket"
+	default:
+		return ""
+	}
+}
+
+func TestClientTransport(t *testing.T) {
+	tests := []struct {
+		transport string
+		opts      *Options
+		expected  string
+	}{
+		{opts: &Options{Address: "localhost:5000"}, expected: "http"},
+		{opts: &Options{Address: "https://gw.example/synse"}, expected: "http"},
+		{opts: &Options{Address: "ws://localhost:5000"}, expected: "websocket"},
+		{opts: &Options{Addresses: []string{" WSS://gw.example", "localhost:5000"}}, expected: "websocket"},
+		{opts: &Options{Address: "ws://localhost:5000", Transport: "http"}, expected: "http"},
+		{opts: &Options{Address: "localhost:5000", Transport: "ws"}, expected: "websocket"},
+		{transport: "websocket", opts: &Options{Address: "localhost:5000", Transport: "http"}, expected: "websocket"},
+		{opts: nil, expected: ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, clientTransport(tt.transport, tt.opts), tt.opts)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{})
+	defer server.Close()
+
+	client, err := NewClient(&Options{Address: server.Address})
+	assert.NoError(t, err)
+	assert.Equal(t, "http", transportOf(client))
+
+	client, err = NewClient(&Options{Address: "ws://" + server.Address})
+	assert.NoError(t, err)
+	defer client.Close()
+	assert.Equal(t, "websocket", transportOf(client))
+
+	// The websocket client is open.
+	status, err := client.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", status.Status)
+
+	_, err = NewClient(&Options{Address: server.Address, Transport: "grpc"})
+	assert.EqualError(t, err, `unknown transport "grpc"`)
+}
+
+func TestNewClient_IncompatibleAPIVersion(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{APIVersion: "v4"})
+	defer server.Close()
+
+	for _, transport := range []string{"http", "websocket"} {
+		client, err := NewClient(&Options{Address: server.Address, Transport: transport})
+		assert.Nil(t, client, transport)
+		assert.True(t, IsIncompatible(err), transport)
+		assert.EqualError(t, err, `synse: synse server API version "v4" is not supported, the client supports v3`, transport)
+	}
+}
+
+func TestNewClient_TransportDisabled(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{
+		Server: scheme.Config{Transport: scheme.TransportOptions{HTTP: true}},
+	})
+	defer server.Close()
+
+	client, err := NewClient(&Options{Address: server.Address})
+	assert.NoError(t, err)
+	assert.NotNil(t, client)
+
+	client, err = NewClient(&Options{Address: "ws://" + server.Address})
+	assert.Nil(t, client)
+	assert.True(t, IsIncompatible(err))
+	assert.EqualError(t, err, "synse: synse server does not serve the websocket transport")
+}
+
+func TestNewClient_NoWebSocketRoute(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	client, err := NewClient(&Options{Address: server.URL, Transport: "websocket"})
+	assert.Nil(t, client)
+	assert.True(t, IsIncompatible(err))
+	assert.True(t, IsNotFound(err))
+}
+
+func TestNewClient_WrongBasePath(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{})
+	defer server.Close()
+
+	// The HTTP API is not found under the base path, which does not make
+	// Synse Server incompatible.
+	client, err := NewClient(&Options{Address: "http://" + server.Address + "/synse"})
+	assert.Nil(t, client)
+	assert.False(t, IsIncompatible(err))
+	assert.True(t, IsNotFound(err))
+}
+
+func TestNewClientContext_HandshakeDeadline(t *testing.T) {
+	// The server accepts connections, but never completes the handshake.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	client, err := NewClientContext(ctx, &Options{Address: "ws://" + ln.Addr().String()})
+	assert.Nil(t, client)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -3,35 +3,86 @@ package synse
 // config.go defines config options for the client.
 
 import (
+	"crypto/tls"
 	"time"
 )
 
 // Options is the root config options.
 type Options struct {
-	// Address specifies the URL of Synse Server in the format `host[:port]`.
-	Address string `default:"-"`
+	// Address specifies the address of Synse Server, either in the format
+	// `host[:port]` or as a URL with the http, https, ws or wss scheme. Both
+	// can have a base path, for Synse Server served under a sub-path, e.g.
+	// `https://gw.example/synse`. An IPv6 host is given in brackets when
+	// it has a port, e.g. `[::1]:5000`. A unix socket is given as `unix://`
+	// followed by its path, e.g. `unix:///run/synse.sock`.
+	Address string `default:"-" yaml:"address"`
+
+	// Addresses specifies further addresses of Synse Server, equivalent to
+	// Address, in order of preference. The client fails over to the next
+	// address when a request fails with a transport or server error on the
+	// one in use. If Address is not set, the first of them is the primary.
+	Addresses []string `default:"-" yaml:"addresses"`
+
+	// Transport specifies the transport of the client created by NewClient,
+	// either `http` or `websocket`. If it is not set, it is taken from the
+	// scheme of the primary address: `ws` and `wss` for websocket, any other
+	// for http.
+	Transport string `yaml:"transport"`
+
+	// Failover specifies the options for failing over between addresses.
+	Failover FailoverOptions `yaml:"failover"`
 
 	// HTTP specifies the options for http protocol, used by a http client.
-	HTTP HTTPOptions
+	HTTP HTTPOptions `yaml:"http"`
 
 	// WebSocket specifies the options for websocket protocol, used by a
 	// websocket client.
-	WebSocket WebSocketOptions
+	WebSocket WebSocketOptions `yaml:"websocket"`
 
 	// TLS specifies the options for TLS/SSL communication.
-	TLS TLSOptions
+	TLS TLSOptions `yaml:"tls"`
+
+	// Proxy specifies the options for the proxy the clients connect through.
+	Proxy ProxyOptions `yaml:"proxy"`
+
+	// Limit specifies the client-side rate limits and concurrency caps.
+	Limit LimitOptions `yaml:"limit"`
+
+	// Breaker specifies the options for the circuit breaker.
+	Breaker BreakerOptions `yaml:"breaker"`
+
+	// DialContext specifies the function the connections to Synse Server are
+	// dialed with, for both clients, e.g. to reach it through an SSH tunnel.
+	// If it is not set, they are dialed with a net.Dialer.
+	DialContext DialContextFunc `default:"-" yaml:"-"`
+
+	// Auth specifies the provider of the credentials of the requests, for
+	// both the HTTP requests and the websocket handshake.
+	Auth AuthProvider `default:"-" yaml:"-"`
+
+	// Middleware specifies the middleware chain which wraps every operation
+	// of the client, the first one being the outermost.
+	Middleware []Middleware `default:"-" yaml:"-"`
+
+	// defaulted is set for options which already have their default values,
+	// e.g. loaded by LoadOptions, so that the clients keep their zero values.
+	defaulted bool
 }
 
 // HTTPOptions is the config options for http protocol,
 type HTTPOptions struct {
 	// Timeout specifies a time limit for a http request.
-	Timeout time.Duration `default:"2s"`
+	Timeout time.Duration `default:"2s" yaml:"timeout"`
 
 	// Retry specifies the options for retry mechanism.
-	Retry RetryOptions
+	Retry RetryOptions `yaml:"retry"`
 
 	// Redirects specifies the max number of allowed http redirects
-	Redirects int `default:"5"`
+	Redirects int `default:"5" yaml:"redirects"`
+
+	// PollInterval specifies how often readings are polled to emulate a
+	// read stream, which is not supported natively by the HTTP API.
+	PollInterval time.Duration `default:"1s" yaml:"poll_interval"`
 }
 
 // WebSocketOptions is the config options for websocket protocol.
@@ -41,7 +92,135 @@ type WebSocketOptions struct {
 	// we're using that specifies the default handshake timeout to be 45s. I
 	// don't have a sense on what is a good value either so I just use what
 	// they have there.
-	HandshakeTimeout time.Duration `default:"45s"`
+	HandshakeTimeout time.Duration `default:"45s" yaml:"handshake_timeout"`
+
+	// RequestTimeout specifies a time limit for a request to get its response.
+	// It does not apply to streamed requests.
+	RequestTimeout time.Duration `default:"30s" yaml:"request_timeout"`
+
+	// PingInterval specifies how often a ping is sent to keep the connection
+	// alive and to detect a dead peer. A negative value disables keepalive.
+	PingInterval time.Duration `default:"30s" yaml:"ping_interval"`
+
+	// PongTimeout specifies how long to wait for a pong (or any other message)
+	// past the ping interval before the connection is declared dead.
+	PongTimeout time.Duration `default:"10s" yaml:"pong_timeout"`
+
+	// WriteTimeout specifies a time limit for writing a message to the
+	// connection.
+	WriteTimeout time.Duration `default:"10s" yaml:"write_timeout"`
+
+	// Reconnect specifies the options for re-establishing a lost connection.
+	Reconnect ReconnectOptions `yaml:"reconnect"`
+}
+
+// FailoverOptions is the config options for failing over between the
+// addresses of a client.
+type FailoverOptions struct {
+	// HealthInterval specifies how often the primary address is checked
+	// while the client has failed over to another one. The client goes back
+	// to the primary address once it is healthy. A negative value disables
+	// the checks.
+	HealthInterval time.Duration `default:"10s" yaml:"health_interval"`
+}
+
+// BreakerOptions is the config options for the circuit breaker of a client.
+// The breaker opens after a number of consecutive failed operations; while it
+// is open, operations fail immediately with an error matching ErrCircuitOpen.
+// After a cool-down, it lets trial operations through (half-open), and closes
+// once one of them succeeds, or opens again if one fails.
+//
+// Transport errors, timeouts of the client and server errors are failures.
+// Other API errors, e.g. for a device which does not exist, show that Synse
+// Server is up, and the cancellation or deadline of the caller's context is
+// not a failure either. Read streams are let through while the breaker is
+// half-open, but are not trial operations.
+type BreakerOptions struct {
+	// Enabled specifies whether the client has a circuit breaker.
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// FailureThreshold specifies the number of consecutive failures which
+	// open the breaker.
+	FailureThreshold uint `default:"5" yaml:"failure_threshold"`
+
+	// CoolDown specifies how long the breaker stays open before letting
+	// trial operations through.
+	CoolDown time.Duration `default:"30s" yaml:"cool_down"`
+
+	// HalfOpenRequests specifies the maximum number of trial operations in
+	// flight while the breaker is half-open.
+	HalfOpenRequests uint `default:"1" yaml:"half_open_requests"`
+
+	// OnStateChange is called when the state of the breaker changes.
+	OnStateChange func(from, to BreakerState) `default:"-" yaml:"-"`
+}
+
+// LimitOptions is the config options for limiting the requests made by a
+// client. The overall budget applies to every operation, and the budget of
+// its class applies in addition to the operations of a class:
+//
+//   - reads: Read, ReadDevice, ReadCache and ReadStream
+//   - writes: WriteAsync and WriteSync
+//   - scans: Scan, Tags and Info
+//
+// A read stream takes from the rate limit when it starts, but does not count
+// as in flight, since it may never end.
+type LimitOptions struct {
+	// LimitBudget specifies the overall budget of the client.
+	LimitBudget `yaml:",inline"`
+
+	// Reads specifies the budget of the read operations.
+	Reads LimitBudget `yaml:"reads"`
+
+	// Writes specifies the budget of the write operations.
+	Writes LimitBudget `yaml:"writes"`
+
+	// Scans specifies the budget of the scan operations.
+	Scans LimitBudget `yaml:"scans"`
+
+	// FailFast specifies whether an operation which exceeds a budget fails
+	// with a LimitError. By default, it waits until the budget is available
+	// or its context is done.
+	FailFast bool `default:"false" yaml:"fail_fast"`
+}
+
+// LimitBudget is the config options for a request budget: a token bucket
+// rate limit and a cap on the number of requests in flight.
+type LimitBudget struct {
+	// Rate specifies the number of requests per second. Zero value means no
+	// rate limit.
+	Rate float64 `default:"0" yaml:"rate"`
+
+	// Burst specifies the number of requests which can be made at once,
+	// above the rate. Zero value means the rate, rounded up.
+	Burst int `default:"0" yaml:"burst"`
+
+	// MaxInFlight specifies the maximum number of requests in flight. Zero
+	// value means no cap.
+	MaxInFlight int `default:"0" yaml:"max_in_flight"`
+}
+
+// ReconnectOptions is the config options for automatically reconnecting a
+// lost websocket connection. It follows the same backoff strategy as
+// RetryOptions, with jitter added to the wait time of each attempt. Active
+// read streams are re-issued with their original options once the connection
+// is re-established.
+type ReconnectOptions struct {
+	// Enabled specifies whether the client reconnects when the connection
+	// is lost.
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Count specifies the number of reconnect attempts. Zero value means
+	// retrying until the connection is re-established.
+	Count uint `default:"0" yaml:"count"`
+
+	// WaitTime specifies the wait time before a reconnect attempt. It is
+	// increased after each attempt.
+	WaitTime time.Duration `default:"500ms" yaml:"wait_time"`
+
+	// MaxWaitTime specifies the maximum wait time, the cap, between
+	// reconnect attempts.
+	MaxWaitTime time.Duration `default:"30s" yaml:"max_wait_time"`
 }
 
 // RetryOptions is the config options for backoff retry mechanism. Its strategy
@@ -49,29 +228,80 @@ type WebSocketOptions struct {
 // value.
 type RetryOptions struct {
 	// Count specifies the number of retry attempts. Zero value means no retry.
-	Count uint `default:"3"`
+	Count uint `default:"3" yaml:"count"`
 
 	// WaitTime specifies the wait time before retrying request. It is
 	// increased after each attempt.
-	WaitTime time.Duration `default:"100ms"`
+	WaitTime time.Duration `default:"100ms" yaml:"wait_time"`
 
 	// MaxWaitTime specifies the maximum wait time, the cap, of all retry
 	// requests that are made.
-	MaxWaitTime time.Duration `default:"2s"`
+	MaxWaitTime time.Duration `default:"2s" yaml:"max_wait_time"`
 }
 
-// TLSOptions is the config options for TLS/SSL communication.
+// TLSOptions is the config options for TLS/SSL communication. They apply
+// the same way to both clients, once TLS is enabled.
 type TLSOptions struct {
 	// CertFile and KeyFile are public/private key pair from a pair of files to
-	// use when communicating with Synse Server.
-	CertFile string `default:"-"`
-	KeyFile  string `default:"-"`
+	// use when communicating with Synse Server. The pair is reloaded when the
+	// files change on disk, for the connections opened afterwards.
+	CertFile string `default:"-" yaml:"cert_file"`
+	KeyFile  string `default:"-" yaml:"key_file"`
+
+	// CAFile specifies a file with the PEM encoded CA certificates used to
+	// verify the certificate of Synse Server, instead of the system ones.
+	CAFile string `default:"-" yaml:"ca_file"`
+
+	// CAPEM specifies PEM encoded CA certificates, like CAFile. The
+	// certificates of both are used if both are set.
+	CAPEM string `default:"-" yaml:"ca_pem"`
+
+	// ServerName overrides the host name used to verify the certificate of
+	// Synse Server, e.g. when connecting to it by IP address. The certificate
+	// of a unix socket address is only verified for a host name if it is set.
+	ServerName string `default:"-" yaml:"server_name"`
+
+	// MinVersion specifies the minimum TLS version, one of `1.0`, `1.1`,
+	// `1.2` or `1.3`. Zero value means the default of crypto/tls.
+	MinVersion string `default:"-" yaml:"min_version"`
+
+	// CipherSuites specifies the enabled cipher suites for TLS 1.2 and
+	// below, by name, e.g. `TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256`. Zero
+	// value means the default of crypto/tls.
+	CipherSuites []string `default:"-" yaml:"cipher_suites"`
+
+	// Config specifies a TLS configuration to start from. The other options
+	// which are set override it.
+	Config *tls.Config `default:"-" yaml:"-"`
 
 	// Enabled specifies whether tls is enabled.
-	Enabled bool `default:"false"`
+	Enabled bool `default:"false" yaml:"enabled"`
 
 	// SkipVerify specifies whether the client can skip certificate check. If
 	// it is set to true, TLS will accept any certificate presented. However,
 	// due to security concern, this should only be used for testing.
-	SkipVerify bool `default:"false"`
+	SkipVerify bool `default:"false" yaml:"skip_verify"`
+}
+
+// ProxyOptions is the config options for the proxy the clients connect
+// through. The websocket client tunnels its connections through an HTTP proxy
+// with CONNECT.
+type ProxyOptions struct {
+	// URL specifies the URL of the proxy, with the http or socks5 scheme,
+	// e.g. `http://proxy.example:3128`. It can carry the credentials of the
+	// proxy. If it is not set, the proxy is taken from the HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY environment variables.
+	URL string `yaml:"url"`
+
+	// NoProxy specifies the hosts which are not reached through the proxy
+	// of URL, in the same format as the entries of NO_PROXY.
+	NoProxy []string `default:"-" yaml:"no_proxy"`
+
+	// Username and Password specify the credentials of the proxy. They
+	// override those of the proxy URL.
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// Disabled disables the proxy, including the one from the environment.
+	Disabled bool `yaml:"disabled"`
 }
@@ -0,0 +1,112 @@
+package synse
+
+// dial.go routes the connections of the clients to Synse Server.
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// DialContextFunc dials a connection to the network address, like
+// net.Dialer.DialContext.
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// unixHost returns the host of the URLs of a unix socket address. It stands
+// in for the socket, so that the connections to it can be told apart from
+// those to any other address.
+func unixHost(socket string) string {
+	return fmt.Sprintf("unix-%08x", crc32.ChecksumIEEE([]byte(socket)))
+}
+
+// newDialContext returns the function the clients dial Synse Server with.
+// Connections to a unix socket address are dialed to its socket, and all
+// connections are dialed with the DialContext of the options if it is set.
+// It returns nil if neither is the case, so that the default dialer is used.
+func newDialContext(opts *Options, addresses map[string]address) DialContextFunc {
+	sockets := unixSockets(addresses)
+	if opts.DialContext == nil && len(sockets) == 0 {
+		return nil
+	}
+
+	dial := opts.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err == nil {
+			if socket, ok := sockets[host]; ok {
+				return dial(ctx, "unix", socket)
+			}
+		}
+		return dial(ctx, network, address)
+	}
+}
+
+// newDialTLSContext returns the function TLS connections are dialed with,
+// over the given dial function, if any of the addresses is a unix socket. The
+// TLS configuration of those connections is that of unixTLSConfig, and that
+// of the others has the server name of their host, as with the default TLS
+// dialer. It returns nil if there is no unix socket address.
+func newDialTLSContext(dial DialContextFunc, cfg *tls.Config, addresses map[string]address) DialContextFunc {
+	sockets := unixSockets(addresses)
+	if dial == nil || cfg == nil || len(sockets) == 0 {
+		return nil
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		c := cfg.Clone()
+		if _, ok := sockets[host]; ok {
+			c = unixTLSConfig(c)
+		} else if c.ServerName == "" {
+			c.ServerName = host
+		}
+
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, c)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
+
+// unixSockets returns the sockets of the unix socket addresses, keyed by the
+// host of their URLs.
+func unixSockets(addresses map[string]address) map[string]string {
+	sockets := map[string]string{}
+	for _, a := range addresses {
+		if a.socket != "" {
+			sockets[a.host] = a.socket
+		}
+	}
+	return sockets
+}
+
+// bypassUnix wraps the proxy function of an HTTP transport so that the
+// requests to unix socket addresses are not proxied.
+func bypassUnix(proxy func(*http.Request) (*url.URL, error), addresses map[string]address) func(*http.Request) (*url.URL, error) {
+	sockets := unixSockets(addresses)
+	if proxy == nil || len(sockets) == 0 {
+		return proxy
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if _, ok := sockets[req.URL.Hostname()]; ok {
+			return nil, nil
+		}
+		return proxy(req)
+	}
+}
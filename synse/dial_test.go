@@ -0,0 +1,228 @@
+package synse
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+// pipeListener is a net.Listener for in-memory pipe connections.
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+// DialContext returns the client end of a new pipe, whose server end is
+// accepted by the listener.
+func (l *pipeListener) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	client, server := net.Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "pipe", Net: "pipe"}
+}
+
+// serveProxy serves a reverse proxy to the server on the listener.
+func serveProxy(t *testing.T, l net.Listener, server *synsetest.Server) {
+	s := &http.Server{Handler: httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: server.Address})}
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(func() { _ = s.Close() })
+}
+
+func TestParseAddress_Unix(t *testing.T) {
+	a, err := parseAddress("unix:///run/synse.sock")
+	assert.NoError(t, err)
+	assert.Equal(t, address{host: unixHost("/run/synse.sock"), socket: "/run/synse.sock"}, a)
+	assert.Equal(t, "http://"+unixHost("/run/synse.sock")+"/test", a.url("http", "https", false, testURI))
+
+	a, err = parseAddress("unix:synse.sock")
+	assert.NoError(t, err)
+	assert.Equal(t, "synse.sock", a.socket)
+	assert.NotEqual(t, unixHost("/run/synse.sock"), a.host)
+
+	_, err = parseAddress("unix://")
+	assert.EqualError(t, err, `invalid address "unix://": no socket path`)
+}
+
+func TestClients_UnixSocket(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{})
+	defer server.Close()
+
+	socket := filepath.Join(t.TempDir(), "synse.sock")
+	l, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	serveProxy(t, l, server)
+
+	httpClient, err := NewHTTPClientV3(&Options{Address: "unix://" + socket})
+	assert.NoError(t, err)
+	status, err := httpClient.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", status.Status)
+
+	wsClient, err := NewWebSocketClientV3(&Options{Address: "unix://" + socket})
+	assert.NoError(t, err)
+	assert.NoError(t, wsClient.Open())
+	defer wsClient.Close()
+	status, err = wsClient.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", status.Status)
+}
+
+func TestClients_DialContext(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{})
+	defer server.Close()
+
+	l := newPipeListener()
+	defer l.Close()
+	serveProxy(t, l, server)
+
+	var dials int32
+	var addresses sync.Map
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		addresses.Store(address, true)
+		return l.DialContext(ctx, network, address)
+	}
+
+	// The address does not resolve, all traffic goes through the pipes.
+	httpClient, err := NewHTTPClientV3(&Options{Address: "synse.invalid:5000", DialContext: dial})
+	assert.NoError(t, err)
+	status, err := httpClient.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", status.Status)
+
+	wsClient, err := NewWebSocketClientV3(&Options{Address: "synse.invalid:5000", DialContext: dial})
+	assert.NoError(t, err)
+	assert.NoError(t, wsClient.Open())
+	defer wsClient.Close()
+	status, err = wsClient.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", status.Status)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&dials))
+	_, ok := addresses.Load("synse.invalid:5000")
+	assert.True(t, ok)
+}
+
+func TestNewDialContext(t *testing.T) {
+	var dialed []string
+	opts := &Options{DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = append(dialed, network+" "+address)
+		return nil, net.ErrClosed
+	}}
+	addresses := map[string]address{"unix:///run/synse.sock": {host: unixHost("/run/synse.sock"), socket: "/run/synse.sock"}}
+
+	dial := newDialContext(opts, addresses)
+	_, _ = dial(context.Background(), "tcp", unixHost("/run/synse.sock")+":80")
+	_, _ = dial(context.Background(), "tcp", "localhost:5000")
+	assert.Equal(t, []string{"unix /run/synse.sock", "tcp localhost:5000"}, dialed)
+
+	// The default dialer is used when there is nothing to route.
+	assert.Nil(t, newDialContext(&Options{}, map[string]address{"localhost:5000": {host: "localhost:5000"}}))
+
+	// The requests to unix sockets are not proxied.
+	proxyURL := &url.URL{Scheme: "http", Host: "proxy:3128"}
+	proxy := bypassUnix(http.ProxyURL(proxyURL), addresses)
+	u, err := proxy(&http.Request{URL: &url.URL{Scheme: "http", Host: unixHost("/run/synse.sock")}})
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+	u, err = proxy(&http.Request{URL: &url.URL{Scheme: "http", Host: "localhost:5000"}})
+	assert.NoError(t, err)
+	assert.Equal(t, proxyURL, u)
+}
+
+func TestClients_UnixSocketTLS(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{})
+	defer server.Close()
+
+	socket := filepath.Join(t.TempDir(), "synse.sock")
+	l, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	ts := httptest.NewUnstartedServer(httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: server.Address}))
+	ts.Listener = l
+	ts.StartTLS()
+	defer ts.Close()
+
+	// The certificate is for example.com and 127.0.0.1, not for the host
+	// which stands in for the socket.
+	ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}))
+	opts := func(tlsOpts TLSOptions) *Options {
+		tlsOpts.Enabled = true
+		tlsOpts.CAPEM = ca
+		return &Options{Address: "unix://" + socket, TLS: tlsOpts}
+	}
+
+	for _, tc := range []struct {
+		tls TLSOptions
+		ok  bool
+	}{
+		{tls: TLSOptions{}, ok: true},
+		{tls: TLSOptions{ServerName: "example.com"}, ok: true},
+		{tls: TLSOptions{ServerName: "synse.example"}, ok: false},
+	} {
+		httpClient, err := NewHTTPClientV3(opts(tc.tls))
+		assert.NoError(t, err)
+		_, err = httpClient.Status()
+		assert.Equal(t, tc.ok, err == nil, "http %s: %v", tc.tls.ServerName, err)
+
+		wsClient, err := NewWebSocketClientV3(opts(tc.tls))
+		assert.NoError(t, err)
+		err = wsClient.Open()
+		assert.Equal(t, tc.ok, err == nil, "websocket %s: %v", tc.tls.ServerName, err)
+		if err == nil {
+			_, err = wsClient.Status()
+			assert.NoError(t, err)
+			assert.NoError(t, wsClient.Close())
+		}
+	}
+
+	// The chain is still verified.
+	httpClient, err := NewHTTPClientV3(&Options{
+		Address: "unix://" + socket,
+		TLS:     TLSOptions{Enabled: true},
+	})
+	assert.NoError(t, err)
+	_, err = httpClient.Status()
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package synse
+
+// errors.go defines the errors returned by the client.
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// Sentinel errors which can be used with errors.Is to check the kind of a
+// failure returned by the client.
+var (
+	// ErrBadRequest matches an APIError for an invalid request (400).
+	ErrBadRequest = errors.New("synse: bad request")
+
+	// ErrUnauthorized matches an APIError for a request which was rejected
+	// for missing or invalid credentials (401).
+	ErrUnauthorized = errors.New("synse: unauthorized")
+
+	// ErrNotFound matches an APIError for a resource which does not exist (404).
+	ErrNotFound = errors.New("synse: not found")
+
+	// ErrTimeout matches an APIError for a request that timed out on the
+	// server side (408, 504), as well as a websocket request that got no
+	// response within its request timeout.
+	ErrTimeout = errors.New("synse: timeout")
+
+	// ErrServerError matches an APIError for a server side failure (5xx).
+	ErrServerError = errors.New("synse: server error")
+
+	// ErrTransport matches a TransportError.
+	ErrTransport = errors.New("synse: transport error")
+
+	// ErrConnectionDead matches a TransportError for a websocket connection
+	// whose peer stopped responding to keepalive pings.
+	ErrConnectionDead = errors.New("synse: connection is dead")
+
+	// ErrLimited matches a LimitError.
+	ErrLimited = errors.New("synse: client limit exceeded")
+
+	// ErrCircuitOpen matches the error for an operation which was not
+	// attempted because the circuit breaker of the client is open.
+	ErrCircuitOpen = errors.New("synse: circuit breaker is open")
+
+	// ErrIncompatible matches an IncompatibleError.
+	ErrIncompatible = errors.New("synse: incompatible server")
+)
+
+// APIError is an error response returned by Synse Server. It holds the
+// scheme.Error of the response, so the HTTP code, description and context
+// reported by the server are available to the caller.
+//
+// Both the HTTP and the WebSocket client return an APIError when Synse
+// Server responds with an error.
+type APIError struct {
+	// Response is the error response returned by Synse Server.
+	Response scheme.Error
+}
+
+// newAPIError creates an APIError from an error response scheme.
+func newAPIError(resp scheme.Error) *APIError {
+	return &APIError{
+		Response: resp,
+	}
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf(
+		"got a %v error response from synse server at %v, saying %v, with context: %v",
+		e.Response.HTTPCode, e.Response.Timestamp, e.Response.Description, e.Response.Context,
+	)
+}
+
+// Is reports whether the APIError matches the given sentinel error, based on
+// its HTTP code.
+func (e *APIError) Is(target error) bool {
+	code := e.Response.HTTPCode
+	switch target {
+	case ErrBadRequest:
+		return code == http.StatusBadRequest
+	case ErrUnauthorized:
+		return code == http.StatusUnauthorized
+	case ErrNotFound:
+		return code == http.StatusNotFound
+	case ErrTimeout:
+		return code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout
+	case ErrServerError:
+		return code >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// TransportError is a failure to communicate with Synse Server, e.g. the
+// connection could not be established or it was lost mid-request. It wraps
+// the underlying error.
+type TransportError struct {
+	// Err is the underlying error.
+	Err error
+}
+
+// newTransportError wraps the given error into a TransportError, annotating
+// it with the given message.
+func newTransportError(err error, message string) *TransportError {
+	return &TransportError{
+		Err: errors.Wrap(err, message),
+	}
+}
+
+// Error implements the error interface.
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrTransport.
+func (e *TransportError) Is(target error) bool {
+	return target == ErrTransport
+}
+
+// IsBadRequest reports whether the error is an APIError for an invalid
+// request.
+func IsBadRequest(err error) bool {
+	return errors.Is(err, ErrBadRequest)
+}
+
+// IsUnauthorized reports whether the error is an APIError for a request
+// which was rejected for missing or invalid credentials.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsNotFound reports whether the error is an APIError for a resource which
+// does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsTimeout reports whether the error is caused by a timeout, either on the
+// server side, in the network or from an expired context deadline.
+func IsTimeout(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) || isTimeout(err)
+}
+
+// IsServerError reports whether the error is an APIError for a server side
+// failure.
+func IsServerError(err error) bool {
+	return errors.Is(err, ErrServerError)
+}
+
+// IsTransport reports whether the error is a TransportError.
+func IsTransport(err error) bool {
+	return errors.Is(err, ErrTransport)
+}
+
+// IsConnectionDead reports whether the error is caused by a websocket
+// connection which was declared dead by keepalive.
+func IsConnectionDead(err error) bool {
+	return errors.Is(err, ErrConnectionDead)
+}
+
+// IsLimited reports whether the error is a LimitError, for an operation
+// which exceeded a client-side limit.
+func IsLimited(err error) bool {
+	return errors.Is(err, ErrLimited)
+}
+
+// IsCircuitOpen reports whether the error is for an operation which was not
+// attempted because the circuit breaker of the client is open.
+func IsCircuitOpen(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// IsIncompatible reports whether the error is an IncompatibleError, for a
+// Synse Server the client can not be used with.
+func IsIncompatible(err error) bool {
+	return errors.Is(err, ErrIncompatible)
+}
+
+// isTimeout reports whether the error is a network timeout.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,216 @@
+package synse
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/internal/test"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+func TestAPIError_Error(t *testing.T) {
+	err := newAPIError(scheme.Error{
+		HTTPCode:    404,
+		Description: "resource not found",
+		Timestamp:   "2019-03-20T17:37:07Z",
+		Context:     "device not found",
+	})
+	assert.Equal(
+		t,
+		"got a 404 error response from synse server at 2019-03-20T17:37:07Z, saying resource not found, with context: device not found",
+		err.Error(),
+	)
+}
+
+func TestAPIError_Is(t *testing.T) {
+	tests := []struct {
+		code         int
+		badRequest   bool
+		unauthorized bool
+		notFound     bool
+		timeout      bool
+		serverError  bool
+	}{
+		{code: 400, badRequest: true},
+		{code: 401, unauthorized: true},
+		{code: 404, notFound: true},
+		{code: 408, timeout: true},
+		{code: 500, serverError: true},
+		{code: 503, serverError: true},
+		{code: 504, timeout: true, serverError: true},
+	}
+
+	for _, tt := range tests {
+		err := errors.Wrap(newAPIError(scheme.Error{HTTPCode: tt.code}), "wrapped")
+		assert.Equal(t, tt.badRequest, IsBadRequest(err), tt.code)
+		assert.Equal(t, tt.unauthorized, IsUnauthorized(err), tt.code)
+		assert.Equal(t, tt.notFound, IsNotFound(err), tt.code)
+		assert.Equal(t, tt.timeout, IsTimeout(err), tt.code)
+		assert.Equal(t, tt.serverError, IsServerError(err), tt.code)
+		assert.False(t, IsTransport(err), tt.code)
+	}
+}
+
+func TestAPIError_As(t *testing.T) {
+	err := errors.Wrap(newAPIError(scheme.Error{HTTPCode: 500, Context: "unknown error"}), "wrapped")
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 500, apiErr.Response.HTTPCode)
+	assert.Equal(t, "unknown error", apiErr.Response.Context)
+}
+
+func TestTransportError(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := errors.Wrap(newTransportError(cause, "failed to connect"), "wrapped")
+
+	assert.True(t, IsTransport(err))
+	assert.False(t, IsServerError(err))
+	assert.False(t, IsNotFound(err))
+	assert.True(t, errors.Is(err, cause))
+	assert.Equal(t, "wrapped: failed to connect: connection refused", err.Error())
+}
+
+func TestIsTimeout(t *testing.T) {
+	assert.True(t, IsTimeout(context.DeadlineExceeded))
+	assert.True(t, IsTimeout(newTransportError(&net.DNSError{IsTimeout: true}, "failed")))
+	assert.False(t, IsTimeout(newTransportError(&net.DNSError{}, "failed")))
+	assert.False(t, IsTimeout(context.Canceled))
+	assert.False(t, IsTimeout(nil))
+}
+
+func TestHTTPClientV3_APIError(t *testing.T) {
+	in := `
+{
+  "http_code":404,
+  "description":"resource not found",
+  "timestamp":"2019-03-20T17:37:07Z",
+  "context":"device not found"
+}`
+
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	server.ServeVersioned(t, "/info/123", 404, in)
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	resp, err := client.Info("123")
+	assert.Nil(t, resp)
+	assert.True(t, IsNotFound(err))
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, scheme.Error{
+		HTTPCode:    404,
+		Description: "resource not found",
+		Timestamp:   "2019-03-20T17:37:07Z",
+		Context:     "device not found",
+	}, apiErr.Response)
+}
+
+func TestHTTPClientV3_APIError_NoScheme(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	server.ServeVersioned(t, "/config", 502, "<html>bad gateway</html>")
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	resp, err := client.Config()
+	assert.Nil(t, resp)
+	assert.True(t, IsServerError(err))
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 502, apiErr.Response.HTTPCode)
+	assert.Equal(t, "Bad Gateway", apiErr.Response.Description)
+}
+
+func TestHTTPClientV3_TransportError(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	server.Close()
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	resp, err := client.Status()
+	assert.Nil(t, resp)
+	assert.True(t, IsTransport(err))
+}
+
+func TestWebSocketClientV3_APIError(t *testing.T) {
+	in := `
+{
+   "id":1,
+   "event":"response/error",
+   "data":{
+      "http_code":404,
+      "description":"resource not found",
+      "timestamp":"2019-03-20T17:37:07Z",
+      "context":"device not found"
+   }
+}`
+
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	server.Serve(in)
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	resp, err := client.Info("123")
+	assert.Nil(t, resp)
+	assert.True(t, IsNotFound(err))
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, scheme.Error{
+		HTTPCode:    404,
+		Description: "resource not found",
+		Timestamp:   "2019-03-20T17:37:07Z",
+		Context:     "device not found",
+	}, apiErr.Response)
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_TransportError(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	server.Close()
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.True(t, IsTransport(err))
+
+	resp, err := client.Status()
+	assert.Nil(t, resp)
+	assert.True(t, IsTransport(err))
+}
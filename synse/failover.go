@@ -0,0 +1,200 @@
+package synse
+
+// failover.go tracks the Synse Server addresses of a client, and fails over
+// between them.
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ActiveEndpoint returns the address of Synse Server the client currently
+// sends its requests to. It is the primary address unless the client failed
+// over to one of the other addresses of its Options.
+func ActiveEndpoint(client Client) string {
+	if c, ok := client.(*middlewareClient); ok {
+		client = c.Client
+	}
+
+	switch c := client.(type) {
+	case *httpClient:
+		return c.endpoints.active()
+	case *websocketClient:
+		return c.endpoints.active()
+	default:
+		return ""
+	}
+}
+
+// failsOver reports whether the error shows that the address in use is not
+// serving, so that the request should be made against the next one.
+func failsOver(err error) bool {
+	return IsTransport(err) || IsServerError(err)
+}
+
+// notSent reports whether the error shows that a request was not sent, as no
+// connection could be made to the address or to the proxy in front of it.
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "proxyconnect")
+}
+
+// endpoints tracks the addresses of a client and which one is active.
+type endpoints struct {
+	// addresses holds the addresses, the primary one first.
+	addresses []string
+
+	// interval is how often the primary address is checked while another
+	// one is active. A zero or negative interval disables the checks.
+	interval time.Duration
+
+	// now returns the current time.
+	now func() time.Time
+
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// current is the index of the active address.
+	current int
+
+	// checking is set while the primary address is being checked.
+	checking bool
+
+	// checked is the time the primary address was last checked.
+	checked time.Time
+}
+
+// newEndpoints creates the endpoints for the addresses of the options, in
+// order and without duplicates.
+func newEndpoints(opts *Options) *endpoints {
+	e := &endpoints{
+		interval: opts.Failover.HealthInterval,
+		now:      time.Now,
+	}
+
+	seen := map[string]bool{}
+	for _, address := range append([]string{opts.Address}, opts.Addresses...) {
+		if address == "" || seen[address] {
+			continue
+		}
+		seen[address] = true
+		e.addresses = append(e.addresses, address)
+	}
+	return e
+}
+
+// multiple reports whether there are addresses to fail over to.
+func (e *endpoints) multiple() bool {
+	return len(e.addresses) > 1
+}
+
+// primary returns the primary address.
+func (e *endpoints) primary() string {
+	return e.addresses[0]
+}
+
+// active returns the active address.
+func (e *endpoints) active() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.addresses[e.current]
+}
+
+// order returns the addresses in the order they should be tried in: the
+// active one first, then the ones after it, wrapping around.
+func (e *endpoints) order() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.rotate(e.current)
+}
+
+// after returns the addresses in the order they should be tried in when the
+// given address failed: the ones after it, wrapping around, then itself.
+func (e *endpoints) after(address string) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.rotate(e.index(address) + 1)
+}
+
+// failed makes the next address active, if the given one is active.
+func (e *endpoints) failed(address string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.addresses[e.current] == address {
+		e.set((e.current + 1) % len(e.addresses))
+	}
+}
+
+// activate makes the given address active.
+func (e *endpoints) activate(address string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if i := e.index(address); i >= 0 {
+		e.set(i)
+	}
+}
+
+// checkPrimary calls check with the primary address in a new goroutine if
+// another address is active and the primary one is due a check. If check
+// reports that it is healthy, the primary address is made active again.
+func (e *endpoints) checkPrimary(check func(address string) bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.current == 0 || e.interval <= 0 || e.checking || e.now().Sub(e.checked) < e.interval {
+		return
+	}
+	e.checking = true
+
+	go func() {
+		healthy := check(e.primary())
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.checking = false
+		e.checked = e.now()
+		if healthy {
+			e.current = 0
+		}
+	}()
+}
+
+// set makes the address at the given index active. It must be called with mu
+// held.
+func (e *endpoints) set(i int) {
+	// The primary address is not checked right after it failed.
+	if e.current == 0 && i != 0 {
+		e.checked = e.now()
+	}
+	e.current = i
+}
+
+// rotate returns the addresses starting from the given index, wrapping
+// around. It must be called with mu held.
+func (e *endpoints) rotate(start int) []string {
+	n := len(e.addresses)
+	out := make([]string, n)
+	for i := range out {
+		out[i] = e.addresses[(start+i)%n]
+	}
+	return out
+}
+
+// index returns the index of the address, or -1. It must be called with mu
+// held.
+func (e *endpoints) index(address string) int {
+	for i, a := range e.addresses {
+		if a == address {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,317 @@
+package synse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/internal/test"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+func TestEndpoints(t *testing.T) {
+	e := newEndpoints(&Options{
+		Address:   "a:5000",
+		Addresses: []string{"b:5000", "a:5000", "", "c:5000"},
+		Failover:  FailoverOptions{HealthInterval: time.Minute},
+	})
+	clock := &fakeClock{now: time.Now()}
+	e.now = clock.Now
+
+	assert.Equal(t, []string{"a:5000", "b:5000", "c:5000"}, e.addresses)
+	assert.True(t, e.multiple())
+	assert.Equal(t, "a:5000", e.active())
+	assert.Equal(t, []string{"a:5000", "b:5000", "c:5000"}, e.order())
+	assert.Equal(t, []string{"c:5000", "a:5000", "b:5000"}, e.after("b:5000"))
+
+	// Only the active address fails over.
+	e.failed("b:5000")
+	assert.Equal(t, "a:5000", e.active())
+	e.failed("a:5000")
+	assert.Equal(t, "b:5000", e.active())
+	assert.Equal(t, []string{"b:5000", "c:5000", "a:5000"}, e.order())
+
+	// The primary address is checked once the interval elapsed.
+	checked := make(chan string, 1)
+	check := func(address string) bool {
+		checked <- address
+		return true
+	}
+	e.checkPrimary(check)
+	assert.Len(t, checked, 0)
+
+	clock.Advance(time.Minute)
+	e.checkPrimary(check)
+	assert.Equal(t, "a:5000", <-checked)
+	assert.Eventually(t, func() bool {
+		return e.active() == "a:5000"
+	}, time.Second, time.Millisecond)
+
+	// The client is back on the primary address.
+	clock.Advance(time.Minute)
+	e.checkPrimary(check)
+	assert.Len(t, checked, 0)
+
+	// The primary address is the first of the addresses without Address.
+	e = newEndpoints(&Options{Addresses: []string{"b:5000", "c:5000"}})
+	assert.Equal(t, "b:5000", e.primary())
+	assert.False(t, newEndpoints(&Options{Address: "a:5000"}).multiple())
+}
+
+func TestHTTPClientV3_Failover(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var scans int32
+
+	primary := test.NewHTTPServerV3()
+	defer primary.Close()
+	primary.HandleUnversioned("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fprintf(t, w, `{"http_code":503,"description":"unavailable","context":"starting"}`)
+			return
+		}
+		fprintf(t, w, `{"status":"ok","timestamp":"2019-01-24T14:34:24Z"}`)
+	})
+	primary.HandleVersioned("/scan", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&scans, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fprintf(t, w, `{"http_code":503,"description":"unavailable","context":"starting"}`)
+			return
+		}
+		fprintf(t, w, `[]`)
+	})
+	primary.ServeVersioned(t, "/info/temp-1", 404, `{"http_code":404,"description":"not found","context":"no device"}`)
+
+	secondary := synsetest.NewServer(synsetest.Config{
+		Devices: []synsetest.Device{{ID: "temp-1", Type: "temperature"}},
+	})
+	defer secondary.Close()
+
+	client, err := NewHTTPClientV3(&Options{
+		Address:   primary.URL,
+		Addresses: []string{secondary.Address},
+		Failover:  FailoverOptions{HealthInterval: 20 * time.Millisecond},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, primary.URL, ActiveEndpoint(client))
+
+	// A server error fails over to the secondary address.
+	devices, err := client.Scan(scheme.ScanOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+	assert.Equal(t, secondary.Address, ActiveEndpoint(client))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&scans))
+
+	// Once the primary address is healthy, the client goes back to it.
+	failing.Store(false)
+	assert.Eventually(t, func() bool {
+		_, err := client.Scan(scheme.ScanOptions{})
+		return err == nil && ActiveEndpoint(client) == primary.URL
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Other API errors do not fail over.
+	_, err = client.Info("temp-1")
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, primary.URL, ActiveEndpoint(client))
+}
+
+func TestHTTPClientV3_Failover_AllDown(t *testing.T) {
+	var addresses []string
+	for i := 0; i < 2; i++ {
+		server := synsetest.NewServer(synsetest.Config{})
+		server.Close()
+		addresses = append(addresses, server.Address)
+	}
+
+	client, err := NewHTTPClientV3(&Options{
+		Addresses: addresses,
+		HTTP: HTTPOptions{
+			Retry: RetryOptions{WaitTime: time.Millisecond, MaxWaitTime: time.Millisecond},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = client.Status()
+	assert.True(t, IsTransport(err))
+	assert.Equal(t, addresses[0], ActiveEndpoint(client))
+}
+
+func TestHTTPClientV3_Failover_Write(t *testing.T) {
+	var writes int32
+	primary := test.NewHTTPServerV3()
+	defer primary.Close()
+	primary.HandleVersioned("/write/led-1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&writes, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		fprintf(t, w, `{"http_code":500,"description":"unknown error","context":"write failed"}`)
+	})
+
+	secondary := synsetest.NewServer(synsetest.Config{
+		Devices: []synsetest.Device{{ID: "led-1", Type: "led", Actions: []string{"state"}}},
+	})
+	defer secondary.Close()
+
+	client, err := NewHTTPClientV3(&Options{
+		Address:   primary.URL,
+		Addresses: []string{secondary.Address},
+	})
+	assert.NoError(t, err)
+
+	// The primary address may have acted on the write, which is not made
+	// again against the secondary one.
+	data := []scheme.WriteData{{Action: "state", Data: "on"}}
+	_, err = client.WriteAsync("led-1", data)
+	assert.True(t, IsServerError(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&writes))
+	assert.Equal(t, primary.URL, ActiveEndpoint(client))
+
+	// A write which could not be sent fails over.
+	down := synsetest.NewServer(synsetest.Config{})
+	down.Close()
+
+	client, err = NewHTTPClientV3(&Options{
+		Address:   down.Address,
+		Addresses: []string{secondary.Address},
+		HTTP: HTTPOptions{
+			Retry: RetryOptions{WaitTime: time.Millisecond, MaxWaitTime: time.Millisecond},
+		},
+	})
+	assert.NoError(t, err)
+
+	transactions, err := client.WriteAsync("led-1", data)
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 1)
+	assert.Equal(t, secondary.Address, ActiveEndpoint(client))
+}
+
+func TestWebSocketClientV3_Failover_Open(t *testing.T) {
+	down := synsetest.NewServer(synsetest.Config{})
+	down.Close()
+	up := synsetest.NewServer(synsetest.Config{})
+	defer up.Close()
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address:   down.Address,
+		Addresses: []string{up.Address},
+		Failover:  FailoverOptions{HealthInterval: -1},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Open())
+	defer client.Close() // nolint: errcheck
+
+	assert.Equal(t, up.Address, ActiveEndpoint(client))
+	_, err = client.Status()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_Failover_ServerError(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+
+	primary := test.NewWebSocketServerV3()
+	defer primary.Close()
+	primary.ServeFunc(func(request []byte) []string {
+		var req scheme.RequestStatus
+		if err := json.Unmarshal(request, &req); err != nil {
+			return nil
+		}
+		if failing.Load() {
+			return []string{fmt.Sprintf(`{"id":%d,"event":"response/error","data":{"http_code":500,"description":"unknown error"}}`, req.ID)}
+		}
+		return []string{fmt.Sprintf(`{"id":%d,"event":"response/status","data":{"status":"ok"}}`, req.ID)}
+	})
+
+	secondary := synsetest.NewServer(synsetest.Config{})
+	defer secondary.Close()
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address:   primary.URL,
+		Addresses: []string{secondary.Address},
+		Failover:  FailoverOptions{HealthInterval: 20 * time.Millisecond},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Open())
+	defer client.Close() // nolint: errcheck
+
+	_, err = client.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, secondary.Address, ActiveEndpoint(client))
+
+	failing.Store(false)
+	assert.Eventually(t, func() bool {
+		return ActiveEndpoint(client) == primary.URL
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status, err := client.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", status.Status)
+}
+
+func TestWebSocketClientV3_Failover_ResumeStream(t *testing.T) {
+	var servers []*test.WebSocketServer
+	streams := make(chan scheme.RequestReadStream, 2)
+	for _, name := range []string{"a", "b"} {
+		name := name
+		server := test.NewWebSocketServerV3()
+		defer server.Close()
+		server.ServeFunc(func(request []byte) []string {
+			var req scheme.RequestReadStream
+			if err := json.Unmarshal(request, &req); err != nil {
+				return nil
+			}
+			if req.Event != requestReadStream || req.Data.Stop {
+				return nil
+			}
+
+			streams <- req
+			return []string{fmt.Sprintf(
+				`{"id":%d,"event":"response/reading","data":{"device":"led-%s","type":"state","value":"on"}}`,
+				req.ID, name,
+			)}
+		})
+		servers = append(servers, server)
+	}
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address:   servers[0].URL,
+		Addresses: []string{servers[1].URL},
+		Failover:  FailoverOptions{HealthInterval: -1},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Open())
+	defer client.Close() // nolint: errcheck
+
+	opts := scheme.ReadStreamOptions{Ids: []string{"led"}}
+	readings := make(chan *scheme.Read)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		_ = client.ReadStream(opts, readings, stop)
+	}()
+
+	for _, device := range []string{"led-a", "led-b"} {
+		select {
+		case r := <-readings:
+			assert.Equal(t, device, r.Device)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout: failed getting read stream data from channel")
+		}
+
+		// The stream is issued again with its original options.
+		req := <-streams
+		assert.Equal(t, opts, req.Data)
+
+		servers[0].DropConnections()
+	}
+	assert.Equal(t, servers[1].URL, ActiveEndpoint(client))
+}
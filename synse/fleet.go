@@ -0,0 +1,294 @@
+package synse
+
+// fleet.go provides a client for a fleet of Synse Servers.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// FleetServer is a Synse Server of a fleet.
+type FleetServer struct {
+	// Name is the unique name of the server in the fleet, e.g. its rack.
+	Name string
+
+	// Transport is the transport of the client for the server, either
+	// `http` or `websocket`. It defaults to the transport of the Options
+	// (see NewClient).
+	Transport string
+
+	// Options holds the client options for the server.
+	Options *Options
+}
+
+// FleetScan is a device scanned from a server of a fleet.
+type FleetScan struct {
+	// Server is the name of the server the device is on.
+	Server string
+
+	*scheme.Scan
+}
+
+// FleetRead is a reading from a server of a fleet.
+type FleetRead struct {
+	// Server is the name of the server the reading is from.
+	Server string
+
+	*scheme.Read
+}
+
+// FleetTag is a tag from a server of a fleet.
+type FleetTag struct {
+	// Server is the name of the server the tag is from.
+	Server string
+
+	// Tag is the tag.
+	Tag string
+}
+
+// FleetError holds the errors of the servers of a fleet which failed an
+// operation. The other servers completed it, and their results are returned
+// along with the error.
+type FleetError struct {
+	// Errors holds the error of each failed server, by name.
+	Errors map[string]error
+}
+
+// Error implements the error interface.
+func (e *FleetError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = fmt.Sprintf("%s: %v", name, e.Errors[name])
+	}
+	return fmt.Sprintf("%d server(s) failed: %v", len(names), strings.Join(msgs, "; "))
+}
+
+// Is reports whether the error of any of the servers matches the target.
+func (e *FleetError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fleet is a client for a fleet of Synse Servers. It runs operations against
+// all servers concurrently and merges their results, annotated with the name
+// of the server they come from.
+//
+// An operation which fails on some of the servers returns the results of the
+// others, with a FleetError holding the errors of the failed servers.
+type Fleet struct {
+	// names holds the names of the servers, in order.
+	names []string
+
+	// clients holds the client of each server, by name.
+	clients map[string]Client
+}
+
+// NewFleet creates a fleet client for the given servers.
+func NewFleet(servers []FleetServer) (*Fleet, error) {
+	clients := make([]Client, len(servers))
+	names := make([]string, len(servers))
+	for i, s := range servers {
+		var err error
+		clients[i], err = newTransportClient(s.Transport, s.Options)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create a client for server %q", s.Name)
+		}
+		names[i] = s.Name
+	}
+	return NewFleetFromClients(names, clients)
+}
+
+// NewFleetFromClients creates a fleet client from existing clients, with the
+// servers named by names.
+func NewFleetFromClients(names []string, clients []Client) (*Fleet, error) {
+	if len(names) != len(clients) {
+		return nil, errors.Errorf("got %d names for %d clients", len(names), len(clients))
+	}
+
+	f := &Fleet{
+		clients: make(map[string]Client, len(clients)),
+	}
+	for i, name := range names {
+		if name == "" {
+			return nil, errors.New("fleet server names can not be empty")
+		}
+		if _, ok := f.clients[name]; ok {
+			return nil, errors.Errorf("duplicate fleet server name %q", name)
+		}
+		f.names = append(f.names, name)
+		f.clients[name] = clients[i]
+	}
+	return f, nil
+}
+
+// Names returns the names of the servers of the fleet.
+func (f *Fleet) Names() []string {
+	return append([]string(nil), f.names...)
+}
+
+// Client returns the client of the server with the given name.
+func (f *Fleet) Client(name string) (Client, bool) {
+	c, ok := f.clients[name]
+	return c, ok
+}
+
+// Open opens the clients of all servers.
+func (f *Fleet) Open() error {
+	return f.each(context.Background(), func(_ context.Context, _ string, c Client) error {
+		return c.Open()
+	})
+}
+
+// Close closes the clients of all servers.
+func (f *Fleet) Close() error {
+	return f.each(context.Background(), func(_ context.Context, _ string, c Client) error {
+		return c.Close()
+	})
+}
+
+// Scan scans the devices of all servers.
+func (f *Fleet) Scan(ctx context.Context, opts scheme.ScanOptions) ([]*FleetScan, error) {
+	results := make([][]*FleetScan, len(f.names))
+	err := f.eachIndex(ctx, func(ctx context.Context, i int, name string, c Client) error {
+		devices, err := c.ScanContext(ctx, opts)
+		for _, d := range devices {
+			results[i] = append(results[i], &FleetScan{Server: name, Scan: d})
+		}
+		return err
+	})
+
+	var out []*FleetScan
+	for _, r := range results {
+		out = append(out, r...)
+	}
+	return out, err
+}
+
+// Read reads the devices of all servers.
+func (f *Fleet) Read(ctx context.Context, opts scheme.ReadOptions) ([]*FleetRead, error) {
+	results := make([][]*FleetRead, len(f.names))
+	err := f.eachIndex(ctx, func(ctx context.Context, i int, name string, c Client) error {
+		readings, err := c.ReadContext(ctx, opts)
+		for _, r := range readings {
+			results[i] = append(results[i], &FleetRead{Server: name, Read: r})
+		}
+		return err
+	})
+
+	var out []*FleetRead
+	for _, r := range results {
+		out = append(out, r...)
+	}
+	return out, err
+}
+
+// Tags returns the tags of all servers.
+func (f *Fleet) Tags(ctx context.Context, opts scheme.TagsOptions) ([]*FleetTag, error) {
+	results := make([][]*FleetTag, len(f.names))
+	err := f.eachIndex(ctx, func(ctx context.Context, i int, name string, c Client) error {
+		tags, err := c.TagsContext(ctx, opts)
+		for _, t := range tags {
+			results[i] = append(results[i], &FleetTag{Server: name, Tag: t})
+		}
+		return err
+	})
+
+	var out []*FleetTag
+	for _, r := range results {
+		out = append(out, r...)
+	}
+	return out, err
+}
+
+// PluginHealth returns the plugin health summary of each server, by name.
+func (f *Fleet) PluginHealth(ctx context.Context) (map[string]*scheme.PluginHealth, error) {
+	var mu sync.Mutex
+	out := make(map[string]*scheme.PluginHealth, len(f.names))
+	err := f.each(ctx, func(ctx context.Context, name string, c Client) error {
+		health, err := c.PluginHealthContext(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		out[name] = health
+		mu.Unlock()
+		return nil
+	})
+	return out, err
+}
+
+// ReadCache streams the cached readings of all servers into out, in the order
+// they are received. The out channel is closed once all servers are done.
+func (f *Fleet) ReadCache(ctx context.Context, opts scheme.ReadCacheOptions, out chan<- *FleetRead) error {
+	defer close(out)
+
+	return f.each(ctx, func(ctx context.Context, name string, c Client) error {
+		readings := make(chan *scheme.Read)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for r := range readings {
+				select {
+				case out <- &FleetRead{Server: name, Read: r}:
+				case <-ctx.Done():
+					// Drain the readings, so that the client is not blocked
+					// until it notices the context is done.
+				}
+			}
+		}()
+
+		// The client closes the readings channel once it returns.
+		err := c.ReadCacheContext(ctx, opts, readings)
+		<-done
+		return err
+	})
+}
+
+// each runs fn for each server concurrently, returning a FleetError with the
+// errors of the servers for which it failed.
+func (f *Fleet) each(ctx context.Context, fn func(ctx context.Context, name string, c Client) error) error {
+	return f.eachIndex(ctx, func(ctx context.Context, _ int, name string, c Client) error {
+		return fn(ctx, name, c)
+	})
+}
+
+// eachIndex is like each, but also passes the index of the server.
+func (f *Fleet) eachIndex(ctx context.Context, fn func(ctx context.Context, i int, name string, c Client) error) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(f.names))
+	for i, name := range f.names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			errs[i] = fn(ctx, i, name, f.clients[name])
+		}(i, name)
+	}
+	wg.Wait()
+
+	fleetErr := &FleetError{Errors: map[string]error{}}
+	for i, err := range errs {
+		if err != nil {
+			fleetErr.Errors[f.names[i]] = err
+		}
+	}
+	if len(fleetErr.Errors) == 0 {
+		return nil
+	}
+	return fleetErr
+}
@@ -0,0 +1,156 @@
+package synse
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+// newTestFleet creates a fleet of a server per rack, and a server which is
+// down.
+func newTestFleet(t *testing.T) *Fleet {
+	var servers []FleetServer
+	for i, rack := range []string{"rack-1", "rack-2"} {
+		server := synsetest.NewServer(synsetest.Config{
+			Plugins: []synsetest.Plugin{{ID: "plugin-" + rack}},
+			Devices: []synsetest.Device{{
+				ID:       "temp-" + rack,
+				Type:     "temperature",
+				Plugin:   "plugin-" + rack,
+				Tags:     []string{"site:" + rack},
+				Readings: []synsetest.Reading{{Type: "temperature", Value: 20 + i}},
+			}},
+		})
+		t.Cleanup(server.Close)
+
+		transport := "http"
+		if i == 1 {
+			transport = "websocket"
+		}
+		servers = append(servers, FleetServer{
+			Name:      rack,
+			Transport: transport,
+			Options:   &Options{Address: server.Address},
+		})
+	}
+
+	down := synsetest.NewServer(synsetest.Config{})
+	down.Close()
+	servers = append(servers, FleetServer{
+		Name: "rack-3",
+		Options: &Options{
+			Address: down.Address,
+			HTTP: HTTPOptions{
+				Retry: RetryOptions{WaitTime: time.Millisecond, MaxWaitTime: time.Millisecond},
+			},
+		},
+	})
+
+	fleet, err := NewFleet(servers)
+	assert.NoError(t, err)
+	return fleet
+}
+
+// assertFleetError asserts that the error is a FleetError for the servers.
+func assertFleetError(t *testing.T, err error, servers ...string) {
+	var fleetErr *FleetError
+	if !assert.True(t, errors.As(err, &fleetErr), err) {
+		return
+	}
+	var names []string
+	for name := range fleetErr.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, servers, names)
+}
+
+func TestNewFleet(t *testing.T) {
+	_, err := NewFleet([]FleetServer{{Name: "a", Options: &Options{Address: "localhost:5000"}}, {Name: "a", Options: &Options{Address: "localhost:5001"}}})
+	assert.EqualError(t, err, `duplicate fleet server name "a"`)
+
+	_, err = NewFleet([]FleetServer{{Options: &Options{Address: "localhost:5000"}}})
+	assert.EqualError(t, err, "fleet server names can not be empty")
+
+	_, err = NewFleet([]FleetServer{{Name: "a", Transport: "grpc", Options: &Options{Address: "localhost:5000"}}})
+	assert.EqualError(t, err, `failed to create a client for server "a": unknown transport "grpc"`)
+
+	_, err = NewFleet([]FleetServer{{Name: "a", Options: &Options{}}})
+	assert.Error(t, err)
+
+	fleet, err := NewFleet([]FleetServer{{Name: "b", Options: &Options{Address: "localhost:5000"}}, {Name: "a", Transport: "websocket", Options: &Options{Address: "localhost:5001"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, fleet.Names())
+	_, ok := fleet.Client("a")
+	assert.True(t, ok)
+	_, ok = fleet.Client("c")
+	assert.False(t, ok)
+}
+
+func TestFleet(t *testing.T) {
+	fleet := newTestFleet(t)
+	ctx := context.Background()
+
+	// The websocket client of rack-2 connects, and rack-3 is down, but the
+	// HTTP client does not connect until a request is made.
+	assert.NoError(t, fleet.Open())
+	defer fleet.Close() // nolint: errcheck
+
+	devices, err := fleet.Scan(ctx, scheme.ScanOptions{})
+	assertFleetError(t, err, "rack-3")
+	assert.True(t, IsTransport(err))
+	if assert.Len(t, devices, 2) {
+		assert.Equal(t, "rack-1", devices[0].Server)
+		assert.Equal(t, "temp-rack-1", devices[0].ID)
+		assert.Equal(t, "rack-2", devices[1].Server)
+		assert.Equal(t, "temp-rack-2", devices[1].ID)
+	}
+
+	readings, err := fleet.Read(ctx, scheme.ReadOptions{})
+	assertFleetError(t, err, "rack-3")
+	if assert.Len(t, readings, 2) {
+		assert.Equal(t, "rack-1", readings[0].Server)
+		assert.Equal(t, "temp-rack-1", readings[0].Device)
+		assert.Equal(t, "rack-2", readings[1].Server)
+		assert.Equal(t, "temp-rack-2", readings[1].Device)
+	}
+
+	tags, err := fleet.Tags(ctx, scheme.TagsOptions{})
+	assertFleetError(t, err, "rack-3")
+	assert.Contains(t, tags, &FleetTag{Server: "rack-1", Tag: "default/site:rack-1"})
+	assert.Contains(t, tags, &FleetTag{Server: "rack-2", Tag: "default/site:rack-2"})
+
+	health, err := fleet.PluginHealth(ctx)
+	assertFleetError(t, err, "rack-3")
+	assert.Len(t, health, 2)
+	assert.Equal(t, []string{"plugin-rack-1"}, health["rack-1"].Healthy)
+	assert.Equal(t, []string{"plugin-rack-2"}, health["rack-2"].Healthy)
+
+	out := make(chan *FleetRead)
+	done := make(chan error)
+	go func() {
+		done <- fleet.ReadCache(ctx, scheme.ReadCacheOptions{}, out)
+	}()
+	servers := map[string]string{}
+	for r := range out {
+		servers[r.Device] = r.Server
+	}
+	assertFleetError(t, <-done, "rack-3")
+	assert.Equal(t, map[string]string{"temp-rack-1": "rack-1", "temp-rack-2": "rack-2"}, servers)
+}
+
+func TestFleetError(t *testing.T) {
+	err := &FleetError{Errors: map[string]error{
+		"b": errors.Wrap(ErrLimited, "limited"),
+		"a": errors.New("failed"),
+	}}
+	assert.EqualError(t, err, "2 server(s) failed: a: failed; b: limited: synse: client limit exceeded")
+	assert.True(t, IsLimited(err))
+	assert.False(t, IsNotFound(err))
+}
@@ -3,9 +3,11 @@ package synse
 // http.go implements a http client.
 
 import (
-	"crypto/tls"
+	"context"
 	"encoding/json"
-	"net/url"
+	"io"
+	"net/http"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/pkg/errors"
@@ -20,43 +22,43 @@ type httpClient struct {
 	// client holds the resty.Client.
 	client *resty.Client
 
+	// endpoints tracks the addresses of Synse Server.
+	endpoints *endpoints
+
+	// addresses holds the parsed addresses of Synse Server.
+	addresses map[string]address
+
 	// apiVersion is the current api version of Synse Server that we are
 	// communicating with.
 	apiVersion string
-
-	// scheme could either be `http` or `https`, depends on the TLS
-	// configuration.
-	scheme string
 }
 
 // NewHTTPClientV3 returns a new instance of a http client for v3 API.
 func NewHTTPClientV3(opts *Options) (Client, error) {
-	c, err := createHTTPClient(opts)
+	// An address with the https scheme enables TLS, so the addresses are
+	// parsed before the TLS configuration is set up.
+	addresses, err := parseAddresses(opts)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create a http client")
 	}
 
-	u, err := url.ParseRequestURI(opts.Address)
-	if err == nil && u != nil && u.Scheme == "https" {
-		opts.TLS.Enabled = true
-		opts.Address = u.Host
-	}
-
-	s := "http"
-	if opts.TLS.Enabled {
-		s = "https"
+	c, err := createHTTPClient(opts, addresses)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a http client")
 	}
 
-	return &httpClient{
+	return withMiddleware(&httpClient{
 		options:    opts,
 		client:     c,
+		endpoints:  newEndpoints(opts),
+		addresses:  addresses,
 		apiVersion: "v3",
-		scheme:     s,
-	}, nil
+	}, "http", opts), nil
 }
 
-// createHTTPClient setups a resty client with configured options.
-func createHTTPClient(opts *Options) (*resty.Client, error) {
+// createHTTPClient setups a resty client with configured options, which
+// dials the given addresses.
+func createHTTPClient(opts *Options, addresses map[string]address) (*resty.Client, error) {
 	err := setDefaults(opts)
 	if err != nil {
 		return nil, err
@@ -71,23 +73,36 @@ func createHTTPClient(opts *Options) (*resty.Client, error) {
 		SetRetryMaxWaitTime(opts.HTTP.Retry.MaxWaitTime).
 		SetRedirectPolicy(resty.FlexibleRedirectPolicy(opts.HTTP.Redirects))
 
+	proxy, err := newProxyFunc(opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	transport, ok := client.GetClient().Transport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("failed to set up the http transport: unexpected transport type")
+	}
+	transport.Proxy = bypassUnix(proxy, addresses)
+	dial := newDialContext(opts, addresses)
+	if dial != nil {
+		transport.DialContext = dial
+	}
+
 	if !opts.TLS.Enabled {
 		return client, nil
 	}
 
 	// Setup TLS if it's enable.
-	cert, err := setTLS(opts)
+	cfg, err := newTLSConfig(opts.TLS)
 	if err != nil {
 		return nil, err
 	}
-
-	if cert != nil {
-		client = client.SetCertificates(*cert)
+	// The requests to unix socket addresses are never proxied, so their TLS
+	// connections can be dialed directly.
+	if dialTLS := newDialTLSContext(dial, cfg, addresses); dialTLS != nil {
+		transport.DialTLSContext = dialTLS
 	}
 
-	// NOTE - refer to #24. If not disable linting, a warning will happen:
-	// TLS InsecureSkipVerify may be true.,HIGH,LOW (gosec)
-	return client.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: opts.TLS.SkipVerify}), nil // nolint
+	return client.SetTLSClientConfig(cfg), nil
 }
 
 // Open opens the connection between the client and Synse Server. This fulfils
@@ -96,6 +111,11 @@ func (c *httpClient) Open() error {
 	return nil
 }
 
+// OpenContext is like Open, but uses the given context.
+func (c *httpClient) OpenContext(ctx context.Context) error {
+	return nil
+}
+
 // Close closes the connection between the client and Synse Server. This fulfils
 // the Client interface, but has no effect for the httpClient.
 func (c *httpClient) Close() error {
@@ -104,8 +124,13 @@ func (c *httpClient) Close() error {
 
 // Status returns the status info.
 func (c *httpClient) Status() (*scheme.Status, error) {
+	return c.StatusContext(context.Background())
+}
+
+// StatusContext is like Status, but uses the given context.
+func (c *httpClient) StatusContext(ctx context.Context) (*scheme.Status, error) {
 	out := new(scheme.Status)
-	err := c.getUnversioned(testURI, out)
+	err := c.getUnversioned(ctx, testURI, out)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to request `/test` endpoint")
 	}
@@ -115,8 +140,13 @@ func (c *httpClient) Status() (*scheme.Status, error) {
 
 // Version returns the version info.
 func (c *httpClient) Version() (*scheme.Version, error) {
+	return c.VersionContext(context.Background())
+}
+
+// VersionContext is like Version, but uses the given context.
+func (c *httpClient) VersionContext(ctx context.Context) (*scheme.Version, error) {
 	out := new(scheme.Version)
-	err := c.getUnversioned(versionURI, out)
+	err := c.getUnversioned(ctx, versionURI, out)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to request `/version` endpoint")
 	}
@@ -126,8 +156,13 @@ func (c *httpClient) Version() (*scheme.Version, error) {
 
 // Config returns the config info.
 func (c *httpClient) Config() (*scheme.Config, error) {
+	return c.ConfigContext(context.Background())
+}
+
+// ConfigContext is like Config, but uses the given context.
+func (c *httpClient) ConfigContext(ctx context.Context) (*scheme.Config, error) {
 	out := new(scheme.Config)
-	if err := c.getVersioned(configURI, out); err != nil {
+	if err := c.getVersioned(ctx, configURI, out); err != nil {
 		return nil, err
 	}
 
@@ -137,8 +172,13 @@ func (c *httpClient) Config() (*scheme.Config, error) {
 // Plugins returns the summary of all plugins currently registered with
 // Synse Server.
 func (c *httpClient) Plugins() ([]*scheme.PluginMeta, error) {
+	return c.PluginsContext(context.Background())
+}
+
+// PluginsContext is like Plugins, but uses the given context.
+func (c *httpClient) PluginsContext(ctx context.Context) ([]*scheme.PluginMeta, error) {
 	out := new([]*scheme.PluginMeta)
-	if err := c.getVersioned(pluginURI, out); err != nil {
+	if err := c.getVersioned(ctx, pluginURI, out); err != nil {
 		return nil, err
 	}
 
@@ -147,8 +187,13 @@ func (c *httpClient) Plugins() ([]*scheme.PluginMeta, error) {
 
 // Plugin returns data from a specific plugin.
 func (c *httpClient) Plugin(id string) (*scheme.Plugin, error) {
+	return c.PluginContext(context.Background(), id)
+}
+
+// PluginContext is like Plugin, but uses the given context.
+func (c *httpClient) PluginContext(ctx context.Context, id string) (*scheme.Plugin, error) {
 	out := new(scheme.Plugin)
-	if err := c.getVersioned(makePath(pluginURI, id), out); err != nil {
+	if err := c.getVersioned(ctx, makePath(pluginURI, id), out); err != nil {
 		return nil, err
 	}
 
@@ -157,8 +202,13 @@ func (c *httpClient) Plugin(id string) (*scheme.Plugin, error) {
 
 // PluginHealth returns the summary of the health of registered plugins.
 func (c *httpClient) PluginHealth() (*scheme.PluginHealth, error) {
+	return c.PluginHealthContext(context.Background())
+}
+
+// PluginHealthContext is like PluginHealth, but uses the given context.
+func (c *httpClient) PluginHealthContext(ctx context.Context) (*scheme.PluginHealth, error) {
 	out := new(scheme.PluginHealth)
-	if err := c.getVersioned(pluginHealthURI, out); err != nil {
+	if err := c.getVersioned(ctx, pluginHealthURI, out); err != nil {
 		return nil, err
 	}
 
@@ -169,8 +219,17 @@ func (c *httpClient) PluginHealth() (*scheme.PluginHealth, error) {
 // from/write to via the configured plugins. It can be filtered to show
 // only those devices which match a set of provided tags by using ScanOptions.
 func (c *httpClient) Scan(opts scheme.ScanOptions) ([]*scheme.Scan, error) {
+	return c.ScanContext(context.Background(), opts)
+}
+
+// ScanContext is like Scan, but uses the given context.
+func (c *httpClient) ScanContext(ctx context.Context, opts scheme.ScanOptions) ([]*scheme.Scan, error) {
+	if err := validateTags([]string{opts.NS}, opts.Tags); err != nil {
+		return nil, err
+	}
+
 	out := new([]*scheme.Scan)
-	if err := c.getVersionedQueryParams(scanURI, opts, out); err != nil {
+	if err := c.getVersionedQueryParams(ctx, scanURI, opts, out); err != nil {
 		return nil, err
 	}
 
@@ -180,8 +239,17 @@ func (c *httpClient) Scan(opts scheme.ScanOptions) ([]*scheme.Scan, error) {
 // Tags returns the list of all tags currently associated with devices.
 // If no TagsOptions is specified, the default tag namespace will be used.
 func (c *httpClient) Tags(opts scheme.TagsOptions) ([]string, error) {
+	return c.TagsContext(context.Background(), opts)
+}
+
+// TagsContext is like Tags, but uses the given context.
+func (c *httpClient) TagsContext(ctx context.Context, opts scheme.TagsOptions) ([]string, error) {
+	if err := validateTags(opts.NS, nil); err != nil {
+		return nil, err
+	}
+
 	out := new([]string)
-	if err := c.getVersionedQueryParams(tagsURI, opts, out); err != nil {
+	if err := c.getVersionedQueryParams(ctx, tagsURI, opts, out); err != nil {
 		return nil, err
 	}
 
@@ -191,8 +259,13 @@ func (c *httpClient) Tags(opts scheme.TagsOptions) ([]string, error) {
 // Info returns the full set of meta info and capabilities for a specific
 // device.
 func (c *httpClient) Info(id string) (*scheme.Info, error) {
+	return c.InfoContext(context.Background(), id)
+}
+
+// InfoContext is like Info, but uses the given context.
+func (c *httpClient) InfoContext(ctx context.Context, id string) (*scheme.Info, error) {
 	out := new(scheme.Info)
-	if err := c.getVersioned(makePath(infoURI, id), out); err != nil {
+	if err := c.getVersioned(ctx, makePath(infoURI, id), out); err != nil {
 		return nil, err
 	}
 
@@ -202,8 +275,17 @@ func (c *httpClient) Info(id string) (*scheme.Info, error) {
 // Read returns data from devices which match the set of provided tags
 // using ReadOptions.
 func (c *httpClient) Read(opts scheme.ReadOptions) ([]*scheme.Read, error) {
+	return c.ReadContext(context.Background(), opts)
+}
+
+// ReadContext is like Read, but uses the given context.
+func (c *httpClient) ReadContext(ctx context.Context, opts scheme.ReadOptions) ([]*scheme.Read, error) {
+	if err := validateTags([]string{opts.NS}, opts.Tags); err != nil {
+		return nil, err
+	}
+
 	out := new([]*scheme.Read)
-	if err := c.getVersionedQueryParams(readURI, opts, out); err != nil {
+	if err := c.getVersionedQueryParams(ctx, readURI, opts, out); err != nil {
 		return nil, err
 	}
 
@@ -213,8 +295,13 @@ func (c *httpClient) Read(opts scheme.ReadOptions) ([]*scheme.Read, error) {
 // ReadDevice returns data from a specific device. It is the same as Read()
 // where the label matches the device id tag specified in ReadOptions.
 func (c *httpClient) ReadDevice(id string) ([]*scheme.Read, error) {
+	return c.ReadDeviceContext(context.Background(), id)
+}
+
+// ReadDeviceContext is like ReadDevice, but uses the given context.
+func (c *httpClient) ReadDeviceContext(ctx context.Context, id string) ([]*scheme.Read, error) {
 	out := new([]*scheme.Read)
-	if err := c.getVersioned(makePath(readURI, id), out); err != nil {
+	if err := c.getVersioned(ctx, makePath(readURI, id), out); err != nil {
 		return nil, err
 	}
 
@@ -223,34 +310,169 @@ func (c *httpClient) ReadDevice(id string) ([]*scheme.Read, error) {
 
 // ReadCache returns cached reading data from the registered plugins.
 func (c *httpClient) ReadCache(opts scheme.ReadCacheOptions, out chan<- *scheme.Read) error {
+	return c.ReadCacheContext(context.Background(), opts, out)
+}
+
+// ReadCacheContext is like ReadCache, but uses the given context.
+// Cancelling the context stops decoding the response and closes the out
+// channel.
+func (c *httpClient) ReadCacheContext(ctx context.Context, opts scheme.ReadCacheOptions, out chan<- *scheme.Read) error {
 	defer close(out)
-	errScheme := new(scheme.Error)
 
-	resp, err := c.setVersioned().R().SetDoNotParseResponse(true).SetQueryParamsFromValues(structToURLValues(opts)).SetError(errScheme).Get(readcacheURI)
-	if err = check(err, errScheme); err != nil {
+	var resp *resty.Response
+	err := c.do(ctx, true, func(req *resty.Request, address string) error {
+		var err error
+		errScheme := new(scheme.Error)
+		resp, err = req.SetDoNotParseResponse(true).SetQueryParamsFromValues(structToURLValues(opts)).Get(c.versionedURL(address, readcacheURI))
+		if err != nil || resp.IsError() {
+			// The body is only read below for a successful response, so it
+			// is released here otherwise.
+			defer discardBody(resp)
+		}
+		if err != nil {
+			return contextError(ctx, check(resp, err, errScheme))
+		}
+
+		// The response is not parsed by resty, so an error response needs to
+		// be decoded here.
+		if resp.IsError() {
+			// Failing to decode still results in an APIError from the status
+			// code.
+			_ = json.NewDecoder(resp.RawBody()).Decode(errScheme)
+			return check(resp, nil, errScheme)
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
+	defer resp.RawBody().Close() // nolint: errcheck
 
 	dec := json.NewDecoder(resp.RawBody())
 	for dec.More() {
 		var read = new(scheme.Read)
 		if err := dec.Decode(read); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			return errors.Wrap(err, "failed to decode a JSON response into an appropriate struct")
 		}
-		out <- read
+
+		select {
+		case out <- read:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 	return nil
 }
 
+// discardBody drains and closes the body of a response which is not parsed
+// by resty, so that its connection is released for reuse.
+func discardBody(resp *resty.Response) {
+	if resp == nil || resp.RawBody() == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.RawBody())
+	_ = resp.RawBody().Close()
+}
+
 // ReadStream returns a stream of current reading data from the registered plugins.
 func (c *httpClient) ReadStream(opts scheme.ReadStreamOptions, out chan<- *scheme.Read, stop chan struct{}) error {
-	return errors.New("Streamed readings is not currently supported via the HTTP API")
+	return c.ReadStreamContext(context.Background(), opts, out, stop)
+}
+
+// ReadStreamContext is like ReadStream, but uses the given context.
+//
+// The HTTP API does not support streamed readings, so the stream is emulated
+// by polling the `/read` endpoint every HTTPOptions.PollInterval for the
+// devices selected by the stream options. Readings which were already emitted
+// (same device, type and timestamp) are dropped. A poll which fails with a
+// transient error, e.g. a server error, is retried with the backoff of
+// HTTPOptions.Retry, for up to its count of consecutive failures. The stream
+// is terminated when either the stop channel is closed or the context is done.
+func (c *httpClient) ReadStreamContext(ctx context.Context, opts scheme.ReadStreamOptions, out chan<- *scheme.Read, stop chan struct{}) error {
+	if err := validateStreamTags(opts); err != nil {
+		return err
+	}
+
+	if c.options.HTTP.PollInterval <= 0 {
+		return errors.Errorf("invalid poll interval %v: it must be positive", c.options.HTTP.PollInterval)
+	}
+
+	readOpts := scheme.ReadOptions{
+		Tags: streamTags(opts),
+	}
+
+	ticker := time.NewTicker(c.options.HTTP.PollInterval)
+	defer ticker.Stop()
+
+	// last holds the timestamp of the last reading emitted for each device
+	// reading type.
+	last := map[string]string{}
+
+	// failures counts the consecutive polls which failed with a transient
+	// error.
+	var failures uint
+	retry := c.options.HTTP.Retry
+	for {
+		readings, err := c.ReadContext(ctx, readOpts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !isFailure(err) || failures >= retry.Count {
+				return errors.Wrap(err, "failed to poll reading data")
+			}
+
+			wait := backoff(retry.WaitTime, retry.MaxWaitTime, failures)
+			failures++
+			select {
+			case <-time.After(wait):
+			case <-stop:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+		failures = 0
+
+		for _, r := range readings {
+			key := r.Device + "/" + r.Type
+			if ts, ok := last[key]; ok && ts == r.Timestamp {
+				continue
+			}
+			last[key] = r.Timestamp
+
+			select {
+			case out <- r:
+			case <-stop:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 }
 
 // WriteAsync writes data to a device, in an asynchronous manner.
 func (c *httpClient) WriteAsync(id string, opts []scheme.WriteData) ([]*scheme.Write, error) {
+	return c.WriteAsyncContext(context.Background(), id, opts)
+}
+
+// WriteAsyncContext is like WriteAsync, but uses the given context.
+func (c *httpClient) WriteAsyncContext(ctx context.Context, id string, opts []scheme.WriteData) ([]*scheme.Write, error) {
 	out := new([]*scheme.Write)
-	if err := c.postVersioned(makePath(writeURI, id), opts, out); err != nil {
+	if err := c.postVersioned(ctx, makePath(writeURI, id), opts, out); err != nil {
 		return nil, err
 	}
 
@@ -259,8 +481,13 @@ func (c *httpClient) WriteAsync(id string, opts []scheme.WriteData) ([]*scheme.W
 
 // WriteSync writes data to a device, waiting for the write to complete.
 func (c *httpClient) WriteSync(id string, opts []scheme.WriteData) ([]*scheme.Transaction, error) {
+	return c.WriteSyncContext(context.Background(), id, opts)
+}
+
+// WriteSyncContext is like WriteSync, but uses the given context.
+func (c *httpClient) WriteSyncContext(ctx context.Context, id string, opts []scheme.WriteData) ([]*scheme.Transaction, error) {
 	out := new([]*scheme.Transaction)
-	if err := c.postVersioned(makePath(writeWaitURI, id), opts, out); err != nil {
+	if err := c.postVersioned(ctx, makePath(writeWaitURI, id), opts, out); err != nil {
 		return nil, err
 	}
 
@@ -269,8 +496,13 @@ func (c *httpClient) WriteSync(id string, opts []scheme.WriteData) ([]*scheme.Tr
 
 // Transactions returns the sorted list of all cached transaction IDs.
 func (c *httpClient) Transactions() ([]string, error) {
+	return c.TransactionsContext(context.Background())
+}
+
+// TransactionsContext is like Transactions, but uses the given context.
+func (c *httpClient) TransactionsContext(ctx context.Context) ([]string, error) {
 	out := new([]string)
-	if err := c.getVersioned(transactionURI, out); err != nil {
+	if err := c.getVersioned(ctx, transactionURI, out); err != nil {
 		return nil, err
 	}
 
@@ -279,8 +511,13 @@ func (c *httpClient) Transactions() ([]string, error) {
 
 // Transaction returns the state and status of a write transaction.
 func (c *httpClient) Transaction(id string) (*scheme.Transaction, error) {
+	return c.TransactionContext(context.Background(), id)
+}
+
+// TransactionContext is like Transaction, but uses the given context.
+func (c *httpClient) TransactionContext(ctx context.Context, id string) (*scheme.Transaction, error) {
 	out := new(scheme.Transaction)
-	if err := c.getVersioned(makePath(transactionURI, id), out); err != nil {
+	if err := c.getVersioned(ctx, makePath(transactionURI, id), out); err != nil {
 		return nil, err
 	}
 
@@ -294,54 +531,139 @@ func (c *httpClient) GetOptions() *Options {
 
 // getVersionedQueryParams performs a GET request using query parameters
 // against the Synse Server versioned API.
-func (c *httpClient) getVersionedQueryParams(uri string, params interface{}, okScheme interface{}) error {
-	errScheme := new(scheme.Error)
-	_, err := c.setVersioned().R().SetQueryParamsFromValues(structToURLValues(params)).SetResult(okScheme).SetError(errScheme).Get(uri)
-	return check(err, errScheme)
-
+func (c *httpClient) getVersionedQueryParams(ctx context.Context, uri string, params interface{}, okScheme interface{}) error {
+	return c.do(ctx, true, func(req *resty.Request, address string) error {
+		errScheme := new(scheme.Error)
+		resp, err := req.SetQueryParamsFromValues(structToURLValues(params)).SetResult(okScheme).SetError(errScheme).Get(c.versionedURL(address, uri))
+		return contextError(ctx, check(resp, err, errScheme))
+	})
 }
 
 // getVersioned performs a GET request against the Synse Server versioned API.
-func (c *httpClient) getVersioned(uri string, okScheme interface{}) error {
+func (c *httpClient) getVersioned(ctx context.Context, uri string, okScheme interface{}) error {
 	params := struct{}{}
-	return c.getVersionedQueryParams(uri, params, okScheme)
+	return c.getVersionedQueryParams(ctx, uri, params, okScheme)
 }
 
 // getUnversioned performs a GET request against the Synse Server unversioned API.
-func (c *httpClient) getUnversioned(uri string, okScheme interface{}) error {
-	errScheme := new(scheme.Error)
-	_, err := c.setUnversioned().R().SetResult(okScheme).SetError(errScheme).Get(uri)
-	return check(err, errScheme)
+func (c *httpClient) getUnversioned(ctx context.Context, uri string, okScheme interface{}) error {
+	return c.do(ctx, true, func(req *resty.Request, address string) error {
+		errScheme := new(scheme.Error)
+		resp, err := req.SetResult(okScheme).SetError(errScheme).Get(c.unversionedURL(address, uri))
+		return contextError(ctx, check(resp, err, errScheme))
+	})
 }
 
 // postVersioned performs a POST request against the Synse Server versioned API.
-func (c *httpClient) postVersioned(uri string, body interface{}, okScheme interface{}) error {
-	errScheme := new(scheme.Error)
-	_, err := c.setVersioned().R().SetBody(body).SetResult(okScheme).SetError(errScheme).Post(uri)
-	return check(err, errScheme)
+func (c *httpClient) postVersioned(ctx context.Context, uri string, body interface{}, okScheme interface{}) error {
+	return c.do(ctx, false, func(req *resty.Request, address string) error {
+		errScheme := new(scheme.Error)
+		resp, err := req.SetBody(body).SetResult(okScheme).SetError(errScheme).Post(c.versionedURL(address, uri))
+		return contextError(ctx, check(resp, err, errScheme))
+	})
 }
 
-// setUnversioned returns a client that uses unversioned host URL.
-func (c *httpClient) setUnversioned() *resty.Client {
-	return c.client.SetBaseURL(buildURL(c.scheme, c.options.Address))
+// do makes a request against the active address of Synse Server. If it fails
+// with a transport or server error, it is made against the next addresses in
+// turn, and the first one which serves it becomes the active address. A
+// request which is not idempotent, e.g. a write, is only made again if it was
+// not sent, since the failed address may have acted on it.
+func (c *httpClient) do(ctx context.Context, idempotent bool, send func(req *resty.Request, address string) error) error {
+	c.endpoints.checkPrimary(c.healthy)
+
+	var err error
+	for _, address := range c.endpoints.order() {
+		err = c.send(ctx, address, send)
+		if err == nil || ctx.Err() != nil || !failsOver(err) || (!idempotent && !notSent(err)) {
+			return err
+		}
+		c.endpoints.failed(address)
+	}
+	return err
 }
 
-// setVersioned returns a client that uses versioned host URL.
-func (c *httpClient) setVersioned() *resty.Client {
-	return c.client.SetBaseURL(buildURL(c.scheme, c.options.Address, c.apiVersion))
+// send makes a request against the given address. If it is rejected as
+// unauthorized and the credentials can be refreshed, it is made once more
+// with the refreshed credentials.
+func (c *httpClient) send(ctx context.Context, address string, send func(req *resty.Request, address string) error) error {
+	for refreshed := false; ; refreshed = true {
+		req, err := c.request(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = send(req, address)
+		if refreshed || !IsUnauthorized(err) {
+			return err
+		}
+		if ok, rerr := refreshAuth(ctx, c.options.Auth); rerr != nil {
+			return rerr
+		} else if !ok {
+			return err
+		}
+	}
 }
 
-// check validates returned response from the Synse Server.
-func check(err error, errResp *scheme.Error) error {
+// healthy reports whether Synse Server at the given address is healthy. It
+// is used to check the primary address after failing over.
+func (c *httpClient) healthy(address string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.HTTP.Timeout)
+	defer cancel()
+
+	req, err := c.request(ctx)
 	if err != nil {
-		return errors.Wrap(err, "failed to make a request to synse server")
+		return false
 	}
+	errScheme := new(scheme.Error)
+	resp, err := req.SetResult(new(scheme.Status)).SetError(errScheme).Get(c.unversionedURL(address, testURI))
+	return check(resp, err, errScheme) == nil
+}
 
+// request creates a request with the given context, carrying the headers
+// added by the middleware of the operation and the credentials of the auth
+// provider.
+func (c *httpClient) request(ctx context.Context) (*resty.Request, error) {
+	req := c.client.R().SetContext(ctx)
+	for k, v := range operationHeader(ctx) {
+		req.Header[k] = append(req.Header[k], v...)
+	}
+	if err := applyAuth(ctx, c.options.Auth, req.Header); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// unversionedURL returns the full URL of an unversioned API endpoint. The
+// URL is built per request rather than set as the base URL of the shared
+// resty client, so the client is safe for concurrent use.
+func (c *httpClient) unversionedURL(address, uri string) string {
+	return c.addresses[address].url("http", "https", c.options.TLS.Enabled, uri)
+}
+
+// versionedURL returns the full URL of a versioned API endpoint.
+func (c *httpClient) versionedURL(address, uri string) string {
+	return c.addresses[address].url("http", "https", c.options.TLS.Enabled, c.apiVersion, uri)
+}
+
+// check validates returned response from the Synse Server. An error response
+// is returned as an APIError; a failure to make the request is returned as a
+// TransportError.
+func check(resp *resty.Response, err error, errResp *scheme.Error) error {
 	if *errResp != (scheme.Error{}) {
-		return errors.Errorf(
-			"got a %v error response from synse server at %v, saying %v, with context: %v",
-			errResp.HTTPCode, errResp.Timestamp, errResp.Description, errResp.Context,
-		)
+		return newAPIError(*errResp)
+	}
+
+	// The response may be an error that does not carry an error scheme, e.g.
+	// one returned by a proxy in front of Synse Server.
+	if resp != nil && resp.IsError() {
+		return newAPIError(scheme.Error{
+			HTTPCode:    resp.StatusCode(),
+			Description: http.StatusText(resp.StatusCode()),
+		})
+	}
+
+	if err != nil {
+		return newTransportError(err, "failed to make a request to synse server")
 	}
 
 	return nil
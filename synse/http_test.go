@@ -1,7 +1,13 @@
 package synse
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -53,6 +59,7 @@ func TestNewHTTPClientV3_defaults(t *testing.T) {
 	assert.False(t, client.GetOptions().TLS.Enabled)
 	assert.False(t, client.GetOptions().TLS.SkipVerify)
 	assert.Equal(t, 5, client.GetOptions().HTTP.Redirects)
+	assert.Equal(t, 1*time.Second, client.GetOptions().HTTP.PollInterval)
 }
 
 func TestNewHTTPClientV3_ValidAddress(t *testing.T) {
@@ -154,6 +161,65 @@ func TestHTTPClientV3_Status_500(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestHTTPClientV3_StatusContext_Cancelled(t *testing.T) {
+	in := `
+{
+  "status":"ok",
+  "timestamp":"2019-03-20T17:37:07Z"
+}`
+
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	server.ServeUnversioned(t, "/test", 200, in)
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resp, err := client.StatusContext(ctx)
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestHTTPClientV3_ConcurrentRequests(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	server.ServeUnversioned(t, "/test", 200, `{"status":"ok","timestamp":"2019-03-20T17:37:07Z"}`)
+	server.ServeVersioned(t, "/tags", 200, `["default/foo"]`)
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Status()
+			assert.NoError(t, err)
+			assert.NotNil(t, resp)
+		}()
+		go func() {
+			defer wg.Done()
+			resp, err := client.Tags(scheme.TagsOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"default/foo"}, resp)
+		}()
+	}
+	wg.Wait()
+}
+
 func TestHTTPClientV3_Version_200(t *testing.T) {
 	in := `
 {
@@ -1283,6 +1349,243 @@ func TestHTTPClientV3_ReadCache_500(t *testing.T) {
 	assert.Empty(t, results)
 }
 
+func TestHTTPClientV3_ReadCache_ErrorReleasesConnection(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	// The error is followed by padding the decoder does not read, which has
+	// to be drained for the connection to be reused.
+	var mu sync.Mutex
+	conns := map[string]bool{}
+	server.HandleVersioned("/readcache", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conns[r.RemoteAddr] = true
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fprintf(t, w, `{"http_code":404,"description":"resource not found","context":"no readings"}%s`, strings.Repeat(" ", 1<<20))
+	})
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err := client.ReadCache(scheme.ReadCacheOptions{}, make(chan *scheme.Read))
+		assert.True(t, IsNotFound(err))
+	}
+	assert.Len(t, conns, 1)
+}
+
+func TestHTTPClientV3_ReadStream_200(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	// Every other poll, the led reading gets a new timestamp whereas the
+	// temperature reading stays the same.
+	var mu sync.Mutex
+	var polls int
+	var tags []string
+	server.HandleVersioned("/read", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		tags = r.URL.Query()["tags"]
+		polls++
+		w.Header().Set("Content-Type", "application/json")
+		fprintf(t, w, `[
+  {"device":"led","type":"state","value":"on","timestamp":"2019-03-20T17:37:0%dZ"},
+  {"device":"temp","type":"temperature","value":20.3,"timestamp":"2019-03-20T17:37:07Z"}
+]`, polls/2)
+	})
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+		HTTP: HTTPOptions{
+			PollInterval: 20 * time.Millisecond,
+		},
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	opts := scheme.ReadStreamOptions{
+		Ids:  []string{"led", "temp"},
+		Tags: []string{"default/foo"},
+	}
+	readings := make(chan *scheme.Read, 10)
+	stop := make(chan struct{})
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- client.ReadStream(opts, readings, stop)
+	}()
+
+	var results []*scheme.Read
+	for len(results) < 4 {
+		select {
+		case r := <-readings:
+			results = append(results, r)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout: failed getting read stream data from channel")
+		}
+	}
+	close(stop)
+
+	select {
+	case err := <-errs:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout: read stream was not terminated")
+	}
+
+	// The temperature reading is only emitted once, the led reading is
+	// emitted once per new timestamp.
+	assert.Equal(t, "led", results[0].Device)
+	assert.Equal(t, "2019-03-20T17:37:00Z", results[0].Timestamp)
+	assert.Equal(t, "temp", results[1].Device)
+	assert.Equal(t, "led", results[2].Device)
+	assert.Equal(t, "2019-03-20T17:37:01Z", results[2].Timestamp)
+	assert.Equal(t, "led", results[3].Device)
+	assert.Equal(t, "2019-03-20T17:37:02Z", results[3].Timestamp)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"system/id:led", "system/id:temp", "default/foo"}, tags)
+}
+
+func TestHTTPClientV3_ReadStream_FailedPoll(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	// The second poll fails, the stream carries on with the next one.
+	var mu sync.Mutex
+	var polls int
+	server.HandleVersioned("/read", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		polls++
+		w.Header().Set("Content-Type", "application/json")
+		if polls == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			fprintf(t, w, `{"http_code":500,"description":"unknown error","context":"unknown error"}`)
+			return
+		}
+		fprintf(t, w, `[{"device":"led","type":"state","value":"on","timestamp":"2019-03-20T17:37:0%dZ"}]`, polls)
+	})
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+		HTTP: HTTPOptions{
+			PollInterval: 20 * time.Millisecond,
+			Retry: RetryOptions{
+				WaitTime: 10 * time.Millisecond,
+			},
+		},
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	readings := make(chan *scheme.Read, 10)
+	stop := make(chan struct{})
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- client.ReadStream(scheme.ReadStreamOptions{}, readings, stop)
+	}()
+
+	var results []*scheme.Read
+	for len(results) < 2 {
+		select {
+		case r := <-readings:
+			results = append(results, r)
+		case err := <-errs:
+			t.Fatalf("read stream terminated: %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout: failed getting read stream data from channel")
+		}
+	}
+	close(stop)
+	assert.NoError(t, <-errs)
+
+	assert.Equal(t, "2019-03-20T17:37:01Z", results[0].Timestamp)
+	assert.Equal(t, "2019-03-20T17:37:03Z", results[1].Timestamp)
+}
+
+func TestHTTPClientV3_ReadStream_500(t *testing.T) {
+	in := `
+{
+  "http_code":500,
+  "description":"unknown error",
+  "timestamp":"2019-03-20T17:37:07Z",
+  "context":"unknown error"
+}`
+
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	server.ServeVersioned(t, "/read", 500, in)
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	readings := make(chan *scheme.Read, 1)
+	err = client.ReadStream(scheme.ReadStreamOptions{}, readings, make(chan struct{}))
+	assert.Error(t, err)
+	assert.True(t, IsServerError(err))
+	assert.Empty(t, readings)
+}
+
+func TestHTTPClientV3_ReadStreamContext_Cancelled(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	server.ServeVersioned(t, "/read", 200, "[]")
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err = client.ReadStreamContext(ctx, scheme.ReadStreamOptions{}, make(chan *scheme.Read), make(chan struct{}))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestHTTPClientV3_ReadStream_ZeroPollInterval(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	server.ServeVersioned(t, "/read", 200, "[]")
+
+	// Loaded options keep their zero values.
+	opts, err := defaultOptions()
+	assert.NoError(t, err)
+	opts.Address = server.URL
+	opts.HTTP.PollInterval = 0
+
+	client, err := NewHTTPClientV3(opts)
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.ReadStream(scheme.ReadStreamOptions{}, make(chan *scheme.Read), make(chan struct{}))
+	assert.EqualError(t, err, "invalid poll interval 0s: it must be positive")
+}
+
+// fprintf calls fmt.Fprintf and validates its returned error.
+func fprintf(t *testing.T, w http.ResponseWriter, format string, a ...interface{}) {
+	_, err := fmt.Fprintf(w, format, a...)
+	if err != nil {
+		t.Errorf("expected no error, but got: %v", err)
+	}
+}
+
 func TestHTTPClientV3_WriteAsync_200(t *testing.T) {
 	in := `
 [
@@ -0,0 +1,316 @@
+package synse
+
+// limit.go provides the client-side rate limits and concurrency caps.
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Names of the limit budgets, as reported by a LimitError.
+const (
+	budgetAll    = "all"
+	budgetReads  = "reads"
+	budgetWrites = "writes"
+	budgetScans  = "scans"
+)
+
+// operationClasses maps the operations to the class of their budget.
+var operationClasses = map[string]string{
+	"Read":       budgetReads,
+	"ReadDevice": budgetReads,
+	"ReadCache":  budgetReads,
+	"ReadStream": budgetReads,
+	"WriteAsync": budgetWrites,
+	"WriteSync":  budgetWrites,
+	"Scan":       budgetScans,
+	"Tags":       budgetScans,
+	"Info":       budgetScans,
+}
+
+// LimitError is returned for an operation which exceeds a budget of the
+// LimitOptions when they are set to fail fast.
+type LimitError struct {
+	// Operation is the name of the operation, e.g. `Read`.
+	Operation string
+
+	// Budget is the name of the exceeded budget: `all` for the overall
+	// budget, or `reads`, `writes` or `scans` for the budget of a class.
+	Budget string
+
+	// InFlight is set if the cap on requests in flight was exceeded, rather
+	// than the rate limit.
+	InFlight bool
+
+	// RetryAfter is the wait time until the rate limit allows the request.
+	// It is zero if the cap on requests in flight was exceeded.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	if e.InFlight {
+		return fmt.Sprintf("synse: %s exceeds the cap on %s requests in flight", e.Operation, e.Budget)
+	}
+	return fmt.Sprintf("synse: %s exceeds the %s rate limit, retry after %v", e.Operation, e.Budget, e.RetryAfter)
+}
+
+// Is reports whether the target is ErrLimited.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimited
+}
+
+// limiter enforces the budgets of the LimitOptions.
+type limiter struct {
+	// failFast specifies whether an operation which exceeds a budget fails
+	// instead of waiting.
+	failFast bool
+
+	// all is the overall budget.
+	all *budget
+
+	// classes holds the budgets of the operation classes.
+	classes map[string]*budget
+}
+
+// newLimiter creates a limiter for the options. It returns nil if the options
+// set no budget.
+func newLimiter(opts LimitOptions) *limiter {
+	l := &limiter{
+		failFast: opts.FailFast,
+		all:      newBudget(budgetAll, opts.LimitBudget),
+		classes:  map[string]*budget{},
+	}
+	for name, b := range map[string]LimitBudget{
+		budgetReads:  opts.Reads,
+		budgetWrites: opts.Writes,
+		budgetScans:  opts.Scans,
+	} {
+		if lb := newBudget(name, b); lb != nil {
+			l.classes[name] = lb
+		}
+	}
+
+	if l.all == nil && len(l.classes) == 0 {
+		return nil
+	}
+	return l
+}
+
+// middleware returns the middleware which makes the operations stay within
+// the budgets.
+func (l *limiter) middleware(next Handler) Handler {
+	return func(ctx context.Context, op *Operation) (interface{}, error) {
+		release, err := l.acquire(ctx, op.Name)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return next(ctx, op)
+	}
+}
+
+// acquire takes from the budgets of the operation, waiting for them unless
+// failing fast. It returns a function which releases the requests in flight.
+func (l *limiter) acquire(ctx context.Context, operation string) (func(), error) {
+	// The budget of the class is taken first, so that an operation waiting on
+	// it does not hold a request in flight of the overall budget.
+	var budgets []*budget
+	if b := l.classes[operationClasses[operation]]; b != nil {
+		budgets = append(budgets, b)
+	}
+	if l.all != nil {
+		budgets = append(budgets, l.all)
+	}
+
+	refund, err := l.acquireRate(ctx, operation, budgets)
+	if err != nil {
+		return nil, err
+	}
+
+	// A read stream may never end, so it does not count as in flight.
+	if operation == "ReadStream" {
+		return func() {}, nil
+	}
+
+	var held []*budget
+	release := func() {
+		for _, b := range held {
+			<-b.slots
+		}
+	}
+	for _, b := range budgets {
+		if b.slots == nil {
+			continue
+		}
+		if l.failFast {
+			select {
+			case b.slots <- struct{}{}:
+			default:
+				// The rejected operation does not use up the rate budget.
+				release()
+				refund()
+				return nil, &LimitError{Operation: operation, Budget: b.name, InFlight: true}
+			}
+		} else {
+			select {
+			case b.slots <- struct{}{}:
+			case <-ctx.Done():
+				release()
+				refund()
+				return nil, ctx.Err()
+			}
+		}
+		held = append(held, b)
+	}
+	return release, nil
+}
+
+// acquireRate takes a token from the rate limit of each budget, waiting for
+// the tokens unless failing fast. It returns a function which gives the
+// tokens back, for an operation which is not made after all.
+func (l *limiter) acquireRate(ctx context.Context, operation string, budgets []*budget) (func(), error) {
+	var taken []*tokenBucket
+	refund := func() {
+		for _, tb := range taken {
+			tb.refund()
+		}
+	}
+
+	var wait time.Duration
+	for _, b := range budgets {
+		if b.bucket == nil {
+			continue
+		}
+		if l.failFast {
+			retryAfter, ok := b.bucket.take(time.Now())
+			if !ok {
+				refund()
+				return nil, &LimitError{Operation: operation, Budget: b.name, RetryAfter: retryAfter}
+			}
+		} else if d := b.bucket.reserve(time.Now()); d > wait {
+			wait = d
+		}
+		taken = append(taken, b.bucket)
+	}
+
+	if wait <= 0 {
+		return refund, nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return refund, nil
+	case <-ctx.Done():
+		refund()
+		return nil, ctx.Err()
+	}
+}
+
+// budget is a request budget.
+type budget struct {
+	// name is the name of the budget.
+	name string
+
+	// bucket is the rate limit, if any.
+	bucket *tokenBucket
+
+	// slots holds a value for each request in flight, if they are capped.
+	slots chan struct{}
+}
+
+// newBudget creates a budget for the options. It returns nil if the options
+// set no limit.
+func newBudget(name string, opts LimitBudget) *budget {
+	if opts.Rate <= 0 && opts.MaxInFlight <= 0 {
+		return nil
+	}
+
+	b := &budget{name: name}
+	if opts.Rate > 0 {
+		b.bucket = newTokenBucket(opts.Rate, opts.Burst, time.Now())
+	}
+	if opts.MaxInFlight > 0 {
+		b.slots = make(chan struct{}, opts.MaxInFlight)
+	}
+	return b
+}
+
+// tokenBucket is a token bucket rate limit. Tokens are added at the rate, up
+// to the burst, and each request takes one.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full token bucket. A burst of zero means the rate,
+// rounded up.
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	b := float64(burst)
+	if burst <= 0 {
+		b = math.Ceil(rate)
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  b,
+		tokens: b,
+		last:   now,
+	}
+}
+
+// advance adds the tokens accumulated since the last update.
+func (b *tokenBucket) advance(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// take takes a token if one is available. Otherwise, it returns the wait time
+// until one is.
+func (b *tokenBucket) take(now time.Time) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return b.duration(1 - b.tokens), false
+}
+
+// reserve takes a token, going into debt if none is available, and returns
+// the wait time until the token is available.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return b.duration(-b.tokens)
+}
+
+// refund gives back a token which was taken or reserved, but not used.
+func (b *tokenBucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// duration returns the time it takes to accumulate the given tokens.
+func (b *tokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / b.rate * float64(time.Second)))
+}
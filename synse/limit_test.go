@@ -0,0 +1,270 @@
+package synse
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/internal/test"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(10, 2, now)
+
+	_, ok := b.take(now)
+	assert.True(t, ok)
+	_, ok = b.take(now)
+	assert.True(t, ok)
+	wait, ok := b.take(now)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, wait)
+
+	// Tokens accumulate at the rate.
+	now = now.Add(50 * time.Millisecond)
+	wait, ok = b.take(now)
+	assert.False(t, ok)
+	assert.Equal(t, 50*time.Millisecond, wait)
+
+	// Reserving goes into debt.
+	assert.Equal(t, 50*time.Millisecond, b.reserve(now))
+	assert.Equal(t, 150*time.Millisecond, b.reserve(now))
+	b.refund()
+	b.refund()
+
+	// Tokens do not accumulate past the burst.
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		_, ok = b.take(now)
+		assert.True(t, ok)
+	}
+	_, ok = b.take(now)
+	assert.False(t, ok)
+
+	// The burst defaults to the rate.
+	assert.Equal(t, 3.0, newTokenBucket(2.5, 0, now).burst)
+}
+
+func TestNewLimiter(t *testing.T) {
+	assert.Nil(t, newLimiter(LimitOptions{}))
+	assert.Nil(t, newLimiter(LimitOptions{FailFast: true}))
+
+	l := newLimiter(LimitOptions{Reads: LimitBudget{MaxInFlight: 2}})
+	assert.Nil(t, l.all)
+	assert.Len(t, l.classes, 1)
+	assert.Equal(t, 2, cap(l.classes[budgetReads].slots))
+	assert.Nil(t, l.classes[budgetReads].bucket)
+}
+
+func TestLimit_RateFailFast(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{
+		Devices: []synsetest.Device{{ID: "led-1", Type: "led"}},
+	})
+	defer server.Close()
+
+	for _, newClient := range []func(*Options) (Client, error){NewHTTPClientV3, NewWebSocketClientV3} {
+		client, err := newClient(&Options{
+			Address: server.Address,
+			Limit: LimitOptions{
+				Reads:    LimitBudget{Rate: 1, Burst: 2},
+				FailFast: true,
+			},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, client.Open())
+
+		for i := 0; i < 2; i++ {
+			_, err = client.Read(scheme.ReadOptions{})
+			assert.NoError(t, err)
+		}
+		_, err = client.ReadDevice("led-1")
+		assert.True(t, IsLimited(err))
+
+		var limitErr *LimitError
+		assert.True(t, errors.As(err, &limitErr))
+		assert.Equal(t, "ReadDevice", limitErr.Operation)
+		assert.Equal(t, "reads", limitErr.Budget)
+		assert.False(t, limitErr.InFlight)
+		assert.True(t, limitErr.RetryAfter > 0 && limitErr.RetryAfter <= time.Second, limitErr.RetryAfter)
+
+		// The other classes have their own budget.
+		_, err = client.Scan(scheme.ScanOptions{})
+		assert.NoError(t, err)
+
+		assert.NoError(t, client.Close())
+	}
+}
+
+func TestLimit_RateWait(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+	server.ServeUnversioned(t, "/test", 200, `{"status":"ok"}`)
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+		Limit: LimitOptions{
+			LimitBudget: LimitBudget{Rate: 20, Burst: 1},
+		},
+	})
+	assert.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := client.Status()
+		assert.NoError(t, err)
+	}
+	assert.True(t, time.Since(start) >= 100*time.Millisecond, time.Since(start))
+
+	// A context which is done before the budget is available ends the wait.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.StatusContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestLimit_InFlight(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	server.HandleVersioned("/write/led-1", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fprintf(t, w, `[]`)
+	})
+	server.ServeUnversioned(t, "/test", 200, `{"status":"ok"}`)
+
+	for _, failFast := range []bool{false, true} {
+		client, err := NewHTTPClientV3(&Options{
+			Address: server.URL,
+			Limit: LimitOptions{
+				Writes:   LimitBudget{MaxInFlight: 1},
+				FailFast: failFast,
+			},
+		})
+		assert.NoError(t, err)
+
+		done := make(chan error)
+		go func() {
+			_, err := client.WriteAsync("led-1", []scheme.WriteData{{Action: "state", Data: "on"}})
+			done <- err
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err = client.WriteAsyncContext(ctx, "led-1", []scheme.WriteData{{Action: "state", Data: "off"}})
+		cancel()
+		if failFast {
+			assert.EqualError(t, err, "synse: WriteAsync exceeds the cap on writes requests in flight")
+			assert.True(t, IsLimited(err))
+		} else {
+			assert.Equal(t, context.DeadlineExceeded, err)
+		}
+
+		// Other classes are not capped.
+		_, err = client.Status()
+		assert.NoError(t, err)
+
+		release <- struct{}{}
+		assert.NoError(t, <-done)
+
+		// Once the write is done, its slot is available again.
+		go func() {
+			<-started
+			release <- struct{}{}
+		}()
+		_, err = client.WriteAsync("led-1", []scheme.WriteData{{Action: "state", Data: "off"}})
+		assert.NoError(t, err)
+	}
+}
+
+func TestLimit_InFlightRefundsRate(t *testing.T) {
+	l := newLimiter(LimitOptions{
+		Writes:   LimitBudget{Rate: 1, Burst: 2, MaxInFlight: 1},
+		FailFast: true,
+	})
+
+	release, err := l.acquire(context.Background(), "WriteAsync")
+	assert.NoError(t, err)
+
+	// The operations rejected by the cap on requests in flight do not take
+	// the token left.
+	for i := 0; i < 3; i++ {
+		_, err = l.acquire(context.Background(), "WriteAsync")
+		assert.True(t, IsLimited(err))
+		var limitErr *LimitError
+		assert.True(t, errors.As(err, &limitErr))
+		assert.True(t, limitErr.InFlight)
+	}
+
+	release()
+	_, err = l.acquire(context.Background(), "WriteAsync")
+	assert.NoError(t, err)
+}
+
+func TestLimit_ReadStreamNotInFlight(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{
+		Devices: []synsetest.Device{{
+			ID:       "temp-1",
+			Type:     "temperature",
+			Readings: []synsetest.Reading{{Type: "temperature", Value: 20}},
+		}},
+	})
+	defer server.Close()
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.Address,
+		Limit: LimitOptions{
+			Reads:    LimitBudget{MaxInFlight: 1},
+			FailFast: true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Open())
+	defer client.Close() // nolint: errcheck
+
+	out := make(chan *scheme.Read, 10)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- client.ReadStream(scheme.ReadStreamOptions{Ids: []string{"temp-1"}}, out, stop)
+	}()
+	<-out
+
+	_, err = client.Read(scheme.ReadOptions{})
+	assert.NoError(t, err)
+
+	close(stop)
+	assert.NoError(t, <-done)
+}
+
+func TestLimit_ReadCacheClosesOut(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{})
+	defer server.Close()
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.Address,
+		Limit: LimitOptions{
+			Reads:    LimitBudget{Rate: 1, Burst: 1},
+			FailFast: true,
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = client.Read(scheme.ReadOptions{})
+	assert.NoError(t, err)
+
+	// The operation does not get to the client, which would close the out
+	// channel otherwise.
+	out := make(chan *scheme.Read)
+	assert.True(t, IsLimited(client.ReadCache(scheme.ReadCacheOptions{}, out)))
+	_, ok := <-out
+	assert.False(t, ok)
+}
@@ -0,0 +1,373 @@
+package synse
+
+// load.go loads config options from a file and environment variables.
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creasty/defaults"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables read by LoadOptions.
+const EnvPrefix = "SYNSE"
+
+// durationType is the type of the duration options.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// negativeOptions holds the options for which a negative value is valid.
+var negativeOptions = map[string]bool{
+	"websocket.ping_interval":  true,
+	"failover.health_interval": true,
+}
+
+// nonZeroOptions holds the options for which a zero value is not valid, e.g.
+// the interval of a ticker.
+var nonZeroOptions = map[string]bool{
+	"http.poll_interval":         true,
+	"failover.health_interval":   true,
+	"breaker.half_open_requests": true,
+}
+
+// LoadOptions loads the config options from a YAML or JSON file, with the
+// same layout as the yaml tags of Options, e.g.
+//
+//	address: localhost:5000
+//	http:
+//	  timeout: 5s
+//	  retry:
+//	    count: 5
+//
+// Environment variables with the EnvPrefix prefix override the values of the
+// file (see OptionsFromEnv). Durations are given as strings, e.g. `500ms`.
+// Options which are set by neither get their default value, while those set
+// to a zero value, e.g. `count: 0`, keep it.
+func LoadOptions(path string) (*Options, error) {
+	return LoadOptionsFrom(path, EnvPrefix, os.LookupEnv)
+}
+
+// OptionsFromEnv loads the config options from environment variables. The
+// variable of an option is named after the path of its yaml tags, in upper
+// case and joined by underscores, following the prefix, e.g. with the `SYNSE`
+// prefix:
+//
+//	SYNSE_ADDRESS=localhost:5000
+//	SYNSE_HTTP_TIMEOUT=5s
+//	SYNSE_HTTP_RETRY_COUNT=5
+//	SYNSE_WEBSOCKET_RECONNECT_ENABLED=true
+//
+// A list, e.g. SYNSE_ADDRESSES, is comma separated. Options which are not set
+// get their default value.
+func OptionsFromEnv(prefix string) (*Options, error) {
+	return LoadOptionsFrom("", prefix, os.LookupEnv)
+}
+
+// LoadOptionsFrom loads the config options like LoadOptions, from the file at
+// path unless it is empty, then from the environment variables with the
+// prefix, which are looked up by lookup instead of os.LookupEnv. This lets a
+// command-line tool set options from its flags under the same names, e.g.
+// SYNSE_ADDRESS for an `-address` flag.
+func LoadOptionsFrom(path, prefix string, lookup func(string) (string, bool)) (*Options, error) {
+	opts, err := defaultOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read options file")
+		}
+		if err := decodeOptions(data, opts); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse options file %s", path)
+		}
+	}
+	if err := envOptions(opts, prefix, lookup); err != nil {
+		return nil, err
+	}
+	if err := validateOptions(opts); err != nil {
+		return nil, errors.Wrap(err, "invalid options")
+	}
+	return opts, nil
+}
+
+// optionField is an option which is set from a single value.
+type optionField struct {
+	// path is the path of the yaml tags of the option, e.g. `http.timeout`.
+	path string
+
+	// value is the field of the option.
+	value reflect.Value
+}
+
+// optionFields returns the options of the struct, in order.
+func optionFields(v reflect.Value, prefix string) []optionField {
+	var out []optionField
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		tag := f.Tag.Get("yaml")
+		if tag == "-" || f.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		path := prefix
+		if !strings.HasSuffix(tag, ",inline") {
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			path = joinPath(prefix, name)
+		}
+
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			out = append(out, optionFields(v.Field(i), path)...)
+			continue
+		}
+		out = append(out, optionField{path: path, value: v.Field(i)})
+	}
+	return out
+}
+
+// joinPath joins the path of an option with the name of one of its fields.
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// decodeOptions sets the options from a YAML (or JSON) document.
+func decodeOptions(data []byte, opts *Options) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	// An empty document sets no options.
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	fields := map[string]reflect.Value{}
+	for _, f := range optionFields(reflect.ValueOf(opts).Elem(), "") {
+		fields[f.path] = f.value
+	}
+	return decodeNode(doc.Content[0], "", fields)
+}
+
+// decodeNode sets the options from a node of a YAML document at the given
+// path.
+func decodeNode(node *yaml.Node, path string, fields map[string]reflect.Value) error {
+	if field, ok := fields[path]; ok {
+		return setNode(field, path, node)
+	}
+
+	if node.Kind != yaml.MappingNode {
+		if path == "" {
+			return errors.New("expected a mapping of options")
+		}
+		if !hasOptions(fields, path) {
+			return errors.Errorf("%s: unknown option", path)
+		}
+		return errors.Errorf("%s: expected a mapping of options", path)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := joinPath(path, node.Content[i].Value)
+		if _, ok := fields[key]; !ok && !hasOptions(fields, key) {
+			return errors.Errorf("%s: unknown option", key)
+		}
+		if err := decodeNode(node.Content[i+1], key, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasOptions reports whether there are options under the given path.
+func hasOptions(fields map[string]reflect.Value, path string) bool {
+	for p := range fields {
+		if strings.HasPrefix(p, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// setNode sets an option from a node of a YAML document.
+func setNode(field reflect.Value, path string, node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+
+	if field.Kind() == reflect.Slice {
+		if node.Kind != yaml.SequenceNode {
+			return errors.Errorf("%s: expected a list", path)
+		}
+		values := make([]string, len(node.Content))
+		for i, n := range node.Content {
+			if n.Kind != yaml.ScalarNode {
+				return errors.Errorf("%s: expected a list of values", path)
+			}
+			values[i] = n.Value
+		}
+		field.Set(reflect.ValueOf(values))
+		return nil
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return errors.Errorf("%s: expected a value", path)
+	}
+	return setValue(field, path, node.Value)
+}
+
+// envOptions sets the options from the environment variables looked up by
+// lookup.
+func envOptions(opts *Options, prefix string, lookup func(string) (string, bool)) error {
+	prefix = strings.TrimSuffix(prefix, "_")
+	for _, f := range optionFields(reflect.ValueOf(opts).Elem(), "") {
+		name := envName(prefix, f.path)
+		v, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		if f.value.Kind() == reflect.Slice {
+			var values []string
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					values = append(values, s)
+				}
+			}
+			f.value.Set(reflect.ValueOf(values))
+			continue
+		}
+		if err := setValue(f.value, f.path, v); err != nil {
+			return errors.Wrapf(err, "invalid %s", name)
+		}
+	}
+	return nil
+}
+
+// envName returns the name of the environment variable of an option.
+func envName(prefix, path string) string {
+	name := strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+// setValue sets an option from its string value.
+func setValue(field reflect.Value, path, s string) error {
+	s = strings.TrimSpace(s)
+
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.Errorf("%s: invalid duration %q", path, s)
+		}
+		field.SetInt(int64(d))
+
+	case field.Kind() == reflect.String:
+		field.SetString(s)
+
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.Errorf("%s: invalid boolean %q", path, s)
+		}
+		field.SetBool(b)
+
+	case field.Kind() >= reflect.Int && field.Kind() <= reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return errors.Errorf("%s: invalid integer %q", path, s)
+		}
+		field.SetInt(n)
+
+	case field.Kind() >= reflect.Uint && field.Kind() <= reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return errors.Errorf("%s: invalid non-negative integer %q", path, s)
+		}
+		field.SetUint(n)
+
+	case field.Kind() == reflect.Float32 || field.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return errors.Errorf("%s: invalid number %q", path, s)
+		}
+		field.SetFloat(f)
+
+	default:
+		return errors.Errorf("%s: unsupported option type %v", path, field.Type())
+	}
+	return nil
+}
+
+// defaultOptions returns the options with their default values, for the
+// loaded values to be set on.
+func defaultOptions() (*Options, error) {
+	opts := &Options{}
+	if err := defaults.Set(opts); err != nil {
+		return nil, errors.Wrap(err, "failed to set default options")
+	}
+	opts.defaulted = true
+	return opts, nil
+}
+
+// validateOptions validates the loaded options.
+func validateOptions(opts *Options) error {
+	if opts.Address == "" && len(opts.Addresses) == 0 {
+		return errors.New("address: no address is specified")
+	}
+	if opts.Address != "" {
+		if _, err := parseAddress(opts.Address); err != nil {
+			return errors.Wrap(err, "address")
+		}
+	}
+	for _, a := range opts.Addresses {
+		if _, err := parseAddress(a); err != nil {
+			return errors.Wrap(err, "addresses")
+		}
+	}
+	switch opts.Transport {
+	case "", TransportHTTP, TransportWebSocket, "ws":
+	default:
+		return errors.Errorf("transport: unknown transport %q", opts.Transport)
+	}
+	if opts.Proxy.URL != "" {
+		if _, err := parseProxyURL(opts.Proxy.URL); err != nil {
+			return errors.Wrap(err, "proxy.url")
+		}
+	}
+	if (opts.TLS.CertFile == "") != (opts.TLS.KeyFile == "") {
+		return errors.New("tls: cert_file and key_file must be set together")
+	}
+
+	for _, f := range optionFields(reflect.ValueOf(opts).Elem(), "") {
+		if nonZeroOptions[f.path] && f.value.IsZero() {
+			return errors.Errorf("%s: can not be zero", f.path)
+		}
+		if negativeOptions[f.path] {
+			continue
+		}
+		negative := false
+		switch f.value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			negative = f.value.Int() < 0
+		case reflect.Float32, reflect.Float64:
+			negative = f.value.Float() < 0
+		}
+		if negative {
+			return errors.Errorf("%s: can not be negative", f.path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package synse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeOptionsFile writes an options file in a temporary directory and
+// returns its path.
+func writeOptionsFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0600))
+	return path
+}
+
+func TestLoadOptions(t *testing.T) {
+	path := writeOptionsFile(t, "options.yaml", `
+address: localhost:5000
+addresses:
+  - localhost:5001
+http:
+  timeout: 5s
+  retry:
+    count: 5
+    wait_time: 200ms
+websocket:
+  ping_interval: -1s
+  reconnect:
+    enabled: true
+tls:
+  enabled: true
+  skip_verify: true
+limit:
+  rate: 20
+  reads:
+    max_in_flight: 4
+breaker:
+  enabled: true
+`)
+	t.Setenv("SYNSE_HTTP_TIMEOUT", "10s")
+	t.Setenv("SYNSE_WEBSOCKET_RECONNECT_COUNT", "3")
+
+	opts, err := LoadOptions(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5000", opts.Address)
+	assert.Equal(t, []string{"localhost:5001"}, opts.Addresses)
+
+	// Environment variables override the file.
+	assert.Equal(t, 10*time.Second, opts.HTTP.Timeout)
+	assert.Equal(t, uint(5), opts.HTTP.Retry.Count)
+	assert.Equal(t, 200*time.Millisecond, opts.HTTP.Retry.WaitTime)
+	assert.Equal(t, -time.Second, opts.WebSocket.PingInterval)
+	assert.True(t, opts.WebSocket.Reconnect.Enabled)
+	assert.Equal(t, uint(3), opts.WebSocket.Reconnect.Count)
+	assert.True(t, opts.TLS.Enabled)
+	assert.True(t, opts.TLS.SkipVerify)
+	assert.Equal(t, 20.0, opts.Limit.Rate)
+	assert.Equal(t, 4, opts.Limit.Reads.MaxInFlight)
+	assert.True(t, opts.Breaker.Enabled)
+
+	// Other options get their default value.
+	assert.Equal(t, 2*time.Second, opts.HTTP.Retry.MaxWaitTime)
+	assert.Equal(t, 30*time.Second, opts.WebSocket.RequestTimeout)
+	assert.Equal(t, uint(5), opts.Breaker.FailureThreshold)
+}
+
+func TestLoadOptions_JSON(t *testing.T) {
+	path := writeOptionsFile(t, "options.json", `{
+  "address": "localhost:5000",
+  "http": {"timeout": "3s", "redirects": 2},
+  "tls": {"cert_file": "cert.pem", "key_file": "key.pem"}
+}`)
+
+	opts, err := LoadOptions(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5000", opts.Address)
+	assert.Equal(t, 3*time.Second, opts.HTTP.Timeout)
+	assert.Equal(t, 2, opts.HTTP.Redirects)
+	assert.Equal(t, "cert.pem", opts.TLS.CertFile)
+	assert.Equal(t, "key.pem", opts.TLS.KeyFile)
+}
+
+func TestLoadOptions_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		err  string
+	}{{
+		data: "address: [",
+		err:  "yaml: line 1: did not find expected node content",
+	}, {
+		data: "- localhost:5000",
+		err:  "expected a mapping of options",
+	}, {
+		data: "address: localhost:5000\nhttp:\n  timeout: 5",
+		err:  `http.timeout: invalid duration "5"`,
+	}, {
+		data: "address: localhost:5000\nhttp:\n  retry:\n    count: -1",
+		err:  `http.retry.count: invalid non-negative integer "-1"`,
+	}, {
+		data: "address: localhost:5000\ntls:\n  enabled: maybe",
+		err:  `tls.enabled: invalid boolean "maybe"`,
+	}, {
+		data: "address: localhost:5000\nhttp:\n  timeot: 5s",
+		err:  "http.timeot: unknown option",
+	}, {
+		data: "address: localhost:5000\nhttp: 5s",
+		err:  "http: expected a mapping of options",
+	}, {
+		data: "address:\n  host: localhost",
+		err:  "address: expected a value",
+	}, {
+		data: "addresses: localhost:5000",
+		err:  "addresses: expected a list",
+	}} {
+		path := writeOptionsFile(t, "options.yaml", tc.data)
+		_, err := LoadOptions(path)
+		assert.EqualError(t, err, "failed to parse options file "+path+": "+tc.err)
+	}
+}
+
+func TestLoadOptions_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		err  string
+	}{{
+		data: "http:\n  timeout: 5s",
+		err:  "address: no address is specified",
+	}, {
+		data: "address: localhost:5000\nhttp:\n  timeout: -5s",
+		err:  "http.timeout: can not be negative",
+	}, {
+		data: "address: localhost:5000\nlimit:\n  writes:\n    rate: -1",
+		err:  "limit.writes.rate: can not be negative",
+	}, {
+		data: "address: localhost:5000\nhttp:\n  poll_interval: 0s",
+		err:  "http.poll_interval: can not be zero",
+	}, {
+		data: "address: localhost:5000\nhttp:\n  poll_interval: -1s",
+		err:  "http.poll_interval: can not be negative",
+	}, {
+		data: "address: localhost:5000\nfailover:\n  health_interval: 0s",
+		err:  "failover.health_interval: can not be zero",
+	}, {
+		data: "address: localhost:5000\nbreaker:\n  half_open_requests: 0",
+		err:  "breaker.half_open_requests: can not be zero",
+	}, {
+		data: "address: localhost:5000\ntls:\n  cert_file: cert.pem",
+		err:  "tls: cert_file and key_file must be set together",
+	}, {
+		data: "addresses: [localhost:5000, 'grpc://localhost:5001']",
+		err:  `addresses: invalid address "grpc://localhost:5001": unsupported scheme "grpc"`,
+	}, {
+		data: "address: localhost:5000\nproxy:\n  url: https://proxy:3128",
+		err:  `proxy.url: invalid proxy URL "https://proxy:3128": unsupported scheme "https"`,
+	}, {
+		data: "address: localhost:5000\ntransport: grpc",
+		err:  `transport: unknown transport "grpc"`,
+	}} {
+		_, err := LoadOptions(writeOptionsFile(t, "options.yaml", tc.data))
+		assert.EqualError(t, err, "invalid options: "+tc.err)
+	}
+
+	_, err := LoadOptions(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv("APP_SYNSE_ADDRESS", "localhost:5000")
+	t.Setenv("APP_SYNSE_ADDRESSES", "localhost:5001, localhost:5002,")
+	t.Setenv("APP_SYNSE_HTTP_RETRY_MAX_WAIT_TIME", "1s")
+	t.Setenv("APP_SYNSE_LIMIT_BURST", "10")
+	t.Setenv("APP_SYNSE_TLS_SKIP_VERIFY", "true")
+
+	opts, err := OptionsFromEnv("APP_SYNSE_")
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5000", opts.Address)
+	assert.Equal(t, []string{"localhost:5001", "localhost:5002"}, opts.Addresses)
+	assert.Equal(t, time.Second, opts.HTTP.Retry.MaxWaitTime)
+	assert.Equal(t, 10, opts.Limit.Burst)
+	assert.True(t, opts.TLS.SkipVerify)
+	assert.Equal(t, 2*time.Second, opts.HTTP.Timeout)
+
+	t.Setenv("APP_SYNSE_WEBSOCKET_WRITE_TIMEOUT", "soon")
+	_, err = OptionsFromEnv("APP_SYNSE")
+	assert.EqualError(t, err, `invalid APP_SYNSE_WEBSOCKET_WRITE_TIMEOUT: websocket.write_timeout: invalid duration "soon"`)
+}
+
+func TestOptionFields(t *testing.T) {
+	var paths []string
+	for _, f := range optionFields(reflect.ValueOf(&Options{}).Elem(), "") {
+		paths = append(paths, f.path)
+	}
+	assert.Contains(t, paths, "http.retry.count")
+	assert.Contains(t, paths, "websocket.reconnect.max_wait_time")
+	assert.Contains(t, paths, "limit.rate")
+	assert.Contains(t, paths, "limit.scans.max_in_flight")
+	assert.NotContains(t, paths, "middleware")
+	assert.NotContains(t, paths, "breaker.on_state_change")
+}
+
+func TestLoadOptions_ZeroValues(t *testing.T) {
+	path := writeOptionsFile(t, "options.yaml", `
+address: localhost:5000
+http:
+  retry:
+    count: 0
+`)
+	// Zero values are kept rather than replaced by the defaults.
+	opts, err := LoadOptions(path)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), opts.HTTP.Retry.Count)
+	assert.Equal(t, 2*time.Second, opts.HTTP.Timeout)
+
+	// The clients keep them too.
+	client, err := NewHTTPClientV3(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, client.(*httpClient).client.RetryCount)
+
+	t.Setenv("SYNSE_ADDRESS", "localhost:5000")
+	t.Setenv("SYNSE_HTTP_RETRY_COUNT", "0")
+	opts, err = OptionsFromEnv(EnvPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), opts.HTTP.Retry.Count)
+}
+
+func TestLoadOptionsFrom(t *testing.T) {
+	path := writeOptionsFile(t, "options.yaml", "address: localhost:5000\ntls:\n  enabled: true\n")
+	env := map[string]string{
+		"TOOL_ADDRESS":         "localhost:5001",
+		"TOOL_TLS_SKIP_VERIFY": "true",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	opts, err := LoadOptionsFrom(path, "TOOL", lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5001", opts.Address)
+	assert.True(t, opts.TLS.Enabled)
+	assert.True(t, opts.TLS.SkipVerify)
+
+	// The file is optional.
+	opts, err = LoadOptionsFrom("", "TOOL", lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5001", opts.Address)
+	assert.False(t, opts.TLS.Enabled)
+}
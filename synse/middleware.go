@@ -0,0 +1,404 @@
+package synse
+
+// middleware.go provides the middleware chain which wraps the operations of a
+// client.
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// Operation describes a logical operation of a client, i.e. a call to one of
+// the Client methods.
+type Operation struct {
+	// Name is the name of the Client method, without the Context suffix,
+	// e.g. `Scan`.
+	Name string
+
+	// Transport is the transport of the client, either `http` or
+	// `websocket`.
+	Transport string
+
+	// Request holds the parameters of the operation: nil for an operation
+	// without parameters, the ID for one on a single plugin, device or
+	// transaction (e.g. Info), the options for one with options (e.g.
+	// scheme.ScanOptions for Scan), or a WriteRequest for a write. A
+	// middleware may replace it with a value of the same type.
+	Request interface{}
+
+	// Header holds the headers added to the HTTP requests of the operation.
+	// It is not used by the WebSocket client, whose requests are messages on
+	// a connection.
+	Header http.Header
+}
+
+// WriteRequest is the request of the WriteAsync and WriteSync operations.
+type WriteRequest struct {
+	// Device is the ID of the device to write to.
+	Device string
+
+	// Data holds the data to write.
+	Data []scheme.WriteData
+}
+
+// Handler performs an operation, returning its response. The response has
+// the type returned by the Client method, e.g. []*scheme.Scan for Scan, and
+// is nil for the streamed operations, ReadCache and ReadStream.
+type Handler func(ctx context.Context, op *Operation) (interface{}, error)
+
+// Middleware wraps the handler of the operations of a client. A middleware
+// can observe the operation and its outcome, or change the request or the
+// response, e.g.
+//
+//	func(next synse.Handler) synse.Handler {
+//		return func(ctx context.Context, op *synse.Operation) (interface{}, error) {
+//			op.Header.Set("X-Request-Id", newRequestID())
+//			return next(ctx, op)
+//		}
+//	}
+//
+// A middleware which replaces the response must keep its type.
+type Middleware func(next Handler) Handler
+
+// Observe returns a middleware which calls fn after each operation, with the
+// response, the error and the duration of the operation. It is meant for
+// logging, metrics and tracing.
+func Observe(fn func(ctx context.Context, op *Operation, resp interface{}, err error, d time.Duration)) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) (interface{}, error) {
+			start := time.Now()
+			resp, err := next(ctx, op)
+			fn(ctx, op, resp, err, time.Since(start))
+			return resp, err
+		}
+	}
+}
+
+// operationKey is the context key of the operation being performed.
+type operationKey struct{}
+
+// operationHeader returns the headers of the operation in the context.
+func operationHeader(ctx context.Context) http.Header {
+	if op, ok := ctx.Value(operationKey{}).(*Operation); ok {
+		return op.Header
+	}
+	return nil
+}
+
+// withMiddleware wraps the client with the middleware chain of the options,
+// if there is any. The circuit breaker and the limits of the options are
+// enforced by the innermost middleware, so that the other middleware observe
+// their errors and the time spent waiting for them. An open breaker fails an
+// operation without waiting for the limits.
+func withMiddleware(client Client, transport string, opts *Options) Client {
+	middleware := append([]Middleware(nil), opts.Middleware...)
+	b := newBreaker(opts.Breaker)
+	if b != nil {
+		middleware = append(middleware, b.middleware)
+	}
+	if l := newLimiter(opts.Limit); l != nil {
+		middleware = append(middleware, l.middleware)
+	}
+
+	if len(middleware) == 0 {
+		return client
+	}
+	return &middlewareClient{
+		Client:     client,
+		transport:  transport,
+		middleware: middleware,
+		breaker:    b,
+	}
+}
+
+// middlewareClient is a client whose operations go through a middleware
+// chain before reaching the wrapped client.
+type middlewareClient struct {
+	Client
+
+	// transport is the transport of the wrapped client.
+	transport string
+
+	// middleware holds the middleware chain, the first one being the
+	// outermost.
+	middleware []Middleware
+
+	// breaker is the circuit breaker of the client, if any.
+	breaker *breaker
+}
+
+// invoke runs an operation through the middleware chain, with fn calling the
+// wrapped client at the end of the chain.
+func (c *middlewareClient) invoke(ctx context.Context, name string, req interface{}, fn func(ctx context.Context, req interface{}) (interface{}, error)) (interface{}, error) {
+	h := func(ctx context.Context, op *Operation) (interface{}, error) {
+		if reflect.TypeOf(op.Request) != reflect.TypeOf(req) {
+			return nil, errors.Errorf("middleware changed the %s request from %T to %T", op.Name, req, op.Request)
+		}
+		resp, err := fn(context.WithValue(ctx, operationKey{}, op), op.Request)
+		if err != nil {
+			// Do not pass a typed nil response on to the middleware.
+			return nil, err
+		}
+		return resp, nil
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+
+	return h(ctx, &Operation{
+		Name:      name,
+		Transport: c.transport,
+		Request:   req,
+		Header:    http.Header{},
+	})
+}
+
+// Status implements the Client interface.
+func (c *middlewareClient) Status() (*scheme.Status, error) {
+	return c.StatusContext(context.Background())
+}
+
+// StatusContext implements the Client interface.
+func (c *middlewareClient) StatusContext(ctx context.Context) (*scheme.Status, error) {
+	resp, err := c.invoke(ctx, "Status", nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return c.Client.StatusContext(ctx)
+	})
+	out, _ := resp.(*scheme.Status)
+	return out, err
+}
+
+// Version implements the Client interface.
+func (c *middlewareClient) Version() (*scheme.Version, error) {
+	return c.VersionContext(context.Background())
+}
+
+// VersionContext implements the Client interface.
+func (c *middlewareClient) VersionContext(ctx context.Context) (*scheme.Version, error) {
+	resp, err := c.invoke(ctx, "Version", nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return c.Client.VersionContext(ctx)
+	})
+	out, _ := resp.(*scheme.Version)
+	return out, err
+}
+
+// Config implements the Client interface.
+func (c *middlewareClient) Config() (*scheme.Config, error) {
+	return c.ConfigContext(context.Background())
+}
+
+// ConfigContext implements the Client interface.
+func (c *middlewareClient) ConfigContext(ctx context.Context) (*scheme.Config, error) {
+	resp, err := c.invoke(ctx, "Config", nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return c.Client.ConfigContext(ctx)
+	})
+	out, _ := resp.(*scheme.Config)
+	return out, err
+}
+
+// Plugins implements the Client interface.
+func (c *middlewareClient) Plugins() ([]*scheme.PluginMeta, error) {
+	return c.PluginsContext(context.Background())
+}
+
+// PluginsContext implements the Client interface.
+func (c *middlewareClient) PluginsContext(ctx context.Context) ([]*scheme.PluginMeta, error) {
+	resp, err := c.invoke(ctx, "Plugins", nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return c.Client.PluginsContext(ctx)
+	})
+	out, _ := resp.([]*scheme.PluginMeta)
+	return out, err
+}
+
+// Plugin implements the Client interface.
+func (c *middlewareClient) Plugin(id string) (*scheme.Plugin, error) {
+	return c.PluginContext(context.Background(), id)
+}
+
+// PluginContext implements the Client interface.
+func (c *middlewareClient) PluginContext(ctx context.Context, id string) (*scheme.Plugin, error) {
+	resp, err := c.invoke(ctx, "Plugin", id, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.Client.PluginContext(ctx, req.(string))
+	})
+	out, _ := resp.(*scheme.Plugin)
+	return out, err
+}
+
+// PluginHealth implements the Client interface.
+func (c *middlewareClient) PluginHealth() (*scheme.PluginHealth, error) {
+	return c.PluginHealthContext(context.Background())
+}
+
+// PluginHealthContext implements the Client interface.
+func (c *middlewareClient) PluginHealthContext(ctx context.Context) (*scheme.PluginHealth, error) {
+	resp, err := c.invoke(ctx, "PluginHealth", nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return c.Client.PluginHealthContext(ctx)
+	})
+	out, _ := resp.(*scheme.PluginHealth)
+	return out, err
+}
+
+// Scan implements the Client interface.
+func (c *middlewareClient) Scan(opts scheme.ScanOptions) ([]*scheme.Scan, error) {
+	return c.ScanContext(context.Background(), opts)
+}
+
+// ScanContext implements the Client interface.
+func (c *middlewareClient) ScanContext(ctx context.Context, opts scheme.ScanOptions) ([]*scheme.Scan, error) {
+	resp, err := c.invoke(ctx, "Scan", opts, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.Client.ScanContext(ctx, req.(scheme.ScanOptions))
+	})
+	out, _ := resp.([]*scheme.Scan)
+	return out, err
+}
+
+// Tags implements the Client interface.
+func (c *middlewareClient) Tags(opts scheme.TagsOptions) ([]string, error) {
+	return c.TagsContext(context.Background(), opts)
+}
+
+// TagsContext implements the Client interface.
+func (c *middlewareClient) TagsContext(ctx context.Context, opts scheme.TagsOptions) ([]string, error) {
+	resp, err := c.invoke(ctx, "Tags", opts, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.Client.TagsContext(ctx, req.(scheme.TagsOptions))
+	})
+	out, _ := resp.([]string)
+	return out, err
+}
+
+// Info implements the Client interface.
+func (c *middlewareClient) Info(id string) (*scheme.Info, error) {
+	return c.InfoContext(context.Background(), id)
+}
+
+// InfoContext implements the Client interface.
+func (c *middlewareClient) InfoContext(ctx context.Context, id string) (*scheme.Info, error) {
+	resp, err := c.invoke(ctx, "Info", id, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.Client.InfoContext(ctx, req.(string))
+	})
+	out, _ := resp.(*scheme.Info)
+	return out, err
+}
+
+// Read implements the Client interface.
+func (c *middlewareClient) Read(opts scheme.ReadOptions) ([]*scheme.Read, error) {
+	return c.ReadContext(context.Background(), opts)
+}
+
+// ReadContext implements the Client interface.
+func (c *middlewareClient) ReadContext(ctx context.Context, opts scheme.ReadOptions) ([]*scheme.Read, error) {
+	resp, err := c.invoke(ctx, "Read", opts, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.Client.ReadContext(ctx, req.(scheme.ReadOptions))
+	})
+	out, _ := resp.([]*scheme.Read)
+	return out, err
+}
+
+// ReadDevice implements the Client interface.
+func (c *middlewareClient) ReadDevice(id string) ([]*scheme.Read, error) {
+	return c.ReadDeviceContext(context.Background(), id)
+}
+
+// ReadDeviceContext implements the Client interface.
+func (c *middlewareClient) ReadDeviceContext(ctx context.Context, id string) ([]*scheme.Read, error) {
+	resp, err := c.invoke(ctx, "ReadDevice", id, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.Client.ReadDeviceContext(ctx, req.(string))
+	})
+	out, _ := resp.([]*scheme.Read)
+	return out, err
+}
+
+// ReadCache implements the Client interface.
+func (c *middlewareClient) ReadCache(opts scheme.ReadCacheOptions, out chan<- *scheme.Read) error {
+	return c.ReadCacheContext(context.Background(), opts, out)
+}
+
+// ReadCacheContext implements the Client interface.
+func (c *middlewareClient) ReadCacheContext(ctx context.Context, opts scheme.ReadCacheOptions, out chan<- *scheme.Read) error {
+	called := false
+	_, err := c.invoke(ctx, "ReadCache", opts, func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, c.Client.ReadCacheContext(ctx, req.(scheme.ReadCacheOptions), out)
+	})
+	// The client closes the out channel, unless a middleware ended the
+	// operation before it got to the client.
+	if !called {
+		close(out)
+	}
+	return err
+}
+
+// ReadStream implements the Client interface.
+func (c *middlewareClient) ReadStream(opts scheme.ReadStreamOptions, out chan<- *scheme.Read, stop chan struct{}) error {
+	return c.ReadStreamContext(context.Background(), opts, out, stop)
+}
+
+// ReadStreamContext implements the Client interface.
+func (c *middlewareClient) ReadStreamContext(ctx context.Context, opts scheme.ReadStreamOptions, out chan<- *scheme.Read, stop chan struct{}) error {
+	_, err := c.invoke(ctx, "ReadStream", opts, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, c.Client.ReadStreamContext(ctx, req.(scheme.ReadStreamOptions), out, stop)
+	})
+	return err
+}
+
+// WriteAsync implements the Client interface.
+func (c *middlewareClient) WriteAsync(id string, opts []scheme.WriteData) ([]*scheme.Write, error) {
+	return c.WriteAsyncContext(context.Background(), id, opts)
+}
+
+// WriteAsyncContext implements the Client interface.
+func (c *middlewareClient) WriteAsyncContext(ctx context.Context, id string, opts []scheme.WriteData) ([]*scheme.Write, error) {
+	resp, err := c.invoke(ctx, "WriteAsync", WriteRequest{Device: id, Data: opts}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		w := req.(WriteRequest)
+		return c.Client.WriteAsyncContext(ctx, w.Device, w.Data)
+	})
+	out, _ := resp.([]*scheme.Write)
+	return out, err
+}
+
+// WriteSync implements the Client interface.
+func (c *middlewareClient) WriteSync(id string, opts []scheme.WriteData) ([]*scheme.Transaction, error) {
+	return c.WriteSyncContext(context.Background(), id, opts)
+}
+
+// WriteSyncContext implements the Client interface.
+func (c *middlewareClient) WriteSyncContext(ctx context.Context, id string, opts []scheme.WriteData) ([]*scheme.Transaction, error) {
+	resp, err := c.invoke(ctx, "WriteSync", WriteRequest{Device: id, Data: opts}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		w := req.(WriteRequest)
+		return c.Client.WriteSyncContext(ctx, w.Device, w.Data)
+	})
+	out, _ := resp.([]*scheme.Transaction)
+	return out, err
+}
+
+// Transactions implements the Client interface.
+func (c *middlewareClient) Transactions() ([]string, error) {
+	return c.TransactionsContext(context.Background())
+}
+
+// TransactionsContext implements the Client interface.
+func (c *middlewareClient) TransactionsContext(ctx context.Context) ([]string, error) {
+	resp, err := c.invoke(ctx, "Transactions", nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return c.Client.TransactionsContext(ctx)
+	})
+	out, _ := resp.([]string)
+	return out, err
+}
+
+// Transaction implements the Client interface.
+func (c *middlewareClient) Transaction(id string) (*scheme.Transaction, error) {
+	return c.TransactionContext(context.Background(), id)
+}
+
+// TransactionContext implements the Client interface.
+func (c *middlewareClient) TransactionContext(ctx context.Context, id string) (*scheme.Transaction, error) {
+	resp, err := c.invoke(ctx, "Transaction", id, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.Client.TransactionContext(ctx, req.(string))
+	})
+	out, _ := resp.(*scheme.Transaction)
+	return out, err
+}
@@ -0,0 +1,192 @@
+package synse
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/internal/test"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+// observed is an operation seen by an observing middleware.
+type observed struct {
+	name      string
+	transport string
+	request   interface{}
+	resp      interface{}
+	err       error
+}
+
+func TestMiddleware_Observe(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{
+		Devices: []synsetest.Device{{
+			ID:       "led-1",
+			Type:     "led",
+			Actions:  []string{"state"},
+			Readings: []synsetest.Reading{{Type: "state", Value: "off"}},
+		}},
+	})
+	defer server.Close()
+
+	for _, newClient := range []func(*Options) (Client, error){NewHTTPClientV3, NewWebSocketClientV3} {
+		var mu sync.Mutex
+		var ops []observed
+		client, err := newClient(&Options{
+			Address: server.Address,
+			Middleware: []Middleware{Observe(func(ctx context.Context, op *Operation, resp interface{}, err error, d time.Duration) {
+				mu.Lock()
+				defer mu.Unlock()
+				assert.True(t, d > 0)
+				ops = append(ops, observed{op.Name, op.Transport, op.Request, resp, err})
+			})},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, client.Open())
+
+		_, err = client.Scan(scheme.ScanOptions{Tags: []string{"system/type:led"}})
+		assert.NoError(t, err)
+		_, err = client.Info("led-2")
+		assert.Error(t, err)
+		_, err = client.WriteAsync("led-1", []scheme.WriteData{{Action: "state", Data: "on"}})
+		assert.NoError(t, err)
+		assert.NoError(t, client.Close())
+
+		transport := client.(*middlewareClient).transport
+		assert.Len(t, ops, 3)
+		assert.Equal(t, "Scan", ops[0].name)
+		assert.Equal(t, transport, ops[0].transport)
+		assert.Equal(t, scheme.ScanOptions{Tags: []string{"system/type:led"}}, ops[0].request)
+		assert.Len(t, ops[0].resp, 1)
+		assert.NoError(t, ops[0].err)
+
+		assert.Equal(t, "Info", ops[1].name)
+		assert.Equal(t, "led-2", ops[1].request)
+		assert.Nil(t, ops[1].resp)
+		assert.True(t, IsNotFound(ops[1].err))
+
+		assert.Equal(t, "WriteAsync", ops[2].name)
+		assert.Equal(t, WriteRequest{Device: "led-1", Data: []scheme.WriteData{{Action: "state", Data: "on"}}}, ops[2].request)
+		assert.IsType(t, []*scheme.Write{}, ops[2].resp)
+	}
+}
+
+func TestMiddleware_Order(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+	server.ServeUnversioned(t, "/test", 200, `{"status":"ok"}`)
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) (interface{}, error) {
+				calls = append(calls, name+" before")
+				resp, err := next(ctx, op)
+				calls = append(calls, name+" after")
+				return resp, err
+			}
+		}
+	}
+
+	client, err := NewHTTPClientV3(&Options{
+		Address:    server.URL,
+		Middleware: []Middleware{trace("outer"), trace("inner")},
+	})
+	assert.NoError(t, err)
+
+	_, err = client.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, calls)
+}
+
+func TestMiddleware_Header(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	var header http.Header
+	server.HandleVersioned("/scan", func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		fprintf(t, w, `[]`)
+	})
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+		Middleware: []Middleware{func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) (interface{}, error) {
+				op.Header.Set("X-Request-Id", "req-1")
+				op.Header.Add("X-Trace", op.Name)
+				return next(ctx, op)
+			}
+		}},
+	})
+	assert.NoError(t, err)
+
+	_, err = client.Scan(scheme.ScanOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "req-1", header.Get("X-Request-Id"))
+	assert.Equal(t, []string{"Scan"}, header.Values("X-Trace"))
+}
+
+func TestMiddleware_Rewrite(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{
+		Devices: []synsetest.Device{
+			{ID: "led-1", Type: "led", Tags: []string{"rack:1"}},
+			{ID: "led-2", Type: "led", Tags: []string{"rack:2"}},
+		},
+	})
+	defer server.Close()
+
+	// The middleware restricts scans to rack 1.
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.Address,
+		Middleware: []Middleware{func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) (interface{}, error) {
+				if opts, ok := op.Request.(scheme.ScanOptions); ok {
+					opts.Tags = []string{"rack:1"}
+					op.Request = opts
+				}
+				return next(ctx, op)
+			}
+		}},
+	})
+	assert.NoError(t, err)
+
+	devices, err := client.Scan(scheme.ScanOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+	assert.Equal(t, "led-1", devices[0].ID)
+
+	// Changing the type of the request is an error.
+	client, err = NewHTTPClientV3(&Options{
+		Address: server.Address,
+		Middleware: []Middleware{func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) (interface{}, error) {
+				op.Request = 42
+				return next(ctx, op)
+			}
+		}},
+	})
+	assert.NoError(t, err)
+
+	_, err = client.Info("led-1")
+	assert.EqualError(t, err, "middleware changed the Info request from string to int")
+}
+
+func TestMiddleware_None(t *testing.T) {
+	client, err := NewHTTPClientV3(&Options{
+		Address: "localhost:5000",
+	})
+	assert.NoError(t, err)
+	assert.IsType(t, &httpClient{}, client)
+
+	client, err = NewWebSocketClientV3(&Options{
+		Address: "localhost:5000",
+	})
+	assert.NoError(t, err)
+	assert.IsType(t, &websocketClient{}, client)
+}
@@ -0,0 +1,69 @@
+package synse
+
+// proxy.go selects the proxy the clients connect through.
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// proxySchemes holds the schemes of the proxies both clients support.
+var proxySchemes = map[string]bool{
+	"http":   true,
+	"socks5": true,
+}
+
+// parseProxyURL parses the URL of a proxy. A URL without a scheme is an HTTP
+// proxy.
+func parseProxyURL(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return nil, errors.Errorf("invalid proxy URL %q", s)
+	}
+	if !proxySchemes[u.Scheme] {
+		return nil, errors.Errorf("invalid proxy URL %q: unsupported scheme %q", s, u.Scheme)
+	}
+	return u, nil
+}
+
+// newProxyFunc returns the function which selects the proxy of a request for
+// the options, or nil if the proxy is disabled.
+func newProxyFunc(opts ProxyOptions) (func(*http.Request) (*url.URL, error), error) {
+	if opts.Disabled {
+		return nil, nil
+	}
+
+	cfg := httpproxy.FromEnvironment()
+	if opts.URL != "" {
+		u, err := parseProxyURL(opts.URL)
+		if err != nil {
+			return nil, err
+		}
+		cfg = &httpproxy.Config{
+			HTTPProxy:  u.String(),
+			HTTPSProxy: u.String(),
+			NoProxy:    strings.Join(opts.NoProxy, ","),
+		}
+	}
+
+	proxy := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := proxy(req.URL)
+		if err != nil || u == nil {
+			return u, err
+		}
+		if opts.Username != "" || opts.Password != "" {
+			withUser := *u
+			withUser.User = url.UserPassword(opts.Username, opts.Password)
+			u = &withUser
+		}
+		return u, nil
+	}, nil
+}
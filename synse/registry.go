@@ -0,0 +1,353 @@
+package synse
+
+// registry.go provides a client-side inventory of devices.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// defaultRegistryTTL is the TTL of the inventory if it is neither configured
+// nor available from the server config.
+const defaultRegistryTTL = time.Minute
+
+// RegistryOptions is the config options for a DeviceRegistry.
+type RegistryOptions struct {
+	// TTL specifies how long the inventory is used before it is refreshed.
+	// Zero value means the TTL is taken from the device cache settings of
+	// the server config.
+	TTL time.Duration
+
+	// Scan specifies the options of the scans which build the inventory,
+	// e.g. to only keep devices with certain tags.
+	Scan scheme.ScanOptions
+}
+
+// DeviceRegistry is an in-memory inventory of the devices known to Synse
+// Server, indexed for lookups by ID, alias, tag, plugin, type and metadata.
+// The inventory is built by scanning the devices, and is refreshed on a TTL.
+// The info of a device is fetched when it is first needed.
+//
+// A DeviceRegistry is safe for concurrent use. The devices it returns are
+// shared and must not be modified.
+type DeviceRegistry struct {
+	// client is the client used to scan devices.
+	client Client
+
+	// options holds the registry options.
+	options RegistryOptions
+
+	// refreshMu serializes refreshes, so that concurrent lookups of a stale
+	// inventory cause a single scan.
+	refreshMu sync.Mutex
+
+	// mu guards the fields below.
+	mu sync.RWMutex
+
+	// ttl is the resolved TTL of the inventory.
+	ttl time.Duration
+
+	// ttlResolved is set once the TTL is configured or taken from the
+	// server config. Until then, the default TTL is used and the server
+	// config is fetched again on each refresh.
+	ttlResolved bool
+
+	// generation is incremented each time the inventory is rebuilt, so that
+	// device info fetched before a rebuild is not kept after it.
+	generation uint64
+
+	// updated is the time the inventory was last built.
+	updated time.Time
+
+	// devices holds the devices in scan order.
+	devices []*scheme.Scan
+
+	// byID, byAlias, byTag, byPlugin and byType index the devices.
+	byID     map[string]*scheme.Scan
+	byAlias  map[string]*scheme.Scan
+	byTag    map[string][]*scheme.Scan
+	byPlugin map[string][]*scheme.Scan
+	byType   map[string][]*scheme.Scan
+
+	// infos holds the device info fetched so far, keyed by device ID.
+	infos map[string]*scheme.Info
+}
+
+// NewDeviceRegistry creates a device registry for the client. The inventory
+// is built on the first lookup.
+func NewDeviceRegistry(client Client, opts *RegistryOptions) *DeviceRegistry {
+	r := &DeviceRegistry{
+		client: client,
+	}
+	if opts != nil {
+		r.options = *opts
+	}
+	r.ttl = r.options.TTL
+	r.ttlResolved = r.ttl != 0
+	return r
+}
+
+// Refresh rebuilds the inventory.
+func (r *DeviceRegistry) Refresh(ctx context.Context) error {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	return r.refresh(ctx, false)
+}
+
+// Rescan rebuilds the inventory, forcing Synse Server to rebuild its own
+// device cache first.
+func (r *DeviceRegistry) Rescan(ctx context.Context) error {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	return r.refresh(ctx, true)
+}
+
+// Updated returns the time the inventory was last built.
+func (r *DeviceRegistry) Updated() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.updated
+}
+
+// Devices returns all devices of the inventory.
+func (r *DeviceRegistry) Devices(ctx context.Context) ([]*scheme.Scan, error) {
+	if err := r.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]*scheme.Scan(nil), r.devices...), nil
+}
+
+// Device returns the device with the given ID.
+func (r *DeviceRegistry) Device(ctx context.Context, id string) (*scheme.Scan, error) {
+	return r.lookup(ctx, func() *scheme.Scan {
+		return r.byID[id]
+	}, "device %s", id)
+}
+
+// ByAlias returns the device with the given alias.
+func (r *DeviceRegistry) ByAlias(ctx context.Context, alias string) (*scheme.Scan, error) {
+	return r.lookup(ctx, func() *scheme.Scan {
+		return r.byAlias[alias]
+	}, "device with alias %s", alias)
+}
+
+// ByTag returns the devices with the given tag. A tag without a namespace is
+// in the default namespace. A malformed tag results in an error matching
+// scheme.ErrInvalidTag.
+func (r *DeviceRegistry) ByTag(ctx context.Context, tag string) ([]*scheme.Scan, error) {
+	t, err := scheme.ParseTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	return r.list(ctx, func() []*scheme.Scan {
+		return r.byTag[t.Qualified().String()]
+	})
+}
+
+// ByPlugin returns the devices managed by the plugin with the given ID.
+func (r *DeviceRegistry) ByPlugin(ctx context.Context, plugin string) ([]*scheme.Scan, error) {
+	return r.list(ctx, func() []*scheme.Scan {
+		return r.byPlugin[plugin]
+	})
+}
+
+// ByType returns the devices of the given type.
+func (r *DeviceRegistry) ByType(ctx context.Context, deviceType string) ([]*scheme.Scan, error) {
+	return r.list(ctx, func() []*scheme.Scan {
+		return r.byType[deviceType]
+	})
+}
+
+// ByMetadata returns the devices whose metadata has the given value for the
+// key.
+func (r *DeviceRegistry) ByMetadata(ctx context.Context, key, value string) ([]*scheme.Scan, error) {
+	return r.list(ctx, func() []*scheme.Scan {
+		var out []*scheme.Scan
+		for _, d := range r.devices {
+			if v, ok := d.Metadata[key]; ok && fmt.Sprint(v) == value {
+				out = append(out, d)
+			}
+		}
+		return out
+	})
+}
+
+// Info returns the info of the device with the given ID. It is fetched from
+// Synse Server the first time it is needed, and kept until the inventory is
+// refreshed.
+func (r *DeviceRegistry) Info(ctx context.Context, id string) (*scheme.Info, error) {
+	if _, err := r.Device(ctx, id); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	info, ok := r.infos[id]
+	generation := r.generation
+	r.mu.RUnlock()
+	if ok {
+		return info, nil
+	}
+
+	info, err := r.client.InfoContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The info is not kept if the inventory was rebuilt in the meantime,
+	// since it may be older than the rebuilt inventory.
+	r.mu.Lock()
+	if r.infos != nil && r.generation == generation {
+		r.infos[id] = info
+	}
+	r.mu.Unlock()
+
+	return info, nil
+}
+
+// lookup returns the device found by the given function, once the inventory
+// is fresh. A device which is not found results in an error matching
+// ErrNotFound.
+func (r *DeviceRegistry) lookup(ctx context.Context, find func() *scheme.Scan, format string, args ...interface{}) (*scheme.Scan, error) {
+	if err := r.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d := find()
+	if d == nil {
+		return nil, errors.Wrapf(ErrNotFound, "%s is not in the device registry", fmt.Sprintf(format, args...))
+	}
+	return d, nil
+}
+
+// list returns a copy of the devices found by the given function, once the
+// inventory is fresh.
+func (r *DeviceRegistry) list(ctx context.Context, find func() []*scheme.Scan) ([]*scheme.Scan, error) {
+	if err := r.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]*scheme.Scan(nil), find()...), nil
+}
+
+// ensure refreshes the inventory if it was never built or its TTL expired.
+func (r *DeviceRegistry) ensure(ctx context.Context) error {
+	if r.fresh() {
+		return nil
+	}
+
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	// Another lookup may have refreshed the inventory in the meantime.
+	if r.fresh() {
+		return nil
+	}
+	return r.refresh(ctx, false)
+}
+
+// fresh reports whether the inventory is built and its TTL did not expire.
+func (r *DeviceRegistry) fresh() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return !r.updated.IsZero() && time.Since(r.updated) < r.ttl
+}
+
+// refresh scans the devices and rebuilds the indexes. It must be called with
+// refreshMu held.
+func (r *DeviceRegistry) refresh(ctx context.Context, force bool) error {
+	if !r.ttlResolved {
+		ttl, ok := r.serverTTL(ctx)
+		r.mu.Lock()
+		r.ttl = ttl
+		r.ttlResolved = ok
+		r.mu.Unlock()
+	}
+
+	opts := r.options.Scan
+	opts.Force = opts.Force || force
+	devices, err := r.client.ScanContext(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to scan devices")
+	}
+
+	byID := make(map[string]*scheme.Scan, len(devices))
+	byAlias := make(map[string]*scheme.Scan)
+	byTag := make(map[string][]*scheme.Scan)
+	byPlugin := make(map[string][]*scheme.Scan)
+	byType := make(map[string][]*scheme.Scan)
+	for _, d := range devices {
+		byID[d.ID] = d
+		if d.Alias != "" {
+			byAlias[d.Alias] = d
+		}
+		for _, t := range d.Tags {
+			t = normalizeTag(t)
+			byTag[t] = append(byTag[t], d)
+		}
+		byPlugin[d.Plugin] = append(byPlugin[d.Plugin], d)
+		byType[d.Type] = append(byType[d.Type], d)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.devices = devices
+	r.byID = byID
+	r.byAlias = byAlias
+	r.byTag = byTag
+	r.byPlugin = byPlugin
+	r.byType = byType
+	r.infos = make(map[string]*scheme.Info)
+	r.generation++
+	r.updated = time.Now()
+	return nil
+}
+
+// serverTTL returns the TTL of the inventory based on the device cache
+// settings of the server, which are in seconds. It falls back to the default
+// TTL if the config is not available, in which case it reports false.
+func (r *DeviceRegistry) serverTTL(ctx context.Context) (time.Duration, bool) {
+	cfg, err := r.client.ConfigContext(ctx)
+	if err != nil {
+		return defaultRegistryTTL, false
+	}
+
+	switch {
+	case cfg.Cache.Device.TTL > 0:
+		return time.Duration(cfg.Cache.Device.TTL) * time.Second, true
+	case cfg.Cache.Device.RebuildEvery > 0:
+		return time.Duration(cfg.Cache.Device.RebuildEvery) * time.Second, true
+	default:
+		return defaultRegistryTTL, true
+	}
+}
+
+// normalizeTag returns the tag with its namespace, adding the default
+// namespace to a tag which has none. A tag which does not parse is returned
+// as is.
+func normalizeTag(tag string) string {
+	t, err := scheme.ParseTag(tag)
+	if err != nil {
+		return tag
+	}
+	return t.Qualified().String()
+}
@@ -0,0 +1,278 @@
+package synse
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/internal/test"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+func TestDeviceRegistry_Lookup(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{
+		Devices: []synsetest.Device{{
+			ID:       "temp-1",
+			Alias:    "inlet",
+			Type:     "temperature",
+			Plugin:   "plugin-1",
+			Tags:     []string{"rack:1", "vapor/sensor"},
+			Metadata: map[string]string{"model": "t100"},
+		}, {
+			ID:     "temp-2",
+			Type:   "temperature",
+			Plugin: "plugin-2",
+			Tags:   []string{"rack:2"},
+		}, {
+			ID:       "led-1",
+			Type:     "led",
+			Plugin:   "plugin-1",
+			Tags:     []string{"rack:1"},
+			Actions:  []string{"state"},
+			Metadata: map[string]string{"model": "l1"},
+		}},
+	})
+	defer server.Close()
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.Address,
+	})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	registry := NewDeviceRegistry(client, &RegistryOptions{TTL: time.Minute})
+
+	devices, err := registry.Devices(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, devices, 3)
+
+	d, err := registry.Device(ctx, "temp-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "plugin-2", d.Plugin)
+
+	_, err = registry.Device(ctx, "temp-3")
+	assert.True(t, IsNotFound(err))
+	assert.EqualError(t, err, "device temp-3 is not in the device registry: synse: not found")
+
+	d, err = registry.ByAlias(ctx, "inlet")
+	assert.NoError(t, err)
+	assert.Equal(t, "temp-1", d.ID)
+
+	_, err = registry.ByAlias(ctx, "outlet")
+	assert.True(t, IsNotFound(err))
+
+	ids := func(devices []*scheme.Scan, err error) []string {
+		assert.NoError(t, err)
+		var out []string
+		for _, d := range devices {
+			out = append(out, d.ID)
+		}
+		return out
+	}
+
+	assert.ElementsMatch(t, []string{"temp-1", "led-1"}, ids(registry.ByTag(ctx, "rack:1")))
+	assert.ElementsMatch(t, []string{"temp-1", "led-1"}, ids(registry.ByTag(ctx, "default/rack:1")))
+	assert.ElementsMatch(t, []string{"temp-1"}, ids(registry.ByTag(ctx, "vapor/sensor")))
+	assert.ElementsMatch(t, []string{"led-1"}, ids(registry.ByTag(ctx, "system/id:led-1")))
+	assert.Empty(t, ids(registry.ByTag(ctx, "rack:3")))
+	_, err = registry.ByTag(ctx, "rack 1")
+	assert.True(t, errors.Is(err, scheme.ErrInvalidTag))
+	assert.ElementsMatch(t, []string{"temp-1", "led-1"}, ids(registry.ByPlugin(ctx, "plugin-1")))
+	assert.ElementsMatch(t, []string{"temp-1", "temp-2"}, ids(registry.ByType(ctx, "temperature")))
+	assert.ElementsMatch(t, []string{"led-1"}, ids(registry.ByMetadata(ctx, "model", "l1")))
+
+	info, err := registry.Info(ctx, "led-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"state"}, info.Capabilities.Write.Actions)
+
+	cached, err := registry.Info(ctx, "led-1")
+	assert.NoError(t, err)
+	assert.Same(t, info, cached)
+
+	_, err = registry.Info(ctx, "led-2")
+	assert.True(t, IsNotFound(err))
+}
+
+// serveRegistry serves the endpoints used by the registry, counting scans and
+// reporting whether the last scan was forced.
+func serveRegistry(t *testing.T, server *test.HTTPServer, cacheTTL int, scans *int32, forced *atomic.Bool) {
+	server.ServeVersioned(t, "/config", 200, `{"cache":{"device":{"ttl":`+strconv.Itoa(cacheTTL)+`,"rebuild_every":180}}}`)
+	server.HandleVersioned("/scan", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(scans, 1)
+		forced.Store(r.URL.Query().Get("force") == "true")
+		w.Header().Set("Content-Type", "application/json")
+		fprintf(t, w, `[{"id":"dev-1","type":"led","plugin":"plugin-1","tags":["system/id:dev-1"]}]`)
+	})
+}
+
+func TestDeviceRegistry_TTL(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	var scans int32
+	var forced atomic.Bool
+	serveRegistry(t, server, 0, &scans, &forced)
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	registry := NewDeviceRegistry(client, &RegistryOptions{TTL: 50 * time.Millisecond})
+	assert.True(t, registry.Updated().IsZero())
+
+	for i := 0; i < 3; i++ {
+		_, err := registry.Device(ctx, "dev-1")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&scans))
+	assert.False(t, registry.Updated().IsZero())
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = registry.Device(ctx, "dev-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&scans))
+	assert.False(t, forced.Load())
+
+	assert.NoError(t, registry.Rescan(ctx))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&scans))
+	assert.True(t, forced.Load())
+
+	assert.NoError(t, registry.Refresh(ctx))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&scans))
+	assert.False(t, forced.Load())
+}
+
+func TestDeviceRegistry_ServerTTL(t *testing.T) {
+	tests := []struct {
+		cacheTTL int
+		expected time.Duration
+	}{
+		{cacheTTL: 20, expected: 20 * time.Second},
+		{cacheTTL: 0, expected: 180 * time.Second},
+	}
+
+	for _, tt := range tests {
+		server := test.NewHTTPServerV3()
+
+		var scans int32
+		var forced atomic.Bool
+		serveRegistry(t, server, tt.cacheTTL, &scans, &forced)
+
+		client, err := NewHTTPClientV3(&Options{
+			Address: server.URL,
+		})
+		assert.NoError(t, err)
+
+		registry := NewDeviceRegistry(client, nil)
+		assert.NoError(t, registry.Refresh(context.Background()))
+		assert.Equal(t, tt.expected, registry.ttl)
+
+		server.Close()
+	}
+
+	// Without the server config, the default TTL is used.
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+	server.ServeVersioned(t, "/scan", 200, `[]`)
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NoError(t, err)
+
+	registry := NewDeviceRegistry(client, nil)
+	assert.NoError(t, registry.Refresh(context.Background()))
+	assert.Equal(t, defaultRegistryTTL, registry.ttl)
+}
+
+func TestDeviceRegistry_ServerTTLRetried(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	var failing atomic.Bool
+	failing.Store(true)
+	server.HandleVersioned("/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			fprintf(t, w, `{"http_code":500,"description":"unknown","context":"config failure"}`)
+			return
+		}
+		fprintf(t, w, `{"cache":{"device":{"ttl":20}}}`)
+	})
+	server.ServeVersioned(t, "/scan", 200, `[]`)
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NoError(t, err)
+
+	// The default TTL is used while the server config is not available, and
+	// the config is fetched again on the next refresh.
+	registry := NewDeviceRegistry(client, nil)
+	assert.NoError(t, registry.Refresh(context.Background()))
+	assert.Equal(t, defaultRegistryTTL, registry.ttl)
+
+	failing.Store(false)
+	assert.NoError(t, registry.Refresh(context.Background()))
+	assert.Equal(t, 20*time.Second, registry.ttl)
+}
+
+func TestDeviceRegistry_InfoRefreshed(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	var scans int32
+	var forced atomic.Bool
+	serveRegistry(t, server, 0, &scans, &forced)
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	registry := NewDeviceRegistry(client, &RegistryOptions{TTL: time.Minute})
+
+	// The inventory is refreshed while the first info is fetched, so that
+	// info is not kept.
+	var infos int32
+	server.HandleVersioned("/info/dev-1", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&infos, 1) == 1 {
+			assert.NoError(t, registry.Refresh(ctx))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fprintf(t, w, `{"id":"dev-1","type":"led"}`)
+	})
+
+	for i := 0; i < 3; i++ {
+		info, err := registry.Info(ctx, "dev-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "dev-1", info.ID)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&infos))
+}
+
+func TestDeviceRegistry_ScanError(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+	server.ServeVersioned(t, "/scan", 500, `{"http_code":500,"description":"unknown","context":"plugin failure"}`)
+
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NoError(t, err)
+
+	registry := NewDeviceRegistry(client, &RegistryOptions{TTL: time.Minute})
+	_, err = registry.Device(context.Background(), "dev-1")
+	assert.True(t, IsServerError(err))
+	assert.True(t, registry.Updated().IsZero())
+}
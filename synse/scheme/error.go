@@ -1,5 +1,7 @@
 package scheme
 
+import "time"
+
 // Error describes an error response.
 type Error struct {
 	HTTPCode    int    `json:"http_code" yaml:"http_code" mapstructure:"http_code"`
@@ -7,3 +9,8 @@ type Error struct {
 	Timestamp   string `json:"timestamp" yaml:"timestamp" mapstructure:"timestamp"`
 	Context     string `json:"context" yaml:"context" mapstructure:"context"`
 }
+
+// Time returns the parsed error timestamp.
+func (e *Error) Time() (time.Time, error) {
+	return parseTime(e.Timestamp)
+}
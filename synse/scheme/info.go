@@ -1,5 +1,7 @@
 package scheme
 
+import "time"
+
 // Info describes a response from `/info` endpoint.
 type Info struct {
 	Timestamp    string              `json:"timestamp" yaml:"timestamp" mapstructure:"timestamp"`
@@ -15,6 +17,11 @@ type Info struct {
 	Outputs      []OutputOptions     `json:"outputs" yaml:"outputs" mapstructure:"outputs"`
 }
 
+// Time returns the parsed info timestamp.
+func (i *Info) Time() (time.Time, error) {
+	return parseTime(i.Timestamp)
+}
+
 // CapabilitiesOptions holds the capabilities info.
 type CapabilitiesOptions struct {
 	Mode  string            `json:"mode" yaml:"mode" mapstructure:"mode"`
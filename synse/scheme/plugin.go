@@ -1,5 +1,7 @@
 package scheme
 
+import "time"
+
 // Plugin describes a response for `plugin` endpoint when the `id` URI
 // parameter is provided.
 type Plugin struct {
@@ -45,6 +47,11 @@ type HealthOptions struct {
 	Checks    []CheckOptions `json:"checks" yaml:"checks" mapstructure:"checks"`
 }
 
+// Time returns the parsed health timestamp.
+func (h *HealthOptions) Time() (time.Time, error) {
+	return parseTime(h.Timestamp)
+}
+
 // CheckOptions holds the health check info.
 type CheckOptions struct {
 	Name      string `json:"name" yaml:"name" mapstructure:"name"`
@@ -54,6 +61,11 @@ type CheckOptions struct {
 	Type      string `json:"type" yaml:"type" mapstructure:"type"`
 }
 
+// Time returns the parsed health check timestamp.
+func (c *CheckOptions) Time() (time.Time, error) {
+	return parseTime(c.Timestamp)
+}
+
 // PluginHealth describes a response for `plugin/health` endpoint.
 type PluginHealth struct {
 	Status    string   `json:"status" yaml:"status" mapstructure:"status"`
@@ -63,3 +75,8 @@ type PluginHealth struct {
 	Active    int      `json:"active" yaml:"active" mapstructure:"active"`
 	Inactive  int      `json:"inactive" yaml:"inactive" mapstructure:"inactive"`
 }
+
+// UpdatedTime returns the parsed time the plugin health was last updated.
+func (p *PluginHealth) UpdatedTime() (time.Time, error) {
+	return parseTime(p.Updated)
+}
@@ -1,5 +1,11 @@
 package scheme
 
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
 // Read describes a unit in a response for `/read` endpoint.
 type Read struct {
 	Device     string                 `json:"device" yaml:"device" mapstructure:"device"`
@@ -12,6 +18,50 @@ type Read struct {
 	Context    map[string]interface{} `json:"context" yaml:"context" mapstructure:"context"`
 }
 
+// Float64Value returns the reading value as a float64. Any numeric value is
+// converted; other values result in an error.
+func (r *Read) Float64Value() (float64, error) {
+	return toFloat64(r.Value)
+}
+
+// Int64Value returns the reading value as an int64. Integer values, and
+// floating point values which are integral, are converted; other values
+// result in an error.
+func (r *Read) Int64Value() (int64, error) {
+	return toInt64(r.Value)
+}
+
+// BoolValue returns the reading value as a bool. A value which is not a
+// bool results in an error.
+func (r *Read) BoolValue() (bool, error) {
+	b, ok := r.Value.(bool)
+	if !ok {
+		return false, errors.Errorf("value %v is a %T, not a bool", r.Value, r.Value)
+	}
+	return b, nil
+}
+
+// StringValue returns the reading value as a string. A value which is not a
+// string results in an error.
+func (r *Read) StringValue() (string, error) {
+	s, ok := r.Value.(string)
+	if !ok {
+		return "", errors.Errorf("value %v is a %T, not a string", r.Value, r.Value)
+	}
+	return s, nil
+}
+
+// BytesValue returns the reading value as bytes. The value may be a byte
+// slice, a list of byte values or a base64 encoded string.
+func (r *Read) BytesValue() ([]byte, error) {
+	return toBytes(r.Value)
+}
+
+// Time returns the parsed reading timestamp.
+func (r *Read) Time() (time.Time, error) {
+	return parseTime(r.Timestamp)
+}
+
 // ReadOptions describes the query parameters for `/read` endpoint.
 type ReadOptions struct {
 	NS   string   `json:"ns,omitempty" yaml:"ns,omitempty" mapstructure:"ns"`
@@ -0,0 +1,187 @@
+package scheme
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead_Float64Value(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected float64
+	}{
+		{value: 20.3, expected: 20.3},
+		{value: float32(2.5), expected: 2.5},
+		{value: 12, expected: 12},
+		{value: int64(-4), expected: -4},
+		{value: uint8(7), expected: 7},
+		{value: json.Number("1.5"), expected: 1.5},
+	}
+
+	for _, tt := range tests {
+		r := Read{Value: tt.value}
+		v, err := r.Float64Value()
+		assert.NoError(t, err, tt.value)
+		assert.Equal(t, tt.expected, v, tt.value)
+	}
+
+	for _, value := range []interface{}{nil, "20.3", true, json.Number("abc")} {
+		r := Read{Value: value}
+		_, err := r.Float64Value()
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRead_Int64Value(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected int64
+	}{
+		{value: float64(20), expected: 20},
+		{value: 12, expected: 12},
+		{value: int32(-4), expected: -4},
+		{value: uint64(7), expected: 7},
+		{value: json.Number("9007199254740993"), expected: 9007199254740993},
+		{value: json.Number("3.0"), expected: 3},
+	}
+
+	for _, tt := range tests {
+		r := Read{Value: tt.value}
+		v, err := r.Int64Value()
+		assert.NoError(t, err, tt.value)
+		assert.Equal(t, tt.expected, v, tt.value)
+	}
+
+	for _, value := range []interface{}{nil, 20.3, "20", false, uint64(1 << 63), 1e20} {
+		r := Read{Value: value}
+		_, err := r.Int64Value()
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRead_BoolValue(t *testing.T) {
+	r := Read{Value: true}
+	v, err := r.BoolValue()
+	assert.NoError(t, err)
+	assert.True(t, v)
+
+	r = Read{Value: "true"}
+	_, err = r.BoolValue()
+	assert.EqualError(t, err, "value true is a string, not a bool")
+}
+
+func TestRead_StringValue(t *testing.T) {
+	r := Read{Value: "on"}
+	v, err := r.StringValue()
+	assert.NoError(t, err)
+	assert.Equal(t, "on", v)
+
+	r = Read{Value: 1.0}
+	_, err = r.StringValue()
+	assert.EqualError(t, err, "value 1 is a float64, not a string")
+}
+
+func TestRead_BytesValue(t *testing.T) {
+	tests := []interface{}{
+		[]byte{1, 2, 255},
+		[]interface{}{float64(1), float64(2), float64(255)},
+		"AQL/",
+	}
+
+	for _, value := range tests {
+		r := Read{Value: value}
+		v, err := r.BytesValue()
+		assert.NoError(t, err, value)
+		assert.Equal(t, []byte{1, 2, 255}, v, value)
+	}
+
+	for _, value := range []interface{}{nil, 1, "not base64!", []interface{}{256}, []interface{}{"a"}} {
+		r := Read{Value: value}
+		_, err := r.BytesValue()
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRead_Decoded(t *testing.T) {
+	in := `{"device":"123","value":20,"timestamp":"2019-03-20T17:37:07.123Z"}`
+
+	// HTTP responses are decoded from JSON.
+	var fromJSON Read
+	assert.NoError(t, json.Unmarshal([]byte(in), &fromJSON))
+
+	// WebSocket responses are decoded from a map with mapstructure.
+	var data map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(in), &data))
+	var fromMap Read
+	assert.NoError(t, mapstructure.Decode(data, &fromMap))
+
+	for _, r := range []Read{fromJSON, fromMap} {
+		i, err := r.Int64Value()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20), i)
+
+		f, err := r.Float64Value()
+		assert.NoError(t, err)
+		assert.Equal(t, 20.0, f)
+
+		ts, err := r.Time()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2019, 3, 20, 17, 37, 7, 123000000, time.UTC), ts)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	ts, err := parseTime("2019-03-20T17:37:07Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2019, 3, 20, 17, 37, 7, 0, time.UTC), ts)
+
+	ts, err = parseTime("2019-03-20T17:37:07.5+01:00")
+	assert.NoError(t, err)
+	assert.True(t, time.Date(2019, 3, 20, 16, 37, 7, 500000000, time.UTC).Equal(ts))
+
+	_, err = parseTime("")
+	assert.EqualError(t, err, "timestamp is not set")
+
+	_, err = parseTime("yesterday")
+	assert.Error(t, err)
+}
+
+func TestTimestamps(t *testing.T) {
+	expected := time.Date(2019, 3, 20, 17, 37, 7, 0, time.UTC)
+	ts := "2019-03-20T17:37:07Z"
+
+	for _, fn := range []func() (time.Time, error){
+		(&Status{Timestamp: ts}).Time,
+		(&Transaction{Created: ts}).CreatedTime,
+		(&Transaction{Updated: ts}).UpdatedTime,
+		(&HealthOptions{Timestamp: ts}).Time,
+		(&CheckOptions{Timestamp: ts}).Time,
+		(&PluginHealth{Updated: ts}).UpdatedTime,
+		(&Info{Timestamp: ts}).Time,
+		(&Error{Timestamp: ts}).Time,
+	} {
+		v, err := fn()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, v)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	d, err := (&Write{Timeout: "30s"}).TimeoutDuration()
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, d)
+
+	d, err = (&Transaction{Timeout: "1m30s"}).TimeoutDuration()
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d)
+
+	_, err = parseDuration("")
+	assert.EqualError(t, err, "duration is not set")
+
+	_, err = parseDuration("soon")
+	assert.Error(t, err)
+}
@@ -1,7 +1,14 @@
 package scheme
 
+import "time"
+
 // Status describes a response for `/test` endpoint.
 type Status struct {
 	Status    string `json:"status" yaml:"status" mapstructure:"status"`
 	Timestamp string `json:"timestamp" yaml:"timestamp" mapstructure:"timestamp"`
 }
+
+// Time returns the parsed status timestamp.
+func (s *Status) Time() (time.Time, error) {
+	return parseTime(s.Timestamp)
+}
@@ -0,0 +1,265 @@
+package scheme
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultNamespace is the namespace of a tag which does not specify one.
+	DefaultNamespace = "default"
+
+	// SystemNamespace is the namespace of the tags that Synse generates for
+	// every device.
+	SystemNamespace = "system"
+)
+
+// ErrInvalidTag matches the error for a tag, tag group or namespace which
+// does not follow the tag format.
+var ErrInvalidTag = errors.New("invalid tag")
+
+// Tag is a device tag, in the `[namespace/][annotation:]label` format. Tags
+// select the devices of scans and reads.
+type Tag struct {
+	// Namespace is the namespace of the tag. An empty namespace means the
+	// namespace of the request, which is the default namespace unless
+	// specified otherwise.
+	Namespace string
+
+	// Annotation is the optional annotation of the tag.
+	Annotation string
+
+	// Label is the label of the tag.
+	Label string
+}
+
+// IDTag returns the system tag which selects the device with the given ID.
+func IDTag(id string) Tag {
+	return Tag{Namespace: SystemNamespace, Annotation: "id", Label: id}
+}
+
+// TypeTag returns the system tag which selects the devices of the given type.
+func TypeTag(deviceType string) Tag {
+	return Tag{Namespace: SystemNamespace, Annotation: "type", Label: deviceType}
+}
+
+// ParseTag parses a tag in the `[namespace/][annotation:]label` format.
+func ParseTag(s string) (Tag, error) {
+	var t Tag
+	rest := s
+	if i := strings.Index(rest, "/"); i >= 0 {
+		t.Namespace, rest = rest[:i], rest[i+1:]
+		if t.Namespace == "" {
+			return Tag{}, errors.Wrapf(ErrInvalidTag, "tag %q has an empty namespace", s)
+		}
+	}
+	if i := strings.Index(rest, ":"); i >= 0 {
+		t.Annotation, rest = rest[:i], rest[i+1:]
+		if t.Annotation == "" {
+			return Tag{}, errors.Wrapf(ErrInvalidTag, "tag %q has an empty annotation", s)
+		}
+	}
+	t.Label = rest
+
+	if err := t.Validate(); err != nil {
+		return Tag{}, err
+	}
+	return t, nil
+}
+
+// MustParseTag is like ParseTag, but panics if the tag is malformed. It is
+// meant for tags known at compile time.
+func MustParseTag(s string) Tag {
+	t, err := ParseTag(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// String returns the tag in its string format.
+func (t Tag) String() string {
+	var b strings.Builder
+	if t.Namespace != "" {
+		b.WriteString(t.Namespace)
+		b.WriteByte('/')
+	}
+	if t.Annotation != "" {
+		b.WriteString(t.Annotation)
+		b.WriteByte(':')
+	}
+	b.WriteString(t.Label)
+	return b.String()
+}
+
+// Validate checks that the tag has a label, and that its string format
+// parses back into the same tag.
+func (t Tag) Validate() error {
+	if t.Label == "" {
+		return errors.Wrapf(ErrInvalidTag, "tag %q has an empty label", t.String())
+	}
+	if err := ValidateNamespace(t.Namespace); err != nil {
+		return err
+	}
+	if err := validateTagPart(t, "an annotation", t.Annotation, "/:"); err != nil {
+		return err
+	}
+	if t.Annotation == "" {
+		// Without an annotation, a colon in the label would parse as one.
+		return validateTagPart(t, "a label", t.Label, "/:")
+	}
+	return validateTagPart(t, "a label", t.Label, "/")
+}
+
+// Qualified returns the tag with the default namespace if it has none, which
+// is how Synse Server reports it.
+func (t Tag) Qualified() Tag {
+	if t.Namespace == "" {
+		t.Namespace = DefaultNamespace
+	}
+	return t
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (t Tag) MarshalText() ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (t *Tag) UnmarshalText(text []byte) error {
+	parsed, err := ParseTag(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// ValidateNamespace checks that a namespace can be used in a tag or as the
+// namespace of a request. An empty namespace is valid.
+func ValidateNamespace(ns string) error {
+	if r, ok := findInvalidTagRune(ns, "/:"); ok {
+		return errors.Wrapf(ErrInvalidTag, "namespace %q contains %q", ns, r)
+	}
+	return nil
+}
+
+// ParseTagGroup parses a tag group, which is a comma separated list of tags
+// that a device must all have to be selected.
+func ParseTagGroup(s string) ([]Tag, error) {
+	var tags []Tag
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, errors.Wrapf(ErrInvalidTag, "tag group %q has an empty tag", s)
+		}
+		t, err := ParseTag(part)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// ValidateTagGroups checks the tag groups of a request.
+func ValidateTagGroups(groups []string) error {
+	for _, g := range groups {
+		if _, err := ParseTagGroup(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTagPart checks that a part of the tag does not contain characters
+// which would change how it parses.
+func validateTagPart(t Tag, name, part, reserved string) error {
+	if r, ok := findInvalidTagRune(part, reserved); ok {
+		return errors.Wrapf(ErrInvalidTag, "tag %q has %s which contains %q", t.String(), name, r)
+	}
+	return nil
+}
+
+// findInvalidTagRune returns the first rune of s which may not be used in a
+// part of a tag: whitespace, the comma which separates the tags of a group,
+// or one of the given reserved characters.
+func findInvalidTagRune(s, reserved string) (rune, bool) {
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == ',' || strings.ContainsRune(reserved, r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// Selector builds the tag groups which select devices for a scan or a read.
+// A device is selected if it has all tags of any of the groups. The zero
+// value selects all devices.
+//
+// A Selector is immutable; its methods return a new Selector.
+type Selector struct {
+	groups [][]Tag
+}
+
+// Select returns a selector for the devices which have all the given tags.
+func Select(tags ...Tag) Selector {
+	return Selector{}.Or(tags...)
+}
+
+// Or returns a selector which also selects the devices which have all the
+// given tags.
+func (s Selector) Or(tags ...Tag) Selector {
+	if len(tags) == 0 {
+		return s
+	}
+	groups := make([][]Tag, len(s.groups), len(s.groups)+1)
+	copy(groups, s.groups)
+	return Selector{groups: append(groups, append([]Tag(nil), tags...))}
+}
+
+// Validate checks all tags of the selector.
+func (s Selector) Validate() error {
+	for _, g := range s.groups {
+		for _, t := range g {
+			if err := t.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Groups returns the selector as tag groups, for the Tags of ScanOptions,
+// ReadOptions and ReadStreamOptions.
+func (s Selector) Groups() []string {
+	var out []string
+	for _, g := range s.groups {
+		tags := make([]string, len(g))
+		for i, t := range g {
+			tags[i] = t.String()
+		}
+		out = append(out, strings.Join(tags, ","))
+	}
+	return out
+}
+
+// ScanOptions returns the scan options for the selected devices.
+func (s Selector) ScanOptions() ScanOptions {
+	return ScanOptions{Tags: s.Groups()}
+}
+
+// ReadOptions returns the read options for the selected devices.
+func (s Selector) ReadOptions() ReadOptions {
+	return ReadOptions{Tags: s.Groups()}
+}
+
+// ReadStreamOptions returns the read stream options for the selected devices.
+func (s Selector) ReadStreamOptions() ReadStreamOptions {
+	return ReadStreamOptions{Tags: s.Groups()}
+}
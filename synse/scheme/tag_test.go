@@ -0,0 +1,121 @@
+package scheme
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Tag
+	}{
+		{in: "foo", expected: Tag{Label: "foo"}},
+		{in: "rack:1", expected: Tag{Annotation: "rack", Label: "1"}},
+		{in: "vapor/foo", expected: Tag{Namespace: "vapor", Label: "foo"}},
+		{in: "system/id:1234", expected: Tag{Namespace: "system", Annotation: "id", Label: "1234"}},
+		{in: "ns/a:b:c", expected: Tag{Namespace: "ns", Annotation: "a", Label: "b:c"}},
+	}
+
+	for _, tt := range tests {
+		tag, err := ParseTag(tt.in)
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.expected, tag, tt.in)
+		assert.Equal(t, tt.in, tag.String(), tt.in)
+	}
+}
+
+func TestParseTag_Invalid(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{in: "", expected: `tag "" has an empty label: invalid tag`},
+		{in: "ns/", expected: `tag "ns/" has an empty label: invalid tag`},
+		{in: "/foo", expected: `tag "/foo" has an empty namespace: invalid tag`},
+		{in: "ns/:foo", expected: `tag "ns/:foo" has an empty annotation: invalid tag`},
+		{in: "a/b/c", expected: `tag "a/b/c" has a label which contains '/': invalid tag`},
+		{in: "a:b/c", expected: `namespace "a:b" contains ':': invalid tag`},
+		{in: "rack 1", expected: `tag "rack 1" has a label which contains ' ': invalid tag`},
+		{in: "a,b", expected: `tag "a,b" has a label which contains ',': invalid tag`},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseTag(tt.in)
+		assert.EqualError(t, err, tt.expected, tt.in)
+		assert.True(t, errors.Is(err, ErrInvalidTag), tt.in)
+	}
+
+	assert.Panics(t, func() { MustParseTag("a/b/c") })
+}
+
+func TestTag_Validate(t *testing.T) {
+	assert.NoError(t, IDTag("1234").Validate())
+	assert.NoError(t, TypeTag("temperature").Validate())
+	assert.EqualError(t, IDTag("").Validate(), `tag "system/id:" has an empty label: invalid tag`)
+
+	// Without an annotation, the colon would be parsed as one.
+	assert.Error(t, Tag{Label: "a:b"}.Validate())
+	assert.NoError(t, Tag{Annotation: "a", Label: "b:c"}.Validate())
+}
+
+func TestTag_Qualified(t *testing.T) {
+	assert.Equal(t, "default/rack:1", MustParseTag("rack:1").Qualified().String())
+	assert.Equal(t, "vapor/rack:1", MustParseTag("vapor/rack:1").Qualified().String())
+}
+
+func TestTag_JSON(t *testing.T) {
+	var tags []Tag
+	assert.NoError(t, json.Unmarshal([]byte(`["system/type:led","rack:1"]`), &tags))
+	assert.Equal(t, []Tag{TypeTag("led"), {Annotation: "rack", Label: "1"}}, tags)
+
+	out, err := json.Marshal(tags)
+	assert.NoError(t, err)
+	assert.Equal(t, `["system/type:led","rack:1"]`, string(out))
+
+	assert.Error(t, json.Unmarshal([]byte(`["a/b/c"]`), &tags))
+	_, err = json.Marshal(Tag{})
+	assert.Error(t, err)
+}
+
+func TestParseTagGroup(t *testing.T) {
+	tags, err := ParseTagGroup("rack:1, system/type:led")
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{{Annotation: "rack", Label: "1"}, TypeTag("led")}, tags)
+
+	_, err = ParseTagGroup("rack:1,")
+	assert.EqualError(t, err, `tag group "rack:1," has an empty tag: invalid tag`)
+
+	assert.NoError(t, ValidateTagGroups([]string{"rack:1,led", "system/id:1"}))
+	assert.Error(t, ValidateTagGroups([]string{"rack:1", "/led"}))
+}
+
+func TestValidateNamespace(t *testing.T) {
+	assert.NoError(t, ValidateNamespace(""))
+	assert.NoError(t, ValidateNamespace("vapor"))
+	assert.EqualError(t, ValidateNamespace("vapor/io"), `namespace "vapor/io" contains '/': invalid tag`)
+}
+
+func TestSelector(t *testing.T) {
+	assert.Nil(t, Selector{}.Groups())
+
+	base := Select(TypeTag("temperature"), MustParseTag("rack:1"))
+	sel := base.Or(IDTag("1234"))
+	assert.Equal(t, []string{"system/type:temperature,rack:1", "system/id:1234"}, sel.Groups())
+	assert.NoError(t, sel.Validate())
+
+	// The selectors do not share their groups.
+	other := base.Or(IDTag("5678"))
+	assert.Equal(t, []string{"system/type:temperature,rack:1"}, base.Groups())
+	assert.Equal(t, []string{"system/type:temperature,rack:1", "system/id:5678"}, other.Groups())
+	assert.Equal(t, []string{"system/type:temperature,rack:1", "system/id:1234"}, sel.Groups())
+
+	assert.Equal(t, sel.Groups(), sel.ScanOptions().Tags)
+	assert.Equal(t, sel.Groups(), sel.ReadOptions().Tags)
+	assert.Equal(t, sel.Groups(), sel.ReadStreamOptions().Tags)
+
+	assert.Error(t, Select(Tag{Namespace: "a b", Label: "c"}).Validate())
+}
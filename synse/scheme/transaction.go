@@ -1,5 +1,16 @@
 package scheme
 
+import "time"
+
+// Transaction statuses reported by Synse Server. A transaction moves from
+// pending to writing, and finishes as either done or error.
+const (
+	TransactionPending = "pending"
+	TransactionWriting = "writing"
+	TransactionDone    = "done"
+	TransactionError   = "error"
+)
+
 // Transaction describes a response for `/transaction` endpoint. It also
 // describes an unit in a response for `/write/wait` endpoint.
 type Transaction struct {
@@ -12,3 +23,18 @@ type Transaction struct {
 	Updated string    `json:"updated" yaml:"updated" mapstructure:"updated"`
 	Message string    `json:"message" yaml:"message" mapstructure:"message"`
 }
+
+// CreatedTime returns the parsed time the transaction was created.
+func (t *Transaction) CreatedTime() (time.Time, error) {
+	return parseTime(t.Created)
+}
+
+// UpdatedTime returns the parsed time the transaction was last updated.
+func (t *Transaction) UpdatedTime() (time.Time, error) {
+	return parseTime(t.Updated)
+}
+
+// TimeoutDuration returns the parsed transaction timeout.
+func (t *Transaction) TimeoutDuration() (time.Duration, error) {
+	return parseDuration(t.Timeout)
+}
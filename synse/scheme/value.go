@@ -0,0 +1,144 @@
+package scheme
+
+// value.go provides conversions of decoded reading values, timestamps and
+// durations.
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// toFloat64 converts a decoded numeric value to a float64. Values decoded from
+// JSON are float64 (or json.Number), whereas values set by other decoders may
+// be any of the Go numeric types.
+func toFloat64(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return 0, errors.Wrapf(err, "value %q is not a number", n)
+		}
+		return f, nil
+	case nil:
+		return 0, errors.New("value is not set")
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	default:
+		return 0, errors.Errorf("value %v is a %T, not a number", v, v)
+	}
+}
+
+// toInt64 converts a decoded numeric value to an int64. A floating point
+// value is only converted if it is integral, as JSON numbers are decoded as
+// float64.
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return 0, errors.Wrapf(err, "value %q is not a number", n)
+		}
+		return floatToInt64(f)
+	case nil:
+		return 0, errors.New("value is not set")
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return floatToInt64(rv.Float())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			return 0, errors.Errorf("value %v overflows int64", u)
+		}
+		return int64(u), nil
+	default:
+		return 0, errors.Errorf("value %v is a %T, not an integer", v, v)
+	}
+}
+
+// floatToInt64 converts an integral float64 to an int64.
+func floatToInt64(f float64) (int64, error) {
+	if f != math.Trunc(f) || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, errors.Errorf("value %v is not an integer", f)
+	}
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, errors.Errorf("value %v overflows int64", f)
+	}
+	return int64(f), nil
+}
+
+// toBytes converts a decoded value to a byte slice. Bytes may be decoded as a
+// byte slice, a list of numbers or a base64 encoded string.
+func toBytes(v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		out, err := base64.StdEncoding.DecodeString(b)
+		if err != nil {
+			return nil, errors.Wrapf(err, "value %q is not base64 encoded bytes", b)
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]byte, len(b))
+		for i, e := range b {
+			n, err := toInt64(e)
+			if err != nil || n < 0 || n > math.MaxUint8 {
+				return nil, errors.Errorf("value %v at index %d is not a byte", e, i)
+			}
+			out[i] = byte(n)
+		}
+		return out, nil
+	case nil:
+		return nil, errors.New("value is not set")
+	default:
+		return nil, errors.Errorf("value %v is a %T, not bytes", v, v)
+	}
+}
+
+// parseTime parses a timestamp returned by Synse Server, which is formatted
+// as RFC3339 with optional fractional seconds.
+func parseTime(ts string) (time.Time, error) {
+	if ts == "" {
+		return time.Time{}, errors.New("timestamp is not set")
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse timestamp %q", ts)
+	}
+	return t, nil
+}
+
+// parseDuration parses a duration returned by Synse Server, e.g. `30s`.
+func parseDuration(d string) (time.Duration, error) {
+	if d == "" {
+		return 0, errors.New("duration is not set")
+	}
+
+	out, err := time.ParseDuration(d)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse duration %q", d)
+	}
+	return out, nil
+}
@@ -1,5 +1,7 @@
 package scheme
 
+import "time"
+
 // Write describes an unit in a response for the `/write` endpoint.
 type Write struct {
 	ID      string    `json:"id" yaml:"id" mapstructure:"id"`
@@ -8,6 +10,11 @@ type Write struct {
 	Timeout string    `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
 }
 
+// TimeoutDuration returns the parsed timeout of the write transaction.
+func (w *Write) TimeoutDuration() (time.Duration, error) {
+	return parseDuration(w.Timeout)
+}
+
 // WriteData describes an unit in the POST body for the `/write` endpoint. This
 // can also be used in a websocket request event payload.
 type WriteData struct {
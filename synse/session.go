@@ -0,0 +1,327 @@
+package synse
+
+// session.go manages a websocket connection and routes its response events.
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// errSessionClosed is the error reported to waiters of a session that was
+// closed by the client.
+var errSessionClosed = errors.New("websocket connection is closed")
+
+// session is a single websocket connection. A background reader goroutine
+// reads every response event off the connection and routes it to the waiter
+// registered for its request ID, so many requests can be in flight on the
+// same connection at once.
+type session struct {
+	// conn is the underlying websocket connection.
+	conn *websocket.Conn
+
+	// address is the address of Synse Server the connection is to.
+	address string
+
+	// options holds the websocket options of the client.
+	options WebSocketOptions
+
+	// writeMu serializes writes to the connection, as gorilla/websocket
+	// supports only one concurrent writer.
+	writeMu sync.Mutex
+
+	// mu guards waiters.
+	mu sync.Mutex
+
+	// waiters holds the waiters for in-flight requests, keyed by request ID.
+	waiters map[uint64]*waiter
+
+	// done is closed once the reader exits and the session can no longer be
+	// used.
+	done chan struct{}
+
+	// err is the reason the session ended. It is set before done is closed.
+	err error
+
+	// closing is set when the session is closed by the client.
+	closing atomic.Bool
+
+	// onEnd is called when the session ends, before done is closed.
+	onEnd func(*session)
+}
+
+// waiter receives the response events for a single request.
+type waiter struct {
+	// responses receives the response events for the request.
+	responses chan scheme.Response
+
+	// done is closed when the waiter is removed from the session, which
+	// releases the reader if it is blocked delivering to it.
+	done chan struct{}
+}
+
+// newSession creates a session for the connection and starts its reader, as
+// well as its pinger if keepalive is enabled. The onEnd callback is called once
+// the session ends.
+func newSession(conn *websocket.Conn, address string, opts WebSocketOptions, onEnd func(*session)) *session {
+	s := &session{
+		conn:    conn,
+		address: address,
+		options: opts,
+		waiters: make(map[uint64]*waiter),
+		done:    make(chan struct{}),
+		onEnd:   onEnd,
+	}
+
+	if s.keepalive() {
+		_ = conn.SetReadDeadline(s.readDeadline())
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(s.readDeadline())
+		})
+		go s.ping()
+	}
+
+	go s.read()
+	return s
+}
+
+// register adds a waiter for the given request ID. The buffer sets how many
+// response events can be queued for the waiter before the reader blocks; a
+// request expects a single response, whereas a stream expects many.
+func (s *session) register(id uint64, buffer int) *waiter {
+	w := &waiter{
+		responses: make(chan scheme.Response, buffer),
+		done:      make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.waiters[id] = w
+	s.mu.Unlock()
+
+	return w
+}
+
+// unregister removes the waiter for the given request ID. Any further
+// response events with that ID are discarded.
+func (s *session) unregister(id uint64) {
+	s.mu.Lock()
+	w, ok := s.waiters[id]
+	delete(s.waiters, id)
+	s.mu.Unlock()
+
+	if ok {
+		close(w.done)
+	}
+}
+
+// writeJSON serializes the request event to the connection. The write is bound
+// by the context deadline, if any.
+func (s *session) writeJSON(ctx context.Context, v interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.conn.SetWriteDeadline(s.writeDeadline(ctx)); err != nil {
+		return err
+	}
+	return s.conn.WriteJSON(v)
+}
+
+// writeDeadline returns the deadline for a write, which is the earliest of the
+// context deadline and the write timeout.
+func (s *session) writeDeadline(ctx context.Context) time.Time {
+	var deadline time.Time
+	if s.options.WriteTimeout > 0 {
+		deadline = time.Now().Add(s.options.WriteTimeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	return deadline
+}
+
+// keepalive reports whether keepalive pings are enabled.
+func (s *session) keepalive() bool {
+	return s.options.PingInterval > 0
+}
+
+// readDeadline returns the deadline for the next message (or pong) from the
+// peer when keepalive is enabled.
+func (s *session) readDeadline() time.Time {
+	return time.Now().Add(s.options.PingInterval + s.options.PongTimeout)
+}
+
+// ping sends a ping every ping interval until the session ends. If the peer
+// does not answer, the read deadline expires and the session ends.
+func (s *session) ping() {
+	ticker := time.NewTicker(s.options.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// WriteControl is safe to call concurrently with other writes.
+			deadline := time.Now().Add(s.options.PongTimeout)
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				// A failed ping is detected by the reader, either through
+				// the broken connection or the read deadline.
+				continue
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// writeClose sends a close message to the server, bound by the given timeout.
+func (s *session) writeClose(timeout time.Duration) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	return s.conn.WriteMessage(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(
+			websocket.CloseNormalClosure,
+			"",
+		),
+	)
+}
+
+// wait waits for the next response event of the waiter. It returns early if
+// the context is done, the timeout elapses (a zero timeout waits
+// indefinitely) or the session ends.
+func (s *session) wait(ctx context.Context, w *waiter, timeout time.Duration) (scheme.Response, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case r := <-w.responses:
+		return r, nil
+	case <-ctx.Done():
+		return scheme.Response{}, ctx.Err()
+	case <-expired:
+		return scheme.Response{}, errors.Wrapf(ErrTimeout, "no response received within %v", timeout)
+	case <-s.done:
+		// A response may have been routed right before the session ended.
+		select {
+		case r := <-w.responses:
+			return r, nil
+		default:
+			return scheme.Response{}, s.err
+		}
+	}
+}
+
+// closed reports whether the session has ended.
+func (s *session) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// close closes the underlying connection, which terminates the reader.
+func (s *session) close() {
+	s.closing.Store(true)
+	_ = s.conn.Close()
+	<-s.done
+}
+
+// read reads response events off the connection and routes them to their
+// waiters until the connection fails or is closed.
+func (s *session) read() {
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			switch {
+			case s.closing.Load() || websocket.IsCloseError(err, websocket.CloseNormalClosure):
+				err = errSessionClosed
+			case s.keepalive() && isTimeout(err):
+				err = errors.Wrapf(ErrConnectionDead, "no pong received within %v", s.options.PingInterval+s.options.PongTimeout)
+			}
+			s.end(newTransportError(err, "failed to read from connection"))
+			return
+		}
+
+		// Any message from the peer shows that the connection is alive.
+		if s.keepalive() {
+			_ = s.conn.SetReadDeadline(s.readDeadline())
+		}
+
+		var r scheme.Response
+		if err := json.Unmarshal(data, &r); err != nil {
+			// The response can not be routed, so the connection is in
+			// an unknown state and is not usable any more.
+			s.end(newTransportError(err, "failed to decode response message"))
+			return
+		}
+
+		s.mu.Lock()
+		w, ok := s.waiters[r.ID]
+		s.mu.Unlock()
+
+		// Responses for unknown (e.g. abandoned or stopped) requests are
+		// discarded.
+		if !ok {
+			continue
+		}
+
+		select {
+		case w.responses <- r:
+		case <-w.done:
+		}
+	}
+}
+
+// end records the reason the session ended, closes the connection and
+// releases all of the waiters.
+func (s *session) end(err error) {
+	s.err = err
+	_ = s.conn.Close()
+	if s.onEnd != nil {
+		s.onEnd(s)
+	}
+	close(s.done)
+}
+
+// reconnect tracks an in-progress reconnect of a lost session.
+type reconnect struct {
+	// done is closed once the reconnect finishes, whether it succeeded or
+	// gave up.
+	done chan struct{}
+
+	// aborted is closed when the reconnect is aborted by the client.
+	aborted chan struct{}
+
+	// once guards closing aborted.
+	once sync.Once
+}
+
+// newReconnect creates a new reconnect.
+func newReconnect() *reconnect {
+	return &reconnect{
+		done:    make(chan struct{}),
+		aborted: make(chan struct{}),
+	}
+}
+
+// abort stops the reconnect at its next attempt.
+func (r *reconnect) abort() {
+	r.once.Do(func() {
+		close(r.aborted)
+	})
+}
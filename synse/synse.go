@@ -3,73 +3,133 @@ package synse
 // synse.go provides a client API for Synse Server.
 
 import (
+	"context"
+
 	"github.com/vapor-ware/synse-client-go/synse/scheme"
 )
 
 // Client API for Synse Server.
+//
+// Each API method has a Context variant (e.g. StatusContext for Status) which
+// takes a context.Context. The context can be used to cancel an in-flight
+// request or to bound it with a deadline. The variants without a context use
+// context.Background().
 type Client interface {
 	// Status returns the status info. This is used to check if the server
 	// is responsive and reachable.
 	Status() (*scheme.Status, error)
 
+	// StatusContext is like Status, but uses the given context.
+	StatusContext(context.Context) (*scheme.Status, error)
+
 	// Version returns the version info.
 	Version() (*scheme.Version, error)
 
+	// VersionContext is like Version, but uses the given context.
+	VersionContext(context.Context) (*scheme.Version, error)
+
 	// Config returns the unified configuration info.
 	Config() (*scheme.Config, error)
 
+	// ConfigContext is like Config, but uses the given context.
+	ConfigContext(context.Context) (*scheme.Config, error)
+
 	// Plugins returns the summary of all plugins currently registered with
 	// Synse Server.
 	Plugins() ([]*scheme.PluginMeta, error)
 
+	// PluginsContext is like Plugins, but uses the given context.
+	PluginsContext(context.Context) ([]*scheme.PluginMeta, error)
+
 	// Plugin returns data from a specific plugin.
 	Plugin(string) (*scheme.Plugin, error)
 
+	// PluginContext is like Plugin, but uses the given context.
+	PluginContext(context.Context, string) (*scheme.Plugin, error)
+
 	// PluginHealth returns the summary of the health of registered plugins.
 	PluginHealth() (*scheme.PluginHealth, error)
 
+	// PluginHealthContext is like PluginHealth, but uses the given context.
+	PluginHealthContext(context.Context) (*scheme.PluginHealth, error)
+
 	// Scan returns the list of devices that Synse knows about and can read
 	// from/write to via the configured plugins.
 	// It can be filtered to show only those devices which match a set
 	// of provided tags by using ScanOptions.
 	Scan(scheme.ScanOptions) ([]*scheme.Scan, error)
 
+	// ScanContext is like Scan, but uses the given context.
+	ScanContext(context.Context, scheme.ScanOptions) ([]*scheme.Scan, error)
+
 	// Tags returns the list of all tags currently associated with devices.
 	// If no TagsOptions is specified, the default tag namespace will be used.
 	Tags(scheme.TagsOptions) ([]string, error)
 
+	// TagsContext is like Tags, but uses the given context.
+	TagsContext(context.Context, scheme.TagsOptions) ([]string, error)
+
 	// Info returns the full set of meta info and capabilities for a specific
 	// device.
 	Info(string) (*scheme.Info, error)
 
+	// InfoContext is like Info, but uses the given context.
+	InfoContext(context.Context, string) (*scheme.Info, error)
+
 	// Read returns data from devices which match the set of provided tags
 	// using ReadOptions.
 	Read(scheme.ReadOptions) ([]*scheme.Read, error)
 
+	// ReadContext is like Read, but uses the given context.
+	ReadContext(context.Context, scheme.ReadOptions) ([]*scheme.Read, error)
+
 	// ReadDevice returns data from a specific device.
 	// It is the same as Read() where the label matches the device id tag
 	// specified in ReadOptions.
 	ReadDevice(string) ([]*scheme.Read, error)
 
+	// ReadDeviceContext is like ReadDevice, but uses the given context.
+	ReadDeviceContext(context.Context, string) ([]*scheme.Read, error)
+
 	// ReadCache returns cached reading data from the registered plugins.
 	ReadCache(scheme.ReadCacheOptions, chan<- *scheme.Read) error
 
+	// ReadCacheContext is like ReadCache, but uses the given context.
+	ReadCacheContext(context.Context, scheme.ReadCacheOptions, chan<- *scheme.Read) error
+
 	// ReadStream returns a stream of current reading data from the
 	// registered plugins.
 	ReadStream(scheme.ReadStreamOptions, chan<- *scheme.Read, chan struct{}) error
 
+	// ReadStreamContext is like ReadStream, but uses the given context. The
+	// stream is terminated when either the stop channel is closed or the
+	// context is done.
+	ReadStreamContext(context.Context, scheme.ReadStreamOptions, chan<- *scheme.Read, chan struct{}) error
+
 	// WriteAsync writes data to a device, in an asynchronous manner.
 	WriteAsync(string, []scheme.WriteData) ([]*scheme.Write, error)
 
+	// WriteAsyncContext is like WriteAsync, but uses the given context.
+	WriteAsyncContext(context.Context, string, []scheme.WriteData) ([]*scheme.Write, error)
+
 	// WriteSync writes data to a device, waiting for the write to complete.
 	WriteSync(string, []scheme.WriteData) ([]*scheme.Transaction, error)
 
+	// WriteSyncContext is like WriteSync, but uses the given context.
+	WriteSyncContext(context.Context, string, []scheme.WriteData) ([]*scheme.Transaction, error)
+
 	// Transactions returns the sorted list of all cached transaction IDs.
 	Transactions() ([]string, error)
 
+	// TransactionsContext is like Transactions, but uses the given context.
+	TransactionsContext(context.Context) ([]string, error)
+
 	// Transaction returns the state and status of a write transaction.
 	Transaction(string) (*scheme.Transaction, error)
 
+	// TransactionContext is like Transaction, but uses the given context.
+	TransactionContext(context.Context, string) (*scheme.Transaction, error)
+
 	// GetOptions returns the current config options of the client.
 	GetOptions() *Options
 
@@ -78,6 +138,10 @@ type Client interface {
 	// client. Calling this method on a HTTP Client will have no effect.
 	Open() error
 
+	// OpenContext is like Open, but uses the given context, which bounds the
+	// websocket handshake.
+	OpenContext(context.Context) error
+
 	// Close closes the websocket connection between the client and Synse Server.
 	// It is only applicable for a WebSocket client in a sense that, one must
 	// close the connection after finish using it. Calling this method on a
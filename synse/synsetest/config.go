@@ -0,0 +1,148 @@
+package synsetest
+
+// config.go defines the config of the fake server's model.
+
+import (
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+	"gopkg.in/yaml.v3"
+)
+
+// Default values of the config.
+const (
+	defaultVersion            = "3.0.0"
+	defaultAPIVersion         = "v3"
+	defaultNamespace          = "default"
+	defaultTransactionStep    = 100 * time.Millisecond
+	defaultTransactionTimeout = 30 * time.Second
+	healthOK                  = "OK"
+)
+
+// Config is the model served by the fake server.
+type Config struct {
+	// Version is the version of Synse Server reported by the `/version`
+	// endpoint. It defaults to 3.0.0.
+	Version string `yaml:"version"`
+
+	// APIVersion is the API version of Synse Server reported by the
+	// `/version` endpoint. It defaults to v3.
+	APIVersion string `yaml:"api_version"`
+
+	// Server is the config reported by the `/config` endpoint.
+	Server scheme.Config `yaml:"server"`
+
+	// Plugins holds the plugins which manage the devices.
+	Plugins []Plugin `yaml:"plugins"`
+
+	// Devices holds the devices.
+	Devices []Device `yaml:"devices"`
+
+	// TransactionStep specifies how long a write transaction stays in each
+	// of its pending and writing states before it finishes. It defaults
+	// to 100ms.
+	TransactionStep time.Duration `yaml:"transaction_step"`
+
+	// TransactionTimeout specifies the timeout reported for write
+	// transactions. It defaults to 30s.
+	TransactionTimeout time.Duration `yaml:"transaction_timeout"`
+
+	// OnWrite, if set, is called for every write. An error fails the write
+	// transaction, with the error as its message. It is not available from
+	// YAML.
+	OnWrite func(device string, data scheme.WriteData) error `yaml:"-"`
+}
+
+// Plugin is a plugin of the fake server.
+type Plugin struct {
+	ID          string                `yaml:"id"`
+	Name        string                `yaml:"name"`
+	Description string                `yaml:"description"`
+	Maintainer  string                `yaml:"maintainer"`
+	Tag         string                `yaml:"tag"`
+	VCS         string                `yaml:"vcs"`
+	Version     scheme.VersionOptions `yaml:"version"`
+	Network     scheme.NetworkOptions `yaml:"network"`
+
+	// Inactive specifies whether the plugin is inactive.
+	Inactive bool `yaml:"inactive"`
+
+	// Health specifies the health status of the plugin, either OK or
+	// FAILING. It defaults to OK.
+	Health string `yaml:"health"`
+
+	// Checks holds the health checks of the plugin.
+	Checks []scheme.CheckOptions `yaml:"checks"`
+}
+
+// Device is a device of the fake server.
+type Device struct {
+	ID        string            `yaml:"id"`
+	Alias     string            `yaml:"alias"`
+	Type      string            `yaml:"type"`
+	Info      string            `yaml:"info"`
+	Plugin    string            `yaml:"plugin"`
+	SortIndex int               `yaml:"sort_index"`
+	Metadata  map[string]string `yaml:"metadata"`
+
+	// Tags holds the tags of the device, in the `[namespace/][annotation:]label`
+	// format. A tag without a namespace is in the default namespace. The
+	// system tags for the device ID and type are added automatically.
+	Tags []string `yaml:"tags"`
+
+	// Actions holds the write actions supported by the device. A device
+	// without actions is read-only.
+	Actions []string `yaml:"actions"`
+
+	// Readings holds the current readings of the device.
+	Readings []Reading `yaml:"readings"`
+}
+
+// Reading is a reading of a device.
+type Reading struct {
+	Type    string                 `yaml:"type"`
+	Value   interface{}            `yaml:"value"`
+	Unit    scheme.UnitOptions     `yaml:"unit"`
+	Context map[string]interface{} `yaml:"context"`
+}
+
+// ParseConfig parses a config from YAML.
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, errors.Wrap(err, "failed to parse config")
+	}
+	return cfg, nil
+}
+
+// LoadConfig loads a config from a YAML file.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, errors.Wrap(err, "failed to read config")
+	}
+	return ParseConfig(data)
+}
+
+// setDefaults sets the default values of unset config fields.
+func (c *Config) setDefaults() {
+	if c.Version == "" {
+		c.Version = defaultVersion
+	}
+	if c.APIVersion == "" {
+		c.APIVersion = defaultAPIVersion
+	}
+	if c.TransactionStep == 0 {
+		c.TransactionStep = defaultTransactionStep
+	}
+	if c.TransactionTimeout == 0 {
+		c.TransactionTimeout = defaultTransactionTimeout
+	}
+	for i := range c.Plugins {
+		if c.Plugins[i].Health == "" {
+			c.Plugins[i].Health = healthOK
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// Package synsetest provides an in-process fake Synse Server for testing
+// integrations with the Synse v3 API.
+//
+// The fake server serves both the HTTP and the WebSocket API from a shared
+// model of plugins, devices and their readings, defined either in Go or in
+// YAML. Writes create transactions whose status moves from pending, through
+// writing, to done (or error) over time, and readings can be changed while
+// the server is running, which is reflected in reads, the reading cache and
+// read streams alike.
+//
+//	server := synsetest.NewServer(synsetest.Config{
+//		Devices: []synsetest.Device{{
+//			ID:   "dev-1",
+//			Type: "temperature",
+//			Readings: []synsetest.Reading{{Type: "temperature", Value: 20.5}},
+//		}},
+//	})
+//	defer server.Close()
+//
+//	client, err := synse.NewHTTPClientV3(&synse.Options{
+//		Address: server.Address,
+//	})
+package synsetest
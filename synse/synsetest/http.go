@@ -0,0 +1,199 @@
+package synsetest
+
+// http.go serves the HTTP API of the fake server.
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.model.status())
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.model.version())
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.model.serverConfig())
+}
+
+func (s *Server) handlePlugins(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.model.pluginList())
+}
+
+func (s *Server) handlePlugin(w http.ResponseWriter, r *http.Request) {
+	plugin, e := s.model.plugin(pathParam(r, "/v3/plugin/"))
+	writeResult(w, plugin, e)
+}
+
+func (s *Server) handlePluginHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.model.pluginHealth())
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	writeJSON(w, s.model.scan(scheme.ScanOptions{
+		NS:    q.Get("ns"),
+		Tags:  q["tags"],
+		Force: q.Get("force") == "true",
+		Sort:  splitValues(q["sort"]),
+	}))
+}
+
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	writeJSON(w, s.model.tagList(scheme.TagsOptions{
+		NS:  splitValues(q["ns"]),
+		IDs: q.Get("ids") == "true",
+	}))
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	info, e := s.model.info(pathParam(r, "/v3/info/"))
+	writeResult(w, info, e)
+}
+
+func (s *Server) handleRead(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	writeJSON(w, s.model.read(scheme.ReadOptions{
+		NS:   q.Get("ns"),
+		Tags: q["tags"],
+	}))
+}
+
+func (s *Server) handleReadDevice(w http.ResponseWriter, r *http.Request) {
+	reads, e := s.model.readDevice(pathParam(r, "/v3/read/"))
+	writeResult(w, reads, e)
+}
+
+func (s *Server) handleReadCache(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	reads, e := s.model.readCache(scheme.ReadCacheOptions{
+		Start: q.Get("start"),
+		End:   q.Get("end"),
+	})
+	if e != nil {
+		writeError(w, e)
+		return
+	}
+
+	// The reading cache is streamed as a sequence of readings.
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	for _, read := range reads {
+		if err := enc.Encode(read); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handleWrite(w http.ResponseWriter, r *http.Request) {
+	txns, e := s.write(r, "/v3/write/")
+	if e != nil {
+		writeError(w, e)
+		return
+	}
+	writeJSON(w, s.model.writeInfo(txns))
+}
+
+func (s *Server) handleWriteWait(w http.ResponseWriter, r *http.Request) {
+	txns, e := s.write(r, "/v3/write/wait/")
+	if e != nil {
+		writeError(w, e)
+		return
+	}
+
+	out, err := s.waitTransactions(r, txns)
+	if err != nil {
+		return
+	}
+	writeJSON(w, out)
+}
+
+// write creates the write transactions of a write request.
+func (s *Server) write(r *http.Request, prefix string) ([]*transaction, *scheme.Error) {
+	if r.Method != http.MethodPost {
+		return nil, newError(http.StatusMethodNotAllowed, "method %s is not allowed", r.Method)
+	}
+
+	var data []scheme.WriteData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		return nil, newError(http.StatusBadRequest, "invalid write data: %v", err)
+	}
+	return s.model.write(pathParam(r, prefix), data)
+}
+
+// waitTransactions waits for the transactions to finish and returns their
+// final status. It fails if the request is cancelled first.
+func (s *Server) waitTransactions(r *http.Request, txns []*transaction) ([]*scheme.Transaction, error) {
+	timer := time.NewTimer(time.Until(s.model.finished(txns)))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-r.Context().Done():
+		return nil, r.Context().Err()
+	}
+
+	now := time.Now()
+	out := make([]*scheme.Transaction, len(txns))
+	for i, t := range txns {
+		out[i] = s.model.transactionStatus(t, now)
+	}
+	return out, nil
+}
+
+func (s *Server) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.model.transactionList())
+}
+
+func (s *Server) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	txn, e := s.model.transaction(pathParam(r, "/v3/transaction/"))
+	writeResult(w, txn, e)
+}
+
+// pathParam returns the trailing path parameter of the request.
+func pathParam(r *http.Request, prefix string) string {
+	return strings.TrimPrefix(r.URL.Path, prefix)
+}
+
+// splitValues splits comma separated query parameter values.
+func splitValues(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, e := range strings.Split(v, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				out = append(out, e)
+			}
+		}
+	}
+	return out
+}
+
+// writeResult writes the response, or the error response if it is set.
+func writeResult(w http.ResponseWriter, v interface{}, e *scheme.Error) {
+	if e != nil {
+		writeError(w, e)
+		return
+	}
+	writeJSON(w, v)
+}
+
+// writeJSON writes a successful JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response.
+func writeError(w http.ResponseWriter, e *scheme.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.HTTPCode)
+	_ = json.NewEncoder(w).Encode(e)
+}
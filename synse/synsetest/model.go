@@ -0,0 +1,795 @@
+package synsetest
+
+// model.go holds the state of the fake server, shared by its HTTP and
+// WebSocket APIs.
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// model is the state of the fake server. Its methods return the response
+// schemes of the API, or the error response to send.
+type model struct {
+	// config is the config of the model.
+	config Config
+
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// plugins holds the plugins, in config order.
+	plugins []Plugin
+
+	// devices holds the devices, in config order.
+	devices []*device
+
+	// byID holds the devices keyed by ID.
+	byID map[string]*device
+
+	// history holds every reading of the devices, for the reading cache.
+	history []*scheme.Read
+
+	// transactions holds the write transactions keyed by ID.
+	transactions map[string]*transaction
+
+	// counter is used to generate transaction IDs.
+	counter int
+
+	// streams holds the active read streams.
+	streams map[*stream]struct{}
+}
+
+// device is a device of the model.
+type device struct {
+	Device
+
+	// tags holds the parsed tags of the device, including its system tags.
+	tags []tag
+
+	// readings holds the current readings of the device.
+	readings []*scheme.Read
+}
+
+// transaction is a write transaction of the model.
+type transaction struct {
+	id      string
+	device  string
+	data    scheme.WriteData
+	created time.Time
+
+	// err fails the transaction once it finishes.
+	err error
+}
+
+// stream is an active read stream, which receives the readings of the devices
+// it selects as they change.
+type stream struct {
+	// ids and groups select the devices of the stream. If both are empty,
+	// all devices are selected.
+	ids    []string
+	groups [][]tag
+
+	// send sends a reading to the stream.
+	send func(*scheme.Read)
+}
+
+// newModel creates a model from the config.
+func newModel(cfg Config) *model {
+	cfg.Plugins = append([]Plugin(nil), cfg.Plugins...)
+	cfg.setDefaults()
+
+	m := &model{
+		config:       cfg,
+		plugins:      cfg.Plugins,
+		byID:         make(map[string]*device),
+		transactions: make(map[string]*transaction),
+		streams:      make(map[*stream]struct{}),
+	}
+
+	ts := timestamp(time.Now())
+	for _, d := range cfg.Devices {
+		dev := &device{Device: d}
+		for _, t := range d.Tags {
+			dev.tags = append(dev.tags, parseTag(t, defaultNamespace))
+		}
+		dev.tags = append(dev.tags,
+			tag{namespace: "system", annotation: "id", label: d.ID},
+			tag{namespace: "system", annotation: "type", label: d.Type},
+		)
+		for _, r := range d.Readings {
+			read := dev.newRead(r, ts)
+			dev.readings = append(dev.readings, read)
+			m.history = append(m.history, read)
+		}
+
+		m.devices = append(m.devices, dev)
+		m.byID[d.ID] = dev
+	}
+	return m
+}
+
+// newRead creates a reading of the device.
+func (d *device) newRead(r Reading, ts string) *scheme.Read {
+	return &scheme.Read{
+		Device:     d.ID,
+		DeviceType: d.Type,
+		DeviceInfo: d.Info,
+		Type:       r.Type,
+		Value:      r.Value,
+		Timestamp:  ts,
+		Unit:       r.Unit,
+		Context:    r.Context,
+	}
+}
+
+// tagStrings returns the tags of the device as strings.
+func (d *device) tagStrings() []string {
+	out := make([]string, len(d.tags))
+	for i, t := range d.tags {
+		out[i] = t.String()
+	}
+	return out
+}
+
+// hasTags reports whether the device has all of the given tags.
+func (d *device) hasTags(tags []tag) bool {
+	for _, t := range tags {
+		found := false
+		for _, dt := range d.tags {
+			if t == dt {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// tag is a parsed device tag.
+type tag struct {
+	namespace  string
+	annotation string
+	label      string
+}
+
+// parseTag parses a tag in the `[namespace/][annotation:]label` format. A tag
+// without a namespace is in the given namespace.
+func parseTag(s, namespace string) tag {
+	t := tag{namespace: namespace}
+	if i := strings.Index(s, "/"); i >= 0 {
+		t.namespace, s = s[:i], s[i+1:]
+	}
+	if i := strings.Index(s, ":"); i >= 0 {
+		t.annotation, s = s[:i], s[i+1:]
+	}
+	t.label = s
+	return t
+}
+
+// String returns the tag in its string format.
+func (t tag) String() string {
+	if t.annotation == "" {
+		return t.namespace + "/" + t.label
+	}
+	return t.namespace + "/" + t.annotation + ":" + t.label
+}
+
+// parseGroups parses the tag groups of a request. Each group is a comma
+// separated list of tags, which a device must all have to be selected by the
+// group.
+func parseGroups(groups []string, namespace string) [][]tag {
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	var out [][]tag
+	for _, g := range groups {
+		var tags []tag
+		for _, t := range strings.Split(g, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tags = append(tags, parseTag(t, namespace))
+			}
+		}
+		if len(tags) > 0 {
+			out = append(out, tags)
+		}
+	}
+	return out
+}
+
+// selects reports whether the device is selected by any of the tag groups. No
+// groups select all devices.
+func selects(d *device, groups [][]tag) bool {
+	if len(groups) == 0 {
+		return true
+	}
+	for _, g := range groups {
+		if d.hasTags(g) {
+			return true
+		}
+	}
+	return false
+}
+
+// timestamp formats a time the way Synse Server does.
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// newError creates an error response.
+func newError(code int, format string, a ...interface{}) *scheme.Error {
+	return &scheme.Error{
+		HTTPCode:    code,
+		Description: strings.ToLower(http.StatusText(code)),
+		Timestamp:   timestamp(time.Now()),
+		Context:     fmt.Sprintf(format, a...),
+	}
+}
+
+// status returns the status of the server.
+func (m *model) status() *scheme.Status {
+	return &scheme.Status{
+		Status:    "ok",
+		Timestamp: timestamp(time.Now()),
+	}
+}
+
+// version returns the version of the server.
+func (m *model) version() *scheme.Version {
+	return &scheme.Version{
+		Version:    m.config.Version,
+		APIVersion: m.config.APIVersion,
+	}
+}
+
+// serverConfig returns the config of the server.
+func (m *model) serverConfig() *scheme.Config {
+	cfg := m.config.Server
+	return &cfg
+}
+
+// pluginList returns the summary of all plugins.
+func (m *model) pluginList() []*scheme.PluginMeta {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := []*scheme.PluginMeta{}
+	for _, p := range m.plugins {
+		meta := pluginMeta(p)
+		out = append(out, &meta)
+	}
+	return out
+}
+
+// pluginMeta returns the metainfo of a plugin.
+func pluginMeta(p Plugin) scheme.PluginMeta {
+	return scheme.PluginMeta{
+		Active:      !p.Inactive,
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Maintainer:  p.Maintainer,
+		Tag:         p.Tag,
+		VCS:         p.VCS,
+		Version:     p.Version,
+	}
+}
+
+// plugin returns the info of a plugin.
+func (m *model) plugin(id string) (*scheme.Plugin, *scheme.Error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.plugins {
+		if p.ID == id {
+			return &scheme.Plugin{
+				PluginMeta: pluginMeta(p),
+				Network:    p.Network,
+				Health: scheme.HealthOptions{
+					Timestamp: timestamp(time.Now()),
+					Status:    p.Health,
+					Checks:    p.Checks,
+				},
+			}, nil
+		}
+	}
+	return nil, newError(http.StatusNotFound, "plugin %s not found", id)
+}
+
+// pluginHealth returns the summary of the health of all plugins.
+func (m *model) pluginHealth() *scheme.PluginHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := &scheme.PluginHealth{
+		Status:    "healthy",
+		Updated:   timestamp(time.Now()),
+		Healthy:   []string{},
+		Unhealthy: []string{},
+	}
+	for _, p := range m.plugins {
+		if p.Health == healthOK {
+			out.Healthy = append(out.Healthy, p.ID)
+		} else {
+			out.Unhealthy = append(out.Unhealthy, p.ID)
+			out.Status = "unhealthy"
+		}
+		if p.Inactive {
+			out.Inactive++
+		} else {
+			out.Active++
+		}
+	}
+	return out
+}
+
+// setPluginHealth sets the health status of a plugin.
+func (m *model) setPluginHealth(id, health string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.plugins {
+		if m.plugins[i].ID == id {
+			m.plugins[i].Health = health
+			return true
+		}
+	}
+	return false
+}
+
+// scan returns the summary of the devices selected by the options.
+func (m *model) scan(opts scheme.ScanOptions) []*scheme.Scan {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	groups := parseGroups(opts.Tags, opts.NS)
+	var devices []*device
+	for _, d := range m.devices {
+		if selects(d, groups) {
+			devices = append(devices, d)
+		}
+	}
+
+	keys := opts.Sort
+	if len(keys) == 0 {
+		keys = []string{"plugin", "sort_index", "id"}
+	}
+	sort.SliceStable(devices, func(i, j int) bool {
+		return less(devices[i], devices[j], keys)
+	})
+
+	out := []*scheme.Scan{}
+	for _, d := range devices {
+		metadata := make(map[string]interface{}, len(d.Metadata))
+		for k, v := range d.Metadata {
+			metadata[k] = v
+		}
+		out = append(out, &scheme.Scan{
+			ID:       d.ID,
+			Alias:    d.Alias,
+			Info:     d.Info,
+			Type:     d.Type,
+			Plugin:   d.Plugin,
+			Tags:     d.tagStrings(),
+			Metadata: metadata,
+		})
+	}
+	return out
+}
+
+// less reports whether a device sorts before another one by the given keys.
+func less(a, b *device, keys []string) bool {
+	for _, k := range keys {
+		var x, y string
+		switch strings.TrimSpace(k) {
+		case "plugin":
+			x, y = a.Plugin, b.Plugin
+		case "sort_index":
+			if a.SortIndex != b.SortIndex {
+				return a.SortIndex < b.SortIndex
+			}
+			continue
+		case "id":
+			x, y = a.ID, b.ID
+		case "type":
+			x, y = a.Type, b.Type
+		case "info":
+			x, y = a.Info, b.Info
+		}
+		if x != y {
+			return x < y
+		}
+	}
+	return false
+}
+
+// tagList returns the tags of all devices in the given namespaces. Device ID
+// tags are only included if ids is set.
+func (m *model) tagList(opts scheme.TagsOptions) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	namespaces := map[string]bool{}
+	for _, ns := range opts.NS {
+		for _, n := range strings.Split(ns, ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				namespaces[n] = true
+			}
+		}
+	}
+	if len(namespaces) == 0 {
+		namespaces[defaultNamespace] = true
+	}
+
+	seen := map[string]bool{}
+	out := []string{}
+	for _, d := range m.devices {
+		for _, t := range d.tags {
+			if t.namespace == "system" && t.annotation == "id" {
+				if !opts.IDs {
+					continue
+				}
+			} else if !namespaces[t.namespace] {
+				continue
+			}
+			if s := t.String(); !seen[s] {
+				seen[s] = true
+				out = append(out, s)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// info returns the info of a device.
+func (m *model) info(id string) (*scheme.Info, *scheme.Error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.byID[id]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "device %s not found", id)
+	}
+
+	mode := "r"
+	if len(d.Actions) > 0 {
+		mode = "rw"
+	}
+	outputs := []scheme.OutputOptions{}
+	for _, r := range d.readings {
+		outputs = append(outputs, scheme.OutputOptions{
+			Name: r.Type,
+			Type: r.Type,
+			Unit: r.Unit,
+		})
+	}
+
+	return &scheme.Info{
+		Timestamp: timestamp(time.Now()),
+		ID:        d.ID,
+		Alias:     d.Alias,
+		Type:      d.Type,
+		Metadata:  d.Metadata,
+		Plugin:    d.Plugin,
+		Info:      d.Info,
+		SortIndex: d.SortIndex,
+		Tags:      d.tagStrings(),
+		Capabilities: scheme.CapabilitiesOptions{
+			Mode: mode,
+			Read: map[string]string{},
+			Write: scheme.WriteOptions{
+				Actions: d.Actions,
+			},
+		},
+		Outputs: outputs,
+	}, nil
+}
+
+// read returns the current readings of the devices selected by the options.
+func (m *model) read(opts scheme.ReadOptions) []*scheme.Read {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	groups := parseGroups(opts.Tags, opts.NS)
+	out := []*scheme.Read{}
+	for _, d := range m.devices {
+		if selects(d, groups) {
+			out = append(out, copyReads(d.readings)...)
+		}
+	}
+	return out
+}
+
+// readDevice returns the current readings of a device.
+func (m *model) readDevice(id string) ([]*scheme.Read, *scheme.Error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.byID[id]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "device %s not found", id)
+	}
+	return copyReads(d.readings), nil
+}
+
+// readCache returns the cached readings within the bounds of the options.
+func (m *model) readCache(opts scheme.ReadCacheOptions) ([]*scheme.Read, *scheme.Error) {
+	var start, end time.Time
+	var err error
+	if opts.Start != "" {
+		if start, err = time.Parse(time.RFC3339Nano, opts.Start); err != nil {
+			return nil, newError(http.StatusBadRequest, "invalid start timestamp %q", opts.Start)
+		}
+	}
+	if opts.End != "" {
+		if end, err = time.Parse(time.RFC3339Nano, opts.End); err != nil {
+			return nil, newError(http.StatusBadRequest, "invalid end timestamp %q", opts.End)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := []*scheme.Read{}
+	for _, r := range m.history {
+		ts, _ := time.Parse(time.RFC3339Nano, r.Timestamp)
+		if (!start.IsZero() && ts.Before(start)) || (!end.IsZero() && ts.After(end)) {
+			continue
+		}
+		out = append(out, copyRead(r))
+	}
+	return out, nil
+}
+
+// copyReads returns copies of the readings, which are safe to use once the
+// model is unlocked.
+func copyReads(reads []*scheme.Read) []*scheme.Read {
+	out := make([]*scheme.Read, len(reads))
+	for i, r := range reads {
+		out[i] = copyRead(r)
+	}
+	return out
+}
+
+// copyRead returns a copy of the reading.
+func copyRead(r *scheme.Read) *scheme.Read {
+	c := *r
+	return &c
+}
+
+// setReading sets the current reading of the given type of a device, and
+// sends it to the read streams which select the device.
+func (m *model) setReading(id string, r Reading) bool {
+	m.mu.Lock()
+
+	d, ok := m.byID[id]
+	if !ok {
+		m.mu.Unlock()
+		return false
+	}
+
+	read := d.newRead(r, timestamp(time.Now()))
+	replaced := false
+	for i, cur := range d.readings {
+		if cur.Type == r.Type {
+			d.readings[i] = read
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		d.readings = append(d.readings, read)
+	}
+	m.history = append(m.history, read)
+
+	var streams []*stream
+	for s := range m.streams {
+		if s.selects(d) {
+			streams = append(streams, s)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, s := range streams {
+		s.send(copyRead(read))
+	}
+	return true
+}
+
+// selects reports whether the stream selects the device.
+func (s *stream) selects(d *device) bool {
+	if len(s.ids) == 0 && len(s.groups) == 0 {
+		return true
+	}
+	for _, id := range s.ids {
+		if id == d.ID {
+			return true
+		}
+	}
+	return len(s.groups) > 0 && selects(d, s.groups)
+}
+
+// subscribe adds a read stream for the options and returns the current
+// readings of the devices it selects.
+func (m *model) subscribe(opts scheme.ReadStreamOptions, send func(*scheme.Read)) (*stream, []*scheme.Read) {
+	s := &stream{
+		ids:    opts.Ids,
+		groups: parseGroups(opts.Tags, ""),
+		send:   send,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.streams[s] = struct{}{}
+
+	var out []*scheme.Read
+	for _, d := range m.devices {
+		if s.selects(d) {
+			out = append(out, copyReads(d.readings)...)
+		}
+	}
+	return s, out
+}
+
+// unsubscribe removes a read stream.
+func (m *model) unsubscribe(s *stream) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.streams, s)
+}
+
+// write creates a write transaction for each of the write data.
+func (m *model) write(id string, data []scheme.WriteData) ([]*transaction, *scheme.Error) {
+	m.mu.Lock()
+	d, ok := m.byID[id]
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, newError(http.StatusNotFound, "device %s not found", id)
+	}
+	if len(d.Actions) == 0 {
+		return nil, newError(http.StatusMethodNotAllowed, "device %s does not support writing", id)
+	}
+	if len(data) == 0 {
+		return nil, newError(http.StatusBadRequest, "no write data specified")
+	}
+	for _, w := range data {
+		if !contains(d.Actions, w.Action) {
+			return nil, newError(http.StatusBadRequest, "device %s does not support action %q", id, w.Action)
+		}
+	}
+
+	// The write hook is called without holding the lock, so it is free to
+	// change the model, e.g. to set the readings that result from the write.
+	errs := make([]error, len(data))
+	if m.config.OnWrite != nil {
+		for i, w := range data {
+			errs[i] = m.config.OnWrite(id, w)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	out := make([]*transaction, len(data))
+	for i, w := range data {
+		txnID := w.Transaction
+		if txnID == "" {
+			m.counter++
+			txnID = fmt.Sprintf("txn-%d", m.counter)
+		}
+		t := &transaction{
+			id:      txnID,
+			device:  id,
+			data:    w,
+			created: now,
+			err:     errs[i],
+		}
+		m.transactions[txnID] = t
+		out[i] = t
+	}
+	return out, nil
+}
+
+// writeInfo returns the write results of the transactions.
+func (m *model) writeInfo(txns []*transaction) []*scheme.Write {
+	out := make([]*scheme.Write, len(txns))
+	for i, t := range txns {
+		out[i] = &scheme.Write{
+			ID:      t.id,
+			Device:  t.device,
+			Context: t.data,
+			Timeout: m.config.TransactionTimeout.String(),
+		}
+	}
+	return out
+}
+
+// transactionStatus returns the status of a transaction at the given time.
+func (m *model) transactionStatus(t *transaction, now time.Time) *scheme.Transaction {
+	step := m.config.TransactionStep
+	elapsed := now.Sub(t.created)
+
+	out := &scheme.Transaction{
+		ID:      t.id,
+		Timeout: m.config.TransactionTimeout.String(),
+		Device:  t.device,
+		Context: t.data,
+		Created: timestamp(t.created),
+	}
+	switch {
+	case elapsed < step:
+		out.Status = scheme.TransactionPending
+		out.Updated = timestamp(t.created)
+	case elapsed < 2*step:
+		out.Status = scheme.TransactionWriting
+		out.Updated = timestamp(t.created.Add(step))
+	case t.err != nil:
+		out.Status = scheme.TransactionError
+		out.Updated = timestamp(t.created.Add(2 * step))
+		out.Message = t.err.Error()
+	default:
+		out.Status = scheme.TransactionDone
+		out.Updated = timestamp(t.created.Add(2 * step))
+	}
+	return out
+}
+
+// transactionList returns the sorted IDs of all transactions.
+func (m *model) transactionList() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := []string{}
+	for id := range m.transactions {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// transaction returns the status of a transaction.
+func (m *model) transaction(id string) (*scheme.Transaction, *scheme.Error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.transactions[id]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "transaction %s not found", id)
+	}
+	return m.transactionStatus(t, time.Now()), nil
+}
+
+// finished returns the time all of the transactions are finished.
+func (m *model) finished(txns []*transaction) time.Time {
+	var out time.Time
+	for _, t := range txns {
+		if f := t.created.Add(2 * m.config.TransactionStep); f.After(out) {
+			out = f
+		}
+	}
+	return out
+}
+
+// contains reports whether the list contains the string.
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
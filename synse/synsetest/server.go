@@ -0,0 +1,131 @@
+package synsetest
+
+// server.go provides the fake Synse Server.
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// Server is a fake Synse Server, serving the v3 HTTP and WebSocket APIs from
+// the same model.
+type Server struct {
+	// Address is the address of the server, in the `host:port` format, as
+	// used for the client options.
+	Address string
+
+	// server is the underlying test server.
+	server *httptest.Server
+
+	// model is the state served by the server.
+	model *model
+
+	// mu guards conns.
+	mu sync.Mutex
+
+	// conns holds the open websocket connections.
+	conns map[*websocket.Conn]struct{}
+}
+
+// NewServer starts a fake Synse Server serving the given config.
+func NewServer(cfg Config) *Server {
+	s := newServer(cfg)
+	s.server = httptest.NewServer(s.handler())
+	s.Address = s.server.Listener.Addr().String()
+	return s
+}
+
+// NewTLSServer starts a fake Synse Server serving the given config over TLS.
+// Its certificate is available from Certificate.
+func NewTLSServer(cfg Config) *Server {
+	s := newServer(cfg)
+	s.server = httptest.NewTLSServer(s.handler())
+	s.Address = s.server.Listener.Addr().String()
+	return s
+}
+
+// newServer creates a server for the config, without starting it.
+func newServer(cfg Config) *Server {
+	return &Server{
+		model: newModel(cfg),
+		conns: make(map[*websocket.Conn]struct{}),
+	}
+}
+
+// Certificate returns the certificate used by a TLS server.
+func (s *Server) Certificate() *x509.Certificate {
+	return s.server.Certificate()
+}
+
+// Close closes all websocket connections and shuts down the server.
+func (s *Server) Close() {
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.server.Close()
+}
+
+// SetReading sets the current reading of the given type of a device, adding
+// it if the device has no reading of that type yet. The reading is added to
+// the reading cache and sent to the read streams which select the device.
+func (s *Server) SetReading(device string, r Reading) error {
+	if !s.model.setReading(device, r) {
+		return errors.Errorf("device %s not found", device)
+	}
+	return nil
+}
+
+// SetPluginHealth sets the health status of a plugin, either OK or FAILING.
+func (s *Server) SetPluginHealth(plugin, health string) error {
+	if !s.model.setPluginHealth(plugin, health) {
+		return errors.Errorf("plugin %s not found", plugin)
+	}
+	return nil
+}
+
+// handler returns the handler for all routes of the server.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/test", s.handleStatus)
+	mux.HandleFunc("/version", s.handleVersion)
+	mux.HandleFunc("/v3/config", s.handleConfig)
+	mux.HandleFunc("/v3/plugin", s.handlePlugins)
+	mux.HandleFunc("/v3/plugin/", s.handlePlugin)
+	mux.HandleFunc("/v3/plugin/health", s.handlePluginHealth)
+	mux.HandleFunc("/v3/scan", s.handleScan)
+	mux.HandleFunc("/v3/tags", s.handleTags)
+	mux.HandleFunc("/v3/info/", s.handleInfo)
+	mux.HandleFunc("/v3/read", s.handleRead)
+	mux.HandleFunc("/v3/read/", s.handleReadDevice)
+	mux.HandleFunc("/v3/readcache", s.handleReadCache)
+	mux.HandleFunc("/v3/write/", s.handleWrite)
+	mux.HandleFunc("/v3/write/wait/", s.handleWriteWait)
+	mux.HandleFunc("/v3/transaction", s.handleTransactions)
+	mux.HandleFunc("/v3/transaction/", s.handleTransaction)
+	mux.HandleFunc("/v3/connect", s.handleConnect)
+
+	return mux
+}
+
+// track adds a websocket connection to be closed with the server.
+func (s *Server) track(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conns[conn] = struct{}{}
+}
+
+// untrack removes a closed websocket connection.
+func (s *Server) untrack(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+}
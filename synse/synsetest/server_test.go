@@ -0,0 +1,291 @@
+package synsetest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/synse"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+// newClients returns a HTTP and an open websocket client for the server.
+func newClients(t *testing.T, server *synsetest.Server) map[string]synse.Client {
+	httpClient, err := synse.NewHTTPClientV3(&synse.Options{
+		Address: server.Address,
+	})
+	assert.NoError(t, err)
+
+	wsClient, err := synse.NewWebSocketClientV3(&synse.Options{
+		Address: server.Address,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, wsClient.Open())
+	t.Cleanup(func() {
+		_ = wsClient.Close()
+	})
+
+	return map[string]synse.Client{
+		"http":      httpClient,
+		"websocket": wsClient,
+	}
+}
+
+// loadServer starts a server for the test config.
+func loadServer(t *testing.T) *synsetest.Server {
+	cfg, err := synsetest.LoadConfig("testdata/config.yaml")
+	assert.NoError(t, err)
+
+	server := synsetest.NewServer(cfg)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := synsetest.LoadConfig("testdata/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "3.0.1", cfg.Version)
+	assert.Equal(t, 20*time.Millisecond, cfg.TransactionStep)
+	assert.Equal(t, 5*time.Second, cfg.TransactionTimeout)
+	assert.Len(t, cfg.Plugins, 2)
+	assert.Len(t, cfg.Devices, 2)
+	assert.Equal(t, 20.5, cfg.Devices[0].Readings[0].Value)
+	assert.Equal(t, scheme.UnitOptions{Name: "celsius", Symbol: "C"}, cfg.Devices[0].Readings[0].Unit)
+	assert.Equal(t, []string{"state", "color"}, cfg.Devices[1].Actions)
+
+	_, err = synsetest.ParseConfig([]byte("devices: 1"))
+	assert.Error(t, err)
+}
+
+func TestServer_Meta(t *testing.T) {
+	server := loadServer(t)
+
+	for name, client := range newClients(t, server) {
+		status, err := client.Status()
+		assert.NoError(t, err, name)
+		assert.Equal(t, "ok", status.Status, name)
+
+		version, err := client.Version()
+		assert.NoError(t, err, name)
+		assert.Equal(t, &scheme.Version{Version: "3.0.1", APIVersion: "v3"}, version, name)
+
+		plugins, err := client.Plugins()
+		assert.NoError(t, err, name)
+		assert.Len(t, plugins, 2, name)
+		assert.True(t, plugins[0].Active, name)
+
+		plugin, err := client.Plugin("plugin-1")
+		assert.NoError(t, err, name)
+		assert.Equal(t, "emulator plugin", plugin.Name, name)
+		assert.Equal(t, "OK", plugin.Health.Status, name)
+
+		_, err = client.Plugin("plugin-3")
+		assert.True(t, synse.IsNotFound(err), name)
+
+		health, err := client.PluginHealth()
+		assert.NoError(t, err, name)
+		assert.Equal(t, "unhealthy", health.Status, name)
+		assert.Equal(t, []string{"plugin-1"}, health.Healthy, name)
+		assert.Equal(t, []string{"plugin-2"}, health.Unhealthy, name)
+		assert.Equal(t, 2, health.Active, name)
+	}
+
+	assert.NoError(t, server.SetPluginHealth("plugin-2", "OK"))
+	assert.Error(t, server.SetPluginHealth("plugin-3", "OK"))
+
+	for name, client := range newClients(t, server) {
+		health, err := client.PluginHealth()
+		assert.NoError(t, err, name)
+		assert.Equal(t, "healthy", health.Status, name)
+	}
+}
+
+func TestServer_Devices(t *testing.T) {
+	server := loadServer(t)
+
+	for name, client := range newClients(t, server) {
+		devices, err := client.Scan(scheme.ScanOptions{})
+		assert.NoError(t, err, name)
+		assert.Len(t, devices, 2, name)
+		// Sorted by plugin, sort index and ID.
+		assert.Equal(t, "led-1", devices[0].ID, name)
+		assert.Equal(t, "temp-1", devices[1].ID, name)
+		assert.Equal(t, []string{"default/rack:1", "vapor/sensor", "system/id:temp-1", "system/type:temperature"}, devices[1].Tags, name)
+
+		devices, err = client.Scan(scheme.ScanOptions{Tags: []string{"rack:1,vapor/sensor"}})
+		assert.NoError(t, err, name)
+		assert.Len(t, devices, 1, name)
+		assert.Equal(t, "temp-1", devices[0].ID, name)
+
+		devices, err = client.Scan(scheme.ScanOptions{Tags: []string{"system/type:led", "vapor/sensor"}})
+		assert.NoError(t, err, name)
+		assert.Len(t, devices, 2, name)
+
+		tags, err := client.Tags(scheme.TagsOptions{})
+		assert.NoError(t, err, name)
+		assert.Equal(t, []string{"default/rack:1"}, tags, name)
+
+		tags, err = client.Tags(scheme.TagsOptions{NS: []string{"vapor", "system"}, IDs: true})
+		assert.NoError(t, err, name)
+		assert.Equal(t, []string{
+			"system/id:led-1", "system/id:temp-1", "system/type:led", "system/type:temperature", "vapor/sensor",
+		}, tags, name)
+
+		info, err := client.Info("led-1")
+		assert.NoError(t, err, name)
+		assert.Equal(t, "rw", info.Capabilities.Mode, name)
+		assert.Equal(t, []string{"state", "color"}, info.Capabilities.Write.Actions, name)
+		assert.Len(t, info.Outputs, 2, name)
+
+		_, err = client.Info("led-2")
+		assert.True(t, synse.IsNotFound(err), name)
+	}
+}
+
+func TestServer_Read(t *testing.T) {
+	server := loadServer(t)
+
+	for name, client := range newClients(t, server) {
+		reads, err := client.Read(scheme.ReadOptions{Tags: []string{"vapor/sensor"}})
+		assert.NoError(t, err, name)
+		assert.Len(t, reads, 1, name)
+		v, err := reads[0].Float64Value()
+		assert.NoError(t, err, name)
+		assert.Equal(t, 20.5, v, name)
+		assert.Equal(t, "C", reads[0].Unit.Symbol, name)
+
+		reads, err = client.ReadDevice("led-1")
+		assert.NoError(t, err, name)
+		assert.Len(t, reads, 2, name)
+
+		_, err = client.ReadDevice("led-2")
+		assert.True(t, synse.IsNotFound(err), name)
+	}
+
+	assert.NoError(t, server.SetReading("temp-1", synsetest.Reading{Type: "temperature", Value: 21.0}))
+	assert.Error(t, server.SetReading("temp-2", synsetest.Reading{Type: "temperature", Value: 21.0}))
+
+	for name, client := range newClients(t, server) {
+		reads, err := client.ReadDevice("temp-1")
+		assert.NoError(t, err, name)
+		assert.Len(t, reads, 1, name)
+		assert.Equal(t, 21.0, reads[0].Value, name)
+
+		out := make(chan *scheme.Read, 10)
+		err = client.ReadCache(scheme.ReadCacheOptions{}, out)
+		assert.NoError(t, err, name)
+
+		var cached []*scheme.Read
+		for r := range out {
+			cached = append(cached, r)
+		}
+		assert.Len(t, cached, 4, name)
+	}
+}
+
+func TestServer_ReadStream(t *testing.T) {
+	for _, name := range []string{"http", "websocket"} {
+		// Each client gets its own server, as the stream changes readings.
+		server := loadServer(t)
+		client := newClients(t, server)[name]
+
+		out := make(chan *scheme.Read)
+		stop := make(chan struct{})
+		errs := make(chan error)
+		go func() {
+			errs <- client.ReadStream(scheme.ReadStreamOptions{Ids: []string{"temp-1"}}, out, stop)
+		}()
+
+		// The current reading comes first.
+		r := <-out
+		assert.Equal(t, "temp-1", r.Device, name)
+		assert.Equal(t, 20.5, r.Value, name)
+
+		assert.NoError(t, server.SetReading("temp-1", synsetest.Reading{Type: "temperature", Value: 22.5}))
+		assert.NoError(t, server.SetReading("led-1", synsetest.Reading{Type: "state", Value: "on"}))
+
+		r = <-out
+		assert.Equal(t, "temp-1", r.Device, name)
+		assert.Equal(t, 22.5, r.Value, name)
+
+		close(stop)
+		assert.NoError(t, <-errs, name)
+	}
+}
+
+func TestServer_Write(t *testing.T) {
+	var written []string
+	cfg, err := synsetest.LoadConfig("testdata/config.yaml")
+	assert.NoError(t, err)
+	cfg.OnWrite = func(device string, data scheme.WriteData) error {
+		written = append(written, device+":"+data.Action+":"+data.Data)
+		return nil
+	}
+
+	server := synsetest.NewServer(cfg)
+	defer server.Close()
+
+	for name, client := range newClients(t, server) {
+		writes, err := client.WriteAsync("led-1", []scheme.WriteData{{Action: "state", Data: "on"}})
+		assert.NoError(t, err, name)
+		assert.Len(t, writes, 1, name)
+		assert.Equal(t, "5s", writes[0].Timeout, name)
+
+		txn, err := client.Transaction(writes[0].ID)
+		assert.NoError(t, err, name)
+		assert.Equal(t, scheme.TransactionPending, txn.Status, name)
+
+		var statuses []string
+		txns, err := synse.WaitTransactions(context.Background(), client, writes, &synse.WaitOptions{
+			WaitTime:    5 * time.Millisecond,
+			MaxWaitTime: 5 * time.Millisecond,
+			OnChange: func(txn *scheme.Transaction) {
+				statuses = append(statuses, txn.Status)
+			},
+		})
+		assert.NoError(t, err, name)
+		assert.Equal(t, scheme.TransactionDone, txns[0].Status, name)
+		assert.Equal(t, []string{"pending", "writing", "done"}, statuses, name)
+
+		txns, err = client.WriteSync("led-1", []scheme.WriteData{{Action: "color", Data: "ffffff"}})
+		assert.NoError(t, err, name)
+		assert.Len(t, txns, 1, name)
+		assert.Equal(t, scheme.TransactionDone, txns[0].Status, name)
+
+		ids, err := client.Transactions()
+		assert.NoError(t, err, name)
+		assert.Contains(t, ids, txns[0].ID, name)
+
+		_, err = client.WriteAsync("temp-1", []scheme.WriteData{{Action: "state", Data: "on"}})
+		assert.Error(t, err, name)
+
+		_, err = client.WriteAsync("led-1", []scheme.WriteData{{Action: "blink"}})
+		assert.True(t, synse.IsBadRequest(err), name)
+	}
+
+	assert.Equal(t, []string{"led-1:state:on", "led-1:color:ffffff", "led-1:state:on", "led-1:color:ffffff"}, written)
+}
+
+func TestServer_WriteError(t *testing.T) {
+	server := synsetest.NewServer(synsetest.Config{
+		TransactionStep: 10 * time.Millisecond,
+		Devices: []synsetest.Device{{
+			ID:      "fan-1",
+			Type:    "fan",
+			Actions: []string{"speed"},
+		}},
+		OnWrite: func(string, scheme.WriteData) error {
+			return assert.AnError
+		},
+	})
+	defer server.Close()
+
+	for name, client := range newClients(t, server) {
+		txns, err := client.WriteSync("fan-1", []scheme.WriteData{{Action: "speed", Data: "100"}})
+		assert.NoError(t, err, name)
+		assert.Equal(t, scheme.TransactionError, txns[0].Status, name)
+		assert.Equal(t, assert.AnError.Error(), txns[0].Message, name)
+	}
+}
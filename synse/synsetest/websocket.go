@@ -0,0 +1,282 @@
+package synsetest
+
+// websocket.go serves the WebSocket API of the fake server.
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// writeTimeout bounds writes to a websocket connection, so a client which
+// stopped reading does not block the server.
+const writeTimeout = 5 * time.Second
+
+// upgrader upgrades HTTP connections to websocket connections.
+var upgrader = websocket.Upgrader{}
+
+// request is a request event, whose data is decoded once its event is known.
+type request struct {
+	ID    uint64          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// wsConn is a websocket connection of the server.
+type wsConn struct {
+	server *Server
+	conn   *websocket.Conn
+
+	// ctx is cancelled once the connection is closed.
+	ctx context.Context
+
+	// writeMu serializes writes to the connection.
+	writeMu sync.Mutex
+
+	// mu guards streams.
+	mu sync.Mutex
+
+	// streams holds the read streams of the connection.
+	streams []*stream
+}
+
+func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s.track(conn)
+	defer s.untrack(conn)
+	defer conn.Close() // nolint: errcheck
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &wsConn{
+		server: s,
+		conn:   conn,
+		ctx:    ctx,
+	}
+	defer c.stopStreams()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.send(0, "response/error", newError(http.StatusBadRequest, "invalid request: %v", err))
+			continue
+		}
+
+		// Requests are handled concurrently, like Synse Server does, so
+		// their responses may come back in any order.
+		go c.handle(req)
+	}
+}
+
+// handle handles a request event and sends its response event.
+func (c *wsConn) handle(req request) {
+	m := c.server.model
+
+	switch req.Event {
+	case "request/status":
+		c.send(req.ID, "response/status", m.status())
+	case "request/version":
+		c.send(req.ID, "response/version", m.version())
+	case "request/config":
+		c.send(req.ID, "response/config", m.serverConfig())
+	case "request/plugins":
+		c.send(req.ID, "response/plugin_summary", m.pluginList())
+	case "request/plugin":
+		var data scheme.PluginData
+		if c.decode(req, &data) {
+			plugin, e := m.plugin(data.Plugin)
+			c.result(req.ID, "response/plugin_info", plugin, e)
+		}
+	case "request/plugin_health":
+		c.send(req.ID, "response/plugin_health", m.pluginHealth())
+	case "request/scan":
+		var data scheme.ScanOptions
+		if c.decode(req, &data) {
+			c.send(req.ID, "response/device_summary", m.scan(data))
+		}
+	case "request/tags":
+		var data scheme.TagsOptions
+		if c.decode(req, &data) {
+			c.send(req.ID, "response/tags", m.tagList(data))
+		}
+	case "request/info":
+		var data scheme.DeviceData
+		if c.decode(req, &data) {
+			info, e := m.info(data.Device)
+			c.result(req.ID, "response/device_info", info, e)
+		}
+	case "request/read":
+		var data scheme.ReadOptions
+		if c.decode(req, &data) {
+			c.send(req.ID, "response/reading", m.read(data))
+		}
+	case "request/read_device":
+		var data scheme.ReadDeviceData
+		if c.decode(req, &data) {
+			reads, e := m.readDevice(data.Device)
+			c.result(req.ID, "response/reading", reads, e)
+		}
+	case "request/read_cache":
+		var data scheme.ReadCacheOptions
+		if c.decode(req, &data) {
+			reads, e := m.readCache(data)
+			c.result(req.ID, "response/reading", reads, e)
+		}
+	case "request/read_stream":
+		var data scheme.ReadStreamOptions
+		if c.decode(req, &data) {
+			c.stream(req.ID, data)
+		}
+	case "request/write_async":
+		var data scheme.RequestWriteData
+		if c.decode(req, &data) {
+			txns, e := m.write(data.Device, data.Payload)
+			if e != nil {
+				c.sendError(req.ID, e)
+				return
+			}
+			c.send(req.ID, "response/transaction_info", m.writeInfo(txns))
+		}
+	case "request/write_sync":
+		var data scheme.RequestWriteData
+		if c.decode(req, &data) {
+			c.writeSync(req.ID, data)
+		}
+	case "request/transactions":
+		c.send(req.ID, "response/transaction_list", m.transactionList())
+	case "request/transaction":
+		var data scheme.WriteData
+		if c.decode(req, &data) {
+			txn, e := m.transaction(data.Transaction)
+			c.result(req.ID, "response/transaction_status", txn, e)
+		}
+	default:
+		c.sendError(req.ID, newError(http.StatusBadRequest, "unsupported request event %q", req.Event))
+	}
+}
+
+// decode decodes the data of a request event, sending an error response if it
+// is invalid.
+func (c *wsConn) decode(req request, v interface{}) bool {
+	if len(req.Data) == 0 || string(req.Data) == "null" {
+		return true
+	}
+	if err := json.Unmarshal(req.Data, v); err != nil {
+		c.sendError(req.ID, newError(http.StatusBadRequest, "invalid request data: %v", err))
+		return false
+	}
+	return true
+}
+
+// stream starts a read stream, or stops the read streams of the connection if
+// the stop option is set. The current readings of the selected devices are
+// sent right away, followed by every reading set afterwards.
+func (c *wsConn) stream(id uint64, opts scheme.ReadStreamOptions) {
+	if opts.Stop {
+		c.stopStreams()
+		return
+	}
+
+	// The stream is locked until the current readings are sent, so that they
+	// are not sent after a newer reading.
+	var mu sync.Mutex
+	mu.Lock()
+	defer mu.Unlock()
+
+	s, reads := c.server.model.subscribe(opts, func(r *scheme.Read) {
+		mu.Lock()
+		defer mu.Unlock()
+		c.send(id, "response/reading", r)
+	})
+
+	c.mu.Lock()
+	c.streams = append(c.streams, s)
+	c.mu.Unlock()
+
+	for _, r := range reads {
+		c.send(id, "response/reading", r)
+	}
+}
+
+// stopStreams stops the read streams of the connection.
+func (c *wsConn) stopStreams() {
+	c.mu.Lock()
+	streams := c.streams
+	c.streams = nil
+	c.mu.Unlock()
+
+	for _, s := range streams {
+		c.server.model.unsubscribe(s)
+	}
+}
+
+// writeSync creates write transactions and responds once they are finished.
+func (c *wsConn) writeSync(id uint64, data scheme.RequestWriteData) {
+	m := c.server.model
+
+	txns, e := m.write(data.Device, data.Payload)
+	if e != nil {
+		c.sendError(id, e)
+		return
+	}
+
+	timer := time.NewTimer(time.Until(m.finished(txns)))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-c.ctx.Done():
+		return
+	}
+
+	now := time.Now()
+	out := make([]*scheme.Transaction, len(txns))
+	for i, t := range txns {
+		out[i] = m.transactionStatus(t, now)
+	}
+	c.send(id, "response/transaction_status", out)
+}
+
+// result sends the response event, or the error response event if it is set.
+func (c *wsConn) result(id uint64, event string, data interface{}, e *scheme.Error) {
+	if e != nil {
+		c.sendError(id, e)
+		return
+	}
+	c.send(id, event, data)
+}
+
+// sendError sends an error response event.
+func (c *wsConn) sendError(id uint64, e *scheme.Error) {
+	c.send(id, "response/error", e)
+}
+
+// send sends a response event. A failed send means the connection is broken,
+// which ends the connection's read loop.
+func (c *wsConn) send(id uint64, event string, data interface{}) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_ = c.conn.WriteJSON(scheme.Response{
+		EventMeta: scheme.EventMeta{
+			ID:    id,
+			Event: event,
+		},
+		Data: data,
+	})
+}
@@ -0,0 +1,219 @@
+package synse
+
+// tls.go builds the TLS configuration shared by the clients.
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tlsVersions maps the names of the TLS versions to their values.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newTLSConfig creates the TLS configuration for the options.
+func newTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if opts.Config != nil {
+		cfg = opts.Config.Clone()
+	}
+
+	if opts.SkipVerify {
+		// NOTE - refer to #24. If not disable linting, a warning will happen:
+		// TLS InsecureSkipVerify may be true.,HIGH,LOW (gosec)
+		cfg.InsecureSkipVerify = true // nolint
+	}
+	if opts.ServerName != "" {
+		cfg.ServerName = opts.ServerName
+	}
+
+	if opts.MinVersion != "" {
+		v, ok := tlsVersions[opts.MinVersion]
+		if !ok {
+			return nil, errors.Errorf("unknown TLS version %q", opts.MinVersion)
+		}
+		cfg.MinVersion = v
+	}
+
+	if len(opts.CipherSuites) > 0 {
+		suites, err := cipherSuites(opts.CipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		cfg.CipherSuites = suites
+	}
+
+	if opts.CAFile != "" || opts.CAPEM != "" {
+		pool, err := caPool(opts)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		r, err := newCertReloader(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = nil
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate()
+		}
+	}
+
+	return cfg, nil
+}
+
+// unixTLSConfig returns the TLS configuration for a unix socket address. The
+// host of its URLs only stands in for the socket, so unless a server name is
+// set, the certificate of the server is verified without one: its chain has
+// to be trusted, whichever host names it is for.
+func unixTLSConfig(cfg *tls.Config) *tls.Config {
+	cfg = cfg.Clone()
+	if cfg.ServerName != "" || cfg.InsecureSkipVerify {
+		return cfg
+	}
+
+	verify := cfg.VerifyConnection
+	cfg.InsecureSkipVerify = true // nolint
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("tls: server did not present a certificate")
+		}
+		opts := x509.VerifyOptions{
+			Roots:         cfg.RootCAs,
+			Intermediates: x509.NewCertPool(),
+		}
+		if cfg.Time != nil {
+			opts.CurrentTime = cfg.Time()
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
+			return err
+		}
+		if verify != nil {
+			return verify(cs)
+		}
+		return nil
+	}
+	return cfg
+}
+
+// cipherSuites returns the IDs of the cipher suites with the given names.
+func cipherSuites(names []string) ([]uint16, error) {
+	ids := map[string]uint16{}
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		ids[s.Name] = s.ID
+	}
+
+	suites := make([]uint16, len(names))
+	for i, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			return nil, errors.Errorf("unknown cipher suite %q", name)
+		}
+		suites[i] = id
+	}
+	return suites, nil
+}
+
+// caPool creates the pool of the CA certificates of the options.
+func caPool(opts TLSOptions) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if opts.CAFile != "" {
+		data, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read CA file")
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.Errorf("no certificates found in CA file %s", opts.CAFile)
+		}
+	}
+	if opts.CAPEM != "" && !pool.AppendCertsFromPEM([]byte(opts.CAPEM)) {
+		return nil, errors.New("no certificates found in CA PEM")
+	}
+	return pool, nil
+}
+
+// certReloader holds the client certificate loaded from its files, and
+// reloads it when they change on disk.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// cert is the loaded certificate.
+	cert *tls.Certificate
+
+	// certMod and keyMod are the modification times of the files the
+	// certificate was loaded from.
+	certMod time.Time
+	keyMod  time.Time
+}
+
+// newCertReloader creates a certReloader, loading the certificate.
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if _, err := r.certificate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// certificate returns the certificate, reloading it first if its files
+// changed. While the files can not be loaded, e.g. when only one of them was
+// replaced yet, the certificate loaded last is returned.
+func (r *certReloader) certificate() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certMod, keyMod, err := r.modTimes()
+	if err == nil && r.cert != nil && certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod) {
+		return r.cert, nil
+	}
+
+	if err == nil {
+		var cert tls.Certificate
+		cert, err = tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err == nil {
+			r.cert = &cert
+			r.certMod, r.keyMod = certMod, keyMod
+			return r.cert, nil
+		}
+	}
+
+	if r.cert != nil {
+		return r.cert, nil
+	}
+	return nil, errors.Wrap(err, "failed to set client certificates")
+}
+
+// modTimes returns the modification times of the certificate files.
+func (r *certReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
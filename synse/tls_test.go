@@ -0,0 +1,157 @@
+package synse
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/synse/synsetest"
+)
+
+// writeKeyPair generates a self-signed certificate with the given common name
+// and writes it and its key to the files.
+func writeKeyPair(t *testing.T, name, certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+}
+
+// commonName returns the common name of a certificate.
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	c, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	return c.Subject.CommonName
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	base := &tls.Config{ServerName: "synse.local", MinVersion: tls.VersionTLS10}
+	cfg, err := newTLSConfig(TLSOptions{
+		Config:       base,
+		MinVersion:   "1.3",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		SkipVerify:   true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "synse.local", cfg.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, cfg.CipherSuites)
+	assert.True(t, cfg.InsecureSkipVerify)
+
+	// The given config is not changed.
+	assert.Equal(t, uint16(tls.VersionTLS10), base.MinVersion)
+	assert.False(t, base.InsecureSkipVerify)
+
+	cfg, err = newTLSConfig(TLSOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, &tls.Config{}, cfg)
+
+	_, err = newTLSConfig(TLSOptions{MinVersion: "1.4"})
+	assert.EqualError(t, err, `unknown TLS version "1.4"`)
+	_, err = newTLSConfig(TLSOptions{CipherSuites: []string{"TLS_NONE"}})
+	assert.EqualError(t, err, `unknown cipher suite "TLS_NONE"`)
+	_, err = newTLSConfig(TLSOptions{CAPEM: "not a certificate"})
+	assert.EqualError(t, err, "no certificates found in CA PEM")
+	_, err = newTLSConfig(TLSOptions{CAFile: "testdata/key.pem"})
+	assert.EqualError(t, err, "no certificates found in CA file testdata/key.pem")
+	_, err = newTLSConfig(TLSOptions{CertFile: "testdata/missing.pem", KeyFile: "testdata/key.pem"})
+	assert.Error(t, err)
+}
+
+func TestTLS_CA(t *testing.T) {
+	server := synsetest.NewTLSServer(synsetest.Config{})
+	defer server.Close()
+
+	ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, []byte(ca), 0600))
+
+	for _, newClient := range []func(*Options) (Client, error){NewHTTPClientV3, NewWebSocketClientV3} {
+		for _, tc := range []struct {
+			opts TLSOptions
+			ok   bool
+		}{
+			{opts: TLSOptions{}, ok: false},
+			{opts: TLSOptions{CAPEM: ca}, ok: true},
+			{opts: TLSOptions{CAFile: caFile, MinVersion: "1.2"}, ok: true},
+			// The test certificate is valid for example.com.
+			{opts: TLSOptions{CAFile: caFile, ServerName: "example.com"}, ok: true},
+			{opts: TLSOptions{CAFile: caFile, ServerName: "synse.local"}, ok: false},
+		} {
+			tc.opts.Enabled = true
+			client, err := newClient(&Options{
+				Address: server.Address,
+				HTTP: HTTPOptions{
+					Retry: RetryOptions{WaitTime: time.Millisecond, MaxWaitTime: time.Millisecond},
+				},
+				TLS: tc.opts,
+			})
+			assert.NoError(t, err)
+
+			err = client.Open()
+			if err == nil {
+				_, err = client.Status()
+				assert.NoError(t, client.Close())
+			}
+			assert.Equal(t, tc.ok, err == nil, "%+v: %v", tc.opts, err)
+		}
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeKeyPair(t, "first", certFile, keyFile)
+
+	r, err := newCertReloader(certFile, keyFile)
+	assert.NoError(t, err)
+	cert, err := r.certificate()
+	assert.NoError(t, err)
+	assert.Equal(t, "first", commonName(t, cert))
+
+	// A pair which does not match yet keeps the last certificate.
+	otherDir := t.TempDir()
+	writeKeyPair(t, "second", certFile, filepath.Join(otherDir, "key.pem"))
+	later := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(certFile, later, later))
+	cert, err = r.certificate()
+	assert.NoError(t, err)
+	assert.Equal(t, "first", commonName(t, cert))
+
+	// Once both files are rotated, the new certificate is loaded.
+	writeKeyPair(t, "third", certFile, keyFile)
+	later = later.Add(time.Hour)
+	assert.NoError(t, os.Chtimes(certFile, later, later))
+	assert.NoError(t, os.Chtimes(keyFile, later, later))
+	cert, err = r.certificate()
+	assert.NoError(t, err)
+	assert.Equal(t, "third", commonName(t, cert))
+
+	// Missing files keep the last certificate as well.
+	assert.NoError(t, os.Remove(keyFile))
+	cert, err = r.certificate()
+	assert.NoError(t, err)
+	assert.Equal(t, "third", commonName(t, cert))
+}
@@ -3,14 +3,27 @@ package synse
 // utils.go provides function utilities for the client.
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
+	"math/rand"
 	"net/url"
 	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/creasty/defaults"
 	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+var (
+	// jitter is the random source for backoff jitter. It is seeded so that
+	// clients started at the same time do not wait in lockstep.
+	jitter = rand.New(rand.NewSource(time.Now().UnixNano())) // nolint: gosec
+
+	// jitterMu guards jitter, which is not safe for concurrent use.
+	jitterMu sync.Mutex
 )
 
 // setDefaults setups default options.
@@ -19,10 +32,13 @@ func setDefaults(opts *Options) error {
 		return errors.New("options can not be nil")
 	}
 
-	if opts.Address == "" {
+	if opts.Address == "" && len(opts.Addresses) == 0 {
 		return errors.New("no address is specified")
 	}
 
+	if opts.defaulted {
+		return nil
+	}
 	if err := defaults.Set(opts); err != nil {
 		return errors.New("failed to set default configs")
 	}
@@ -30,19 +46,36 @@ func setDefaults(opts *Options) error {
 	return nil
 }
 
-// setTLS registers the certificates with configured options.
-func setTLS(opts *Options) (*tls.Certificate, error) {
-	if opts.TLS.CertFile == "" && opts.TLS.KeyFile == "" {
-		// return &tls.Certificate{}, errors.New("no certificates are specified")
-		return nil, nil
+// contextError returns the context error if the context is done, otherwise it
+// returns the given error. This surfaces cancellation and deadline errors
+// instead of the i/o timeout they cause on the connection.
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
 	}
+	return err
+}
 
-	cert, err := tls.LoadX509KeyPair(opts.TLS.CertFile, opts.TLS.KeyFile)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to set client certificates")
+// backoff returns the wait time before the given (zero-based) retry attempt.
+// The wait time doubles after each attempt, up to the max wait time, and is
+// jittered so that many clients do not retry in lockstep.
+func backoff(wait, maxWait time.Duration, attempt uint) time.Duration {
+	d := wait
+	for i := uint(0); i < attempt && d < maxWait; i++ {
+		d *= 2
+	}
+	if d > maxWait {
+		d = maxWait
+	}
+	if d <= 0 {
+		return 0
 	}
 
-	return &cert, nil
+	// Equal jitter: wait at least half of the backoff.
+	half := d / 2
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return half + time.Duration(jitter.Int63n(int64(d-half)+1))
 }
 
 // buildURL builds up a complete URL from given scheme, host and path.
@@ -62,6 +95,41 @@ func makePath(components ...string) string {
 	return strings.Join(components, "/")
 }
 
+// streamTags returns the tag groups which select the devices of a read stream,
+// for use with the `/read` endpoint. Each device ID is selected through its
+// system ID tag.
+func streamTags(opts scheme.ReadStreamOptions) []string {
+	var tags []string
+	for _, id := range opts.Ids {
+		tags = append(tags, scheme.IDTag(id).String())
+	}
+	return append(tags, opts.Tags...)
+}
+
+// validateTags checks the namespace and tag groups of a request, so that a
+// malformed tag is rejected before the request is sent.
+func validateTags(ns []string, groups []string) error {
+	for _, n := range ns {
+		if err := scheme.ValidateNamespace(n); err != nil {
+			return errors.Wrap(err, "invalid request tags")
+		}
+	}
+	if err := scheme.ValidateTagGroups(groups); err != nil {
+		return errors.Wrap(err, "invalid request tags")
+	}
+	return nil
+}
+
+// validateStreamTags checks the device IDs and tag groups of a read stream.
+func validateStreamTags(opts scheme.ReadStreamOptions) error {
+	for _, id := range opts.Ids {
+		if err := scheme.IDTag(id).Validate(); err != nil {
+			return errors.Wrap(err, "invalid stream device id")
+		}
+	}
+	return validateTags(nil, opts.Tags)
+}
+
 // structToURLValues decodes a struct value into url.Values that can
 // be used as query parameters.
 func structToURLValues(s interface{}) url.Values {
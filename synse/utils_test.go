@@ -1,10 +1,14 @@
 package synse
 
 import (
+	"context"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
 )
 
 func TestBuildURL(t *testing.T) {
@@ -179,3 +183,86 @@ func TestStructToURLValues(t *testing.T) {
 		assert.Equal(t, tt.expected, out)
 	}
 }
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt uint
+		min     time.Duration
+		max     time.Duration
+	}{
+		{attempt: 0, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 1, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{attempt: 2, min: 200 * time.Millisecond, max: 400 * time.Millisecond},
+		{attempt: 3, min: 250 * time.Millisecond, max: 500 * time.Millisecond},
+		{attempt: 100, min: 250 * time.Millisecond, max: 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 10; i++ {
+			d := backoff(100*time.Millisecond, 500*time.Millisecond, tt.attempt)
+			assert.GreaterOrEqual(t, d, tt.min, tt.attempt)
+			assert.LessOrEqual(t, d, tt.max, tt.attempt)
+		}
+	}
+
+	assert.Equal(t, time.Duration(0), backoff(0, 0, 3))
+}
+
+func TestStreamTags(t *testing.T) {
+	tests := []struct {
+		in       scheme.ReadStreamOptions
+		expected []string
+	}{
+		{
+			in:       scheme.ReadStreamOptions{},
+			expected: nil,
+		},
+		{
+			in:       scheme.ReadStreamOptions{Ids: []string{"123", "456"}},
+			expected: []string{"system/id:123", "system/id:456"},
+		},
+		{
+			in:       scheme.ReadStreamOptions{Tags: []string{"foo", "default/bar"}},
+			expected: []string{"foo", "default/bar"},
+		},
+		{
+			in:       scheme.ReadStreamOptions{Ids: []string{"123"}, Tags: []string{"foo"}},
+			expected: []string{"system/id:123", "foo"},
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, streamTags(tt.in))
+	}
+}
+
+func TestClients_InvalidTags(t *testing.T) {
+	// Nothing listens on the address, so the errors can only come from the
+	// validation done before sending the requests.
+	opts := &Options{Address: "localhost:1"}
+	httpClient, err := NewHTTPClientV3(opts)
+	assert.NoError(t, err)
+	wsClient, err := NewWebSocketClientV3(opts)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	for _, client := range []Client{httpClient, wsClient} {
+		_, err := client.ScanContext(ctx, scheme.ScanOptions{Tags: []string{"rack:1,"}})
+		assert.True(t, errors.Is(err, scheme.ErrInvalidTag), err)
+
+		_, err = client.ScanContext(ctx, scheme.ScanOptions{NS: "vapor/io"})
+		assert.True(t, errors.Is(err, scheme.ErrInvalidTag), err)
+
+		_, err = client.TagsContext(ctx, scheme.TagsOptions{NS: []string{"default", "a b"}})
+		assert.True(t, errors.Is(err, scheme.ErrInvalidTag), err)
+
+		_, err = client.ReadContext(ctx, scheme.ReadOptions{Tags: []string{"system/type:led", "a/b/c"}})
+		assert.True(t, errors.Is(err, scheme.ErrInvalidTag), err)
+
+		err = client.ReadStreamContext(ctx, scheme.ReadStreamOptions{Ids: []string{""}}, make(chan *scheme.Read), nil)
+		assert.True(t, errors.Is(err, scheme.ErrInvalidTag), err)
+
+		err = client.ReadStreamContext(ctx, scheme.ReadStreamOptions{Tags: []string{":led"}}, make(chan *scheme.Read), nil)
+		assert.True(t, errors.Is(err, scheme.ErrInvalidTag), err)
+	}
+}
@@ -0,0 +1,218 @@
+package synse
+
+// wait.go provides tracking of write transactions to completion.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creasty/defaults"
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// WaitOptions is the config options for waiting on write transactions.
+type WaitOptions struct {
+	// WaitTime specifies the wait time before polling a transaction again.
+	// It is increased after each poll, following the same backoff strategy
+	// as RetryOptions.
+	WaitTime time.Duration `default:"100ms"`
+
+	// MaxWaitTime specifies the maximum wait time, the cap, between polls.
+	MaxWaitTime time.Duration `default:"2s"`
+
+	// Timeout specifies how long to wait for a transaction which has no
+	// timeout of its own, if the context has no deadline either.
+	Timeout time.Duration `default:"5m"`
+
+	// OnChange, if set, is called with the transaction every time its status
+	// changes, including the first status seen. Calls are serialized, so the
+	// callback does not need to be safe for concurrent use.
+	OnChange func(*scheme.Transaction) `default:"-"`
+}
+
+// TransactionError is a write transaction which did not complete successfully,
+// either because it finished in an error state or because it could not be
+// tracked to completion.
+type TransactionError struct {
+	// ID is the ID of the transaction.
+	ID string
+
+	// Transaction is the last known state of the transaction. It is nil if
+	// the transaction was never fetched.
+	Transaction *scheme.Transaction
+
+	// Err is the reason the transaction could not be tracked to completion.
+	// It is nil if the transaction finished in an error state.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *TransactionError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("transaction %v: %v", e.ID, e.Err)
+	}
+	return fmt.Sprintf("transaction %v failed: %v", e.ID, e.Transaction.Message)
+}
+
+// Unwrap returns the underlying error.
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// WaitError aggregates the errors of the transactions which did not complete
+// successfully when waiting on many transactions.
+type WaitError struct {
+	// Errors holds an error for each transaction which did not complete
+	// successfully.
+	Errors []*TransactionError
+}
+
+// Error implements the error interface.
+func (e *WaitError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d transaction(s) did not complete: %v", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Is reports whether any of the transaction errors matches the target.
+func (e *WaitError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitTransactions waits for the transactions of the given writes, as returned
+// by WriteAsync, to complete. Each transaction is polled concurrently with
+// backoff until it is done or in an error state, bounded by its own timeout.
+//
+// It returns the final state of each transaction, in the same order as the
+// writes; a transaction which was never fetched is nil. If any of the
+// transactions does not complete successfully, a *WaitError is returned along
+// with the results.
+func WaitTransactions(ctx context.Context, client Client, writes []*scheme.Write, opts *WaitOptions) ([]*scheme.Transaction, error) {
+	var o WaitOptions
+	if opts != nil {
+		o = *opts
+	}
+	if err := defaults.Set(&o); err != nil {
+		return nil, errors.New("failed to set default configs")
+	}
+
+	w := &transactionWaiter{
+		client:  client,
+		options: o,
+	}
+
+	out := make([]*scheme.Transaction, len(writes))
+	errs := make([]*TransactionError, len(writes))
+
+	var wg sync.WaitGroup
+	for i, write := range writes {
+		wg.Add(1)
+		go func(i int, write *scheme.Write) {
+			defer wg.Done()
+			out[i], errs[i] = w.wait(ctx, write)
+		}(i, write)
+	}
+	wg.Wait()
+
+	var failed []*TransactionError
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) > 0 {
+		return out, &WaitError{Errors: failed}
+	}
+	return out, nil
+}
+
+// transactionWaiter polls transactions until they complete.
+type transactionWaiter struct {
+	// client is the client used to poll transactions.
+	client Client
+
+	// options holds the wait options.
+	options WaitOptions
+
+	// mu serializes calls to the OnChange callback.
+	mu sync.Mutex
+}
+
+// wait polls the transaction of the write until it completes, its timeout
+// elapses or the context is done.
+func (w *transactionWaiter) wait(ctx context.Context, write *scheme.Write) (*scheme.Transaction, *TransactionError) {
+	pollCtx := ctx
+	timeout, err := write.TimeoutDuration()
+	if err != nil || timeout <= 0 {
+		timeout = 0
+		if _, ok := ctx.Deadline(); !ok {
+			timeout = w.options.Timeout
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		pollCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var last *scheme.Transaction
+	for attempt := uint(0); ; attempt++ {
+		t, err := w.client.TransactionContext(pollCtx, write.ID)
+		switch {
+		case pollCtx.Err() != nil:
+			// Handled below, along with a context done while waiting.
+		case err != nil:
+			// A transport failure or a timeout may be transient, whereas any
+			// other error will not go away by polling again.
+			if !IsTransport(err) && !IsTimeout(err) {
+				return last, &TransactionError{ID: write.ID, Transaction: last, Err: err}
+			}
+		default:
+			if last == nil || t.Status != last.Status {
+				w.notify(t)
+			}
+			last = t
+
+			switch t.Status {
+			case scheme.TransactionDone:
+				return t, nil
+			case scheme.TransactionError:
+				return t, &TransactionError{ID: write.ID, Transaction: t}
+			}
+		}
+
+		timer := time.NewTimer(backoff(w.options.WaitTime, w.options.MaxWaitTime, attempt))
+		select {
+		case <-timer.C:
+		case <-pollCtx.Done():
+			timer.Stop()
+			err := ctx.Err()
+			if err == nil {
+				err = errors.Wrapf(ErrTimeout, "did not complete within %v", timeout)
+			}
+			return last, &TransactionError{ID: write.ID, Transaction: last, Err: err}
+		}
+	}
+}
+
+// notify reports a status change of a transaction to the OnChange callback.
+func (w *transactionWaiter) notify(t *scheme.Transaction) {
+	if w.options.OnChange == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.options.OnChange(t)
+}
@@ -0,0 +1,222 @@
+package synse
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-client-go/internal/test"
+	"github.com/vapor-ware/synse-client-go/synse/scheme"
+)
+
+// serveTransactions serves the `/transaction/{id}` endpoint, reporting the
+// given statuses for each transaction in turn, one per poll. The last status
+// is repeated once all of them were reported.
+func serveTransactions(t *testing.T, server *test.HTTPServer, statuses map[string][]string) {
+	var mu sync.Mutex
+	polls := map[string]int{}
+
+	server.HandleVersioned("/transaction/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/v3/transaction/")
+
+		mu.Lock()
+		s, ok := statuses[id]
+		i := polls[id]
+		polls[id]++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(404)
+			fprintf(t, w, `{"http_code":404,"description":"resource not found","context":"transaction %s not found"}`, id)
+			return
+		}
+		if i >= len(s) {
+			i = len(s) - 1
+		}
+		message := ""
+		if s[i] == scheme.TransactionError {
+			message = "write failed"
+		}
+		fprintf(t, w, `{"id":"%s","timeout":"5s","device":"dev","status":"%s","message":"%s"}`, id, s[i], message)
+	})
+}
+
+// newWaitClient creates a HTTP client for the server and the write results
+// for the given transaction IDs.
+func newWaitClient(t *testing.T, server *test.HTTPServer, timeout string, ids ...string) (Client, []*scheme.Write) {
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NoError(t, err)
+
+	var writes []*scheme.Write
+	for _, id := range ids {
+		writes = append(writes, &scheme.Write{ID: id, Device: "dev", Timeout: timeout})
+	}
+	return client, writes
+}
+
+func TestWaitTransactions(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	serveTransactions(t, server, map[string][]string{
+		"t1": {"pending", "writing", "done"},
+		"t2": {"writing", "writing", "writing", "done"},
+	})
+
+	client, writes := newWaitClient(t, server, "5s", "t1", "t2")
+
+	var changes []string
+	txns, err := WaitTransactions(context.Background(), client, writes, &WaitOptions{
+		WaitTime:    time.Millisecond,
+		MaxWaitTime: 5 * time.Millisecond,
+		OnChange: func(txn *scheme.Transaction) {
+			changes = append(changes, txn.ID+":"+txn.Status)
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, txns, 2)
+	assert.Equal(t, "t1", txns[0].ID)
+	assert.Equal(t, "done", txns[0].Status)
+	assert.Equal(t, "t2", txns[1].ID)
+	assert.Equal(t, "done", txns[1].Status)
+
+	// Changes of the two transactions interleave, but each is in order.
+	var t1, t2 []string
+	for _, c := range changes {
+		if strings.HasPrefix(c, "t1:") {
+			t1 = append(t1, c)
+		} else {
+			t2 = append(t2, c)
+		}
+	}
+	assert.Equal(t, []string{"t1:pending", "t1:writing", "t1:done"}, t1)
+	assert.Equal(t, []string{"t2:writing", "t2:done"}, t2)
+}
+
+func TestWaitTransactions_Error(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	serveTransactions(t, server, map[string][]string{
+		"t1": {"pending", "done"},
+		"t2": {"pending", "error"},
+	})
+
+	client, writes := newWaitClient(t, server, "5s", "t1", "t2", "t3")
+
+	txns, err := WaitTransactions(context.Background(), client, writes, &WaitOptions{
+		WaitTime:    time.Millisecond,
+		MaxWaitTime: 5 * time.Millisecond,
+	})
+	assert.Error(t, err)
+	assert.Len(t, txns, 3)
+	assert.Equal(t, "done", txns[0].Status)
+	assert.Equal(t, "error", txns[1].Status)
+	assert.Nil(t, txns[2])
+
+	waitErr, ok := err.(*WaitError)
+	assert.True(t, ok)
+	assert.Len(t, waitErr.Errors, 2)
+	assert.Equal(t, "transaction t2 failed: write failed", waitErr.Errors[0].Error())
+	assert.Equal(t, "t3", waitErr.Errors[1].ID)
+	assert.True(t, IsNotFound(waitErr.Errors[1]))
+	assert.True(t, IsNotFound(err))
+}
+
+func TestWaitTransactions_Timeout(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	serveTransactions(t, server, map[string][]string{
+		"t1": {"pending", "writing"},
+	})
+
+	client, writes := newWaitClient(t, server, "50ms", "t1")
+
+	txns, err := WaitTransactions(context.Background(), client, writes, &WaitOptions{
+		WaitTime:    time.Millisecond,
+		MaxWaitTime: 5 * time.Millisecond,
+	})
+	assert.True(t, IsTimeout(err))
+	assert.Equal(t, "writing", txns[0].Status)
+	assert.Contains(t, err.Error(), "transaction t1: did not complete within 50ms")
+}
+
+func TestWaitTransactions_Cancelled(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	serveTransactions(t, server, map[string][]string{
+		"t1": {"pending"},
+	})
+
+	client, writes := newWaitClient(t, server, "5s", "t1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	txns, err := WaitTransactions(ctx, client, writes, nil)
+	assert.True(t, IsTimeout(err))
+	assert.Equal(t, "pending", txns[0].Status)
+
+	waitErr, ok := err.(*WaitError)
+	assert.True(t, ok)
+	assert.Equal(t, context.DeadlineExceeded, waitErr.Errors[0].Err)
+}
+
+func TestWaitTransactions_DefaultTimeout(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	serveTransactions(t, server, map[string][]string{
+		"t1": {"pending"},
+	})
+
+	// Neither the write nor the context bound the wait.
+	client, writes := newWaitClient(t, server, "", "t1")
+
+	txns, err := WaitTransactions(context.Background(), client, writes, &WaitOptions{
+		WaitTime:    time.Millisecond,
+		MaxWaitTime: 5 * time.Millisecond,
+		Timeout:     50 * time.Millisecond,
+	})
+	assert.True(t, IsTimeout(err))
+	assert.Equal(t, "pending", txns[0].Status)
+	assert.Contains(t, err.Error(), "transaction t1: did not complete within 50ms")
+}
+
+func TestWaitTransactions_NotRetried(t *testing.T) {
+	server := test.NewHTTPServerV3()
+	defer server.Close()
+
+	serveTransactions(t, server, map[string][]string{
+		"t1": {"pending"},
+	})
+
+	var polls int
+	client, err := NewHTTPClientV3(&Options{
+		Address: server.URL,
+		Middleware: []Middleware{func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) (interface{}, error) {
+				polls++
+				return nil, &LimitError{Operation: op.Name, Budget: "all"}
+			}
+		}},
+	})
+	assert.NoError(t, err)
+
+	// Only transport errors and timeouts are retried.
+	txns, err := WaitTransactions(context.Background(), client, []*scheme.Write{{ID: "t1"}}, &WaitOptions{
+		WaitTime: time.Millisecond,
+	})
+	assert.True(t, IsLimited(err))
+	assert.Nil(t, txns[0])
+	assert.Equal(t, 1, polls)
+}
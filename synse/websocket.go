@@ -3,9 +3,13 @@ package synse
 // websocket.go implements a websocket client.
 
 import (
-	"crypto/tls"
+	"context"
+	"fmt"
+	"net/http"
 	"reflect"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/mitchellh/mapstructure"
@@ -13,6 +17,17 @@ import (
 	"github.com/vapor-ware/synse-client-go/synse/scheme"
 )
 
+const (
+	// closeTimeout is how long Close waits for the server to acknowledge
+	// the close message before tearing down the connection.
+	closeTimeout = time.Second
+
+	// streamBuffer is the number of stream response events that can be
+	// queued before the connection reader blocks on a slow consumer.
+	streamBuffer = 64
+)
+
+// websocketClient implements a websocket client.
 type websocketClient struct {
 	// options is the global config options of the client.
 	options *Options
@@ -20,8 +35,20 @@ type websocketClient struct {
 	// client holds the websocket.Dialer.
 	client *websocket.Dialer
 
-	// connection holds the websocket connection.
-	connection *websocket.Conn
+	// endpoints tracks the addresses of Synse Server.
+	endpoints *endpoints
+
+	// mu guards session, reconnecting and reconnectErr.
+	mu sync.Mutex
+
+	// session holds the current websocket connection session.
+	session *session
+
+	// reconnecting holds the in-progress reconnect, if any.
+	reconnecting *reconnect
+
+	// reconnectErr is the reason the last reconnect gave up.
+	reconnectErr error
 
 	// counter counts the number of request sent. It has the type uint64
 	// that later be used by an atomic function, which makes it more
@@ -35,99 +62,233 @@ type websocketClient struct {
 	// entryRoute is the entry route to start the websocket connection.
 	entryRoute string
 
-	// scheme could either be ws or wss depending on TLS configuration.
-	scheme string
+	// addresses holds the parsed addresses of Synse Server.
+	addresses map[string]address
 }
 
 // NewWebSocketClientV3 returns a new instance of a websocket client for v3.
 func NewWebSocketClientV3(opts *Options) (Client, error) {
-	c, err := createWebSocketClient(opts)
+	// An address with the wss scheme enables TLS, so the addresses are
+	// parsed before the TLS configuration is set up.
+	addresses, err := parseAddresses(opts)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create a websocket client")
 	}
 
-	s := "ws"
-	if opts.TLS.Enabled {
-		s = "wss"
+	c, err := createWebSocketClient(opts, addresses)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a websocket client")
 	}
 
-	return &websocketClient{
+	return withMiddleware(&websocketClient{
 		options:    opts,
 		client:     c,
+		endpoints:  newEndpoints(opts),
+		addresses:  addresses,
 		apiVersion: "v3",
 		entryRoute: "connect",
-		scheme:     s,
-	}, nil
+	}, "websocket", opts), nil
 }
 
-// createWebSocketClient setups a websocket dialer with configured options.
-func createWebSocketClient(opts *Options) (*websocket.Dialer, error) {
+// createWebSocketClient setups a websocket dialer with configured options,
+// which dials the given addresses.
+func createWebSocketClient(opts *Options, addresses map[string]address) (*websocket.Dialer, error) {
 	err := setDefaults(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	if !opts.TLS.Enabled {
-		return &websocket.Dialer{
-			HandshakeTimeout: opts.WebSocket.HandshakeTimeout,
-		}, nil
-	}
-
-	// Only return a TLS client if its config option is enable.
-	cert, err := setTLS(opts)
+	proxy, err := newProxyFunc(opts.Proxy)
 	if err != nil {
 		return nil, err
 	}
 
-	certs := []tls.Certificate{}
-	if cert != nil {
-		certs = append(certs, *cert)
-	}
-
-	return &websocket.Dialer{
+	dialer := &websocket.Dialer{
 		HandshakeTimeout: opts.WebSocket.HandshakeTimeout,
-		TLSClientConfig: &tls.Config{
-			Certificates: certs,
+		NetDialContext:   newDialContext(opts, addresses),
+		Proxy:            bypassUnix(proxy, addresses),
+	}
+	if !opts.TLS.Enabled {
+		return dialer, nil
+	}
 
-			// NOTE - refer to #24. If not disable linting, a warning will happen:
-			// TLS InsecureSkipVerify may be true.,HIGH,LOW (gosec)
-			InsecureSkipVerify: opts.TLS.SkipVerify, // nolint
-		},
-	}, nil
+	// Only set up TLS if its config option is enable.
+	cfg, err := newTLSConfig(opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+	dialer.TLSClientConfig = cfg
+	return dialer, nil
 }
 
 // Open opens the websocket connection between the client and Synse Server.
+// Calling Open on a client that already has an open connection has no effect.
+// If the active address can not be connected to, the next ones are tried in
+// turn.
 func (c *websocketClient) Open() error {
-	conn, _, err := c.client.Dial(buildURL(c.scheme, c.options.Address, c.apiVersion, c.entryRoute), nil)
+	return c.OpenContext(context.Background())
+}
+
+// OpenContext is like Open, but uses the given context for the handshake.
+func (c *websocketClient) OpenContext(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session != nil && !c.session.closed() {
+		return nil
+	}
+
+	conn, address, err := c.dialAny(ctx, c.endpoints.order())
 	if err != nil {
-		return errors.Wrap(err, "failed to open the websocket connection")
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// The handshake times out at the deadline of the context, which may
+		// be just before the context reports it.
+		if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+			return context.DeadlineExceeded
+		}
+		// A handshake rejected by Synse Server is not a transport error.
+		if errors.As(err, new(*APIError)) {
+			return errors.Wrap(err, "failed to open the websocket connection")
+		}
+		return newTransportError(err, "failed to open the websocket connection")
 	}
 
-	c.connection = conn
+	c.setSession(conn, address)
+	c.reconnectErr = nil
 	return nil
 }
 
 // Close closes the websocket connection between the client and Synse Server.
-// It's up to the user to close the connection after finish using it.
+// It's up to the user to close the connection after finish using it. Any
+// requests still in flight fail once the connection is closed, and any
+// in-progress reconnect is aborted.
 func (c *websocketClient) Close() error {
-	if c.connection != nil {
-		err := c.connection.WriteMessage(
-			websocket.CloseMessage,
-			websocket.FormatCloseMessage(
-				websocket.CloseNormalClosure,
-				"",
-			),
-		)
-		if err != nil {
-			return errors.Wrap(err, "failed to close the connection gracefully")
-		}
+	c.mu.Lock()
+	s := c.session
+	c.session = nil
+	if c.reconnecting != nil {
+		c.reconnecting.abort()
+	}
+	c.mu.Unlock()
+
+	if s == nil || s.closed() {
+		return nil
+	}
+	defer s.close()
+
+	err := s.writeClose(closeTimeout)
+	if err != nil {
+		return errors.Wrap(err, "failed to close the connection gracefully")
+	}
+
+	// Give the server a chance to acknowledge the close message before the
+	// connection is torn down.
+	select {
+	case <-s.done:
+	case <-time.After(closeTimeout):
 	}
 	return nil
 }
 
+// dial opens a new websocket connection to Synse Server at the given address,
+// with the credentials of the auth provider. If the handshake is rejected as
+// unauthorized and the credentials can be refreshed, it is made once more
+// with the refreshed credentials.
+func (c *websocketClient) dial(ctx context.Context, address string) (*websocket.Conn, error) {
+	for refreshed := false; ; refreshed = true {
+		header := http.Header{}
+		if err := applyAuth(ctx, c.options.Auth, header); err != nil {
+			return nil, err
+		}
+
+		conn, resp, err := c.dialer(address).DialContext(ctx, c.url(address), header)
+		if err == nil {
+			return conn, nil
+		}
+		// A handshake rejected by the server, or a proxy in front of it, is
+		// reported as an APIError for its status code.
+		if resp != nil && resp.StatusCode >= http.StatusBadRequest {
+			err = newAPIError(scheme.Error{
+				HTTPCode:    resp.StatusCode,
+				Description: http.StatusText(resp.StatusCode),
+			})
+		}
+
+		if refreshed || !IsUnauthorized(err) {
+			return nil, err
+		}
+		if ok, rerr := refreshAuth(ctx, c.options.Auth); rerr != nil {
+			return nil, rerr
+		} else if !ok {
+			return nil, err
+		}
+	}
+}
+
+// dialer returns the dialer of the given address. The TLS connections to a
+// unix socket address are dialed by the client, see newDialTLSContext. This
+// is not done for the other addresses, since the dialer would then dial a
+// TLS connection to a proxy in front of them.
+func (c *websocketClient) dialer(addr string) *websocket.Dialer {
+	a := c.addresses[addr]
+	if a.socket == "" || c.client.TLSClientConfig == nil {
+		return c.client
+	}
+	d := *c.client
+	d.NetDialTLSContext = newDialTLSContext(d.NetDialContext, d.TLSClientConfig, map[string]address{addr: a})
+	return &d
+}
+
+// url returns the URL of the connect route of Synse Server at the given
+// address.
+func (c *websocketClient) url(address string) string {
+	return c.addresses[address].url("ws", "wss", c.options.TLS.Enabled, c.apiVersion, c.entryRoute)
+}
+
+// dialAny opens a new websocket connection to the first of the addresses that
+// can be connected to, and returns its address.
+func (c *websocketClient) dialAny(ctx context.Context, addresses []string) (*websocket.Conn, string, error) {
+	var err error
+	for _, address := range addresses {
+		var conn *websocket.Conn
+		conn, err = c.dial(ctx, address)
+		if err == nil {
+			return conn, address, nil
+		}
+		if ctx.Err() != nil {
+			return nil, "", err
+		}
+		// The other addresses would reject the handshake as well, e.g. for
+		// invalid credentials.
+		if errors.As(err, new(*APIError)) && !IsServerError(err) {
+			return nil, "", err
+		}
+	}
+	return nil, "", err
+}
+
+// setSession installs a session for the connection to the given address,
+// which becomes the active address. It must be called with mu held.
+func (c *websocketClient) setSession(conn *websocket.Conn, address string) {
+	s := newSession(conn, address, c.options.WebSocket, c.sessionEnded)
+	c.session = s
+	c.endpoints.activate(address)
+
+	if address != c.endpoints.primary() && c.endpoints.interval > 0 {
+		go c.watchPrimary(s)
+	}
+}
+
 // Status returns the status info. This is used to check if the server
 // is responsive and reachable.
 func (c *websocketClient) Status() (*scheme.Status, error) {
+	return c.StatusContext(context.Background())
+}
+
+// StatusContext is like Status, but uses the given context.
+func (c *websocketClient) StatusContext(ctx context.Context) (*scheme.Status, error) {
 	req := scheme.RequestStatus{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -136,7 +297,7 @@ func (c *websocketClient) Status() (*scheme.Status, error) {
 	}
 
 	resp := new(scheme.Status)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -146,6 +307,11 @@ func (c *websocketClient) Status() (*scheme.Status, error) {
 
 // Version returns the version info.
 func (c *websocketClient) Version() (*scheme.Version, error) {
+	return c.VersionContext(context.Background())
+}
+
+// VersionContext is like Version, but uses the given context.
+func (c *websocketClient) VersionContext(ctx context.Context) (*scheme.Version, error) {
 	req := scheme.RequestVersion{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -154,7 +320,7 @@ func (c *websocketClient) Version() (*scheme.Version, error) {
 	}
 
 	resp := new(scheme.Version)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -164,6 +330,11 @@ func (c *websocketClient) Version() (*scheme.Version, error) {
 
 // Config returns the unified configuration info.
 func (c *websocketClient) Config() (*scheme.Config, error) {
+	return c.ConfigContext(context.Background())
+}
+
+// ConfigContext is like Config, but uses the given context.
+func (c *websocketClient) ConfigContext(ctx context.Context) (*scheme.Config, error) {
 	req := scheme.RequestConfig{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -172,7 +343,7 @@ func (c *websocketClient) Config() (*scheme.Config, error) {
 	}
 
 	resp := new(scheme.Config)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -183,6 +354,11 @@ func (c *websocketClient) Config() (*scheme.Config, error) {
 // Plugins returns the summary of all plugins currently registered with
 // Synse Server.
 func (c *websocketClient) Plugins() ([]*scheme.PluginMeta, error) {
+	return c.PluginsContext(context.Background())
+}
+
+// PluginsContext is like Plugins, but uses the given context.
+func (c *websocketClient) PluginsContext(ctx context.Context) ([]*scheme.PluginMeta, error) {
 	req := scheme.RequestPlugins{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -191,7 +367,7 @@ func (c *websocketClient) Plugins() ([]*scheme.PluginMeta, error) {
 	}
 
 	resp := new([]*scheme.PluginMeta)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -201,6 +377,11 @@ func (c *websocketClient) Plugins() ([]*scheme.PluginMeta, error) {
 
 // Plugin returns data from a specific plugin.
 func (c *websocketClient) Plugin(id string) (*scheme.Plugin, error) {
+	return c.PluginContext(context.Background(), id)
+}
+
+// PluginContext is like Plugin, but uses the given context.
+func (c *websocketClient) PluginContext(ctx context.Context, id string) (*scheme.Plugin, error) {
 	req := scheme.RequestPlugin{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -212,7 +393,7 @@ func (c *websocketClient) Plugin(id string) (*scheme.Plugin, error) {
 	}
 
 	resp := new(scheme.Plugin)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -222,6 +403,11 @@ func (c *websocketClient) Plugin(id string) (*scheme.Plugin, error) {
 
 // PluginHealth returns the summary of the health of registered plugins.
 func (c *websocketClient) PluginHealth() (*scheme.PluginHealth, error) {
+	return c.PluginHealthContext(context.Background())
+}
+
+// PluginHealthContext is like PluginHealth, but uses the given context.
+func (c *websocketClient) PluginHealthContext(ctx context.Context) (*scheme.PluginHealth, error) {
 	req := scheme.RequestPluginHealth{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -230,7 +416,7 @@ func (c *websocketClient) PluginHealth() (*scheme.PluginHealth, error) {
 	}
 
 	resp := new(scheme.PluginHealth)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -243,6 +429,15 @@ func (c *websocketClient) PluginHealth() (*scheme.PluginHealth, error) {
 // It can be filtered to show only those devices which match a set
 // of provided tags by using ScanOptions.
 func (c *websocketClient) Scan(opts scheme.ScanOptions) ([]*scheme.Scan, error) {
+	return c.ScanContext(context.Background(), opts)
+}
+
+// ScanContext is like Scan, but uses the given context.
+func (c *websocketClient) ScanContext(ctx context.Context, opts scheme.ScanOptions) ([]*scheme.Scan, error) {
+	if err := validateTags([]string{opts.NS}, opts.Tags); err != nil {
+		return nil, err
+	}
+
 	req := scheme.RequestScan{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -252,7 +447,7 @@ func (c *websocketClient) Scan(opts scheme.ScanOptions) ([]*scheme.Scan, error)
 	}
 
 	resp := new([]*scheme.Scan)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -263,6 +458,15 @@ func (c *websocketClient) Scan(opts scheme.ScanOptions) ([]*scheme.Scan, error)
 // Tags returns the list of all tags currently associated with devices.
 // If no TagsOptions is specified, the default tag namespace will be used.
 func (c *websocketClient) Tags(opts scheme.TagsOptions) ([]string, error) {
+	return c.TagsContext(context.Background(), opts)
+}
+
+// TagsContext is like Tags, but uses the given context.
+func (c *websocketClient) TagsContext(ctx context.Context, opts scheme.TagsOptions) ([]string, error) {
+	if err := validateTags(opts.NS, nil); err != nil {
+		return nil, err
+	}
+
 	req := scheme.RequestTags{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -272,7 +476,7 @@ func (c *websocketClient) Tags(opts scheme.TagsOptions) ([]string, error) {
 	}
 
 	resp := new([]string)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -283,6 +487,11 @@ func (c *websocketClient) Tags(opts scheme.TagsOptions) ([]string, error) {
 // Info returns the full set of meta info and capabilities for a specific
 // device.
 func (c *websocketClient) Info(device string) (*scheme.Info, error) {
+	return c.InfoContext(context.Background(), device)
+}
+
+// InfoContext is like Info, but uses the given context.
+func (c *websocketClient) InfoContext(ctx context.Context, device string) (*scheme.Info, error) {
 	req := scheme.RequestInfo{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -294,7 +503,7 @@ func (c *websocketClient) Info(device string) (*scheme.Info, error) {
 	}
 
 	resp := new(scheme.Info)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -305,6 +514,15 @@ func (c *websocketClient) Info(device string) (*scheme.Info, error) {
 // Read returns data from devices which match the set of provided tags
 // using ReadOptions.
 func (c *websocketClient) Read(opts scheme.ReadOptions) ([]*scheme.Read, error) {
+	return c.ReadContext(context.Background(), opts)
+}
+
+// ReadContext is like Read, but uses the given context.
+func (c *websocketClient) ReadContext(ctx context.Context, opts scheme.ReadOptions) ([]*scheme.Read, error) {
+	if err := validateTags([]string{opts.NS}, opts.Tags); err != nil {
+		return nil, err
+	}
+
 	req := scheme.RequestRead{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -314,7 +532,7 @@ func (c *websocketClient) Read(opts scheme.ReadOptions) ([]*scheme.Read, error)
 	}
 
 	resp := new([]*scheme.Read)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -326,6 +544,11 @@ func (c *websocketClient) Read(opts scheme.ReadOptions) ([]*scheme.Read, error)
 // It is the same as Read() where the label matches the device id tag
 // specified in ReadOptions.
 func (c *websocketClient) ReadDevice(device string) ([]*scheme.Read, error) {
+	return c.ReadDeviceContext(context.Background(), device)
+}
+
+// ReadDeviceContext is like ReadDevice, but uses the given context.
+func (c *websocketClient) ReadDeviceContext(ctx context.Context, device string) ([]*scheme.Read, error) {
 	req := scheme.RequestReadDevice{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -337,7 +560,7 @@ func (c *websocketClient) ReadDevice(device string) ([]*scheme.Read, error) {
 	}
 
 	resp := new([]*scheme.Read)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -347,6 +570,11 @@ func (c *websocketClient) ReadDevice(device string) ([]*scheme.Read, error) {
 
 // ReadCache returns cached reading data from the registered plugins.
 func (c *websocketClient) ReadCache(opts scheme.ReadCacheOptions, out chan<- *scheme.Read) error {
+	return c.ReadCacheContext(context.Background(), opts, out)
+}
+
+// ReadCacheContext is like ReadCache, but uses the given context.
+func (c *websocketClient) ReadCacheContext(ctx context.Context, opts scheme.ReadCacheOptions, out chan<- *scheme.Read) error {
 	defer close(out)
 
 	req := scheme.RequestReadCache{
@@ -358,18 +586,31 @@ func (c *websocketClient) ReadCache(opts scheme.ReadCacheOptions, out chan<- *sc
 	}
 
 	resp := new([]*scheme.Read)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return err
 	}
 	for _, r := range *resp {
-		out <- r
+		select {
+		case out <- r:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 	return nil
 }
 
 // ReadStream returns a stream of current reading data from the registered plugins.
 func (c *websocketClient) ReadStream(opts scheme.ReadStreamOptions, out chan<- *scheme.Read, stop chan struct{}) error {
+	return c.ReadStreamContext(context.Background(), opts, out, stop)
+}
+
+// ReadStreamContext is like ReadStream, but uses the given context. The stream
+// is terminated when either the stop channel is closed or the context is done.
+func (c *websocketClient) ReadStreamContext(ctx context.Context, opts scheme.ReadStreamOptions, out chan<- *scheme.Read, stop chan struct{}) error {
+	if err := validateStreamTags(opts); err != nil {
+		return err
+	}
 
 	req := scheme.RequestReadStream{
 		EventMeta: scheme.EventMeta{
@@ -381,25 +622,32 @@ func (c *websocketClient) ReadStream(opts scheme.ReadStreamOptions, out chan<- *
 
 	resp := &scheme.Read{}
 	proxy := make(chan interface{})
+	quit := make(chan struct{})
+	forwarded := make(chan struct{})
 	go func() {
-		for {
-			data, open := <-proxy
-			if !open {
-				return
+		defer close(forwarded)
+		for data := range proxy {
+			select {
+			case out <- data.(*scheme.Read):
+			case <-quit:
 			}
-			out <- data.(*scheme.Read)
 		}
 	}()
 
-	err := c.streamRequest(req, resp, proxy, stop)
+	err := c.streamRequest(ctx, req, resp, proxy, stop)
+
+	// Once the stream ended, a reading which is not received yet is dropped,
+	// so that nothing is sent to the out channel after returning.
+	close(quit)
+	<-forwarded
 	if err != nil {
 		return errors.Wrap(err, "failed to stream reading data")
 	}
 
-	// If we got here, the stream has been terminated (e.g. via the `stop` channel)
-	// and the WebSocket session is still active (e.g. we did not error, panic, or
-	// exit the program). The client has stopped listening for readings, but we must
-	// tell the server to stop sending them as well.
+	// If we got here, the stream has been terminated (e.g. via the `stop` channel
+	// or the context) and the WebSocket session is still active (e.g. we did not
+	// error, panic, or exit the program). The client has stopped listening for
+	// readings, but we must tell the server to stop sending them as well.
 	req = scheme.RequestReadStream{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -414,11 +662,16 @@ func (c *websocketClient) ReadStream(opts scheme.ReadStreamOptions, out chan<- *
 	if err != nil {
 		return errors.Wrap(err, "failed to stop server-side read stream")
 	}
-	return nil
+	return ctx.Err()
 }
 
 // WriteAsync writes data to a device, in an asynchronous manner.
 func (c *websocketClient) WriteAsync(device string, opts []scheme.WriteData) ([]*scheme.Write, error) {
+	return c.WriteAsyncContext(context.Background(), device, opts)
+}
+
+// WriteAsyncContext is like WriteAsync, but uses the given context.
+func (c *websocketClient) WriteAsyncContext(ctx context.Context, device string, opts []scheme.WriteData) ([]*scheme.Write, error) {
 	req := scheme.RequestWrite{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -431,7 +684,7 @@ func (c *websocketClient) WriteAsync(device string, opts []scheme.WriteData) ([]
 	}
 
 	resp := new([]*scheme.Write)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -441,6 +694,11 @@ func (c *websocketClient) WriteAsync(device string, opts []scheme.WriteData) ([]
 
 // WriteSync writes data to a device, waiting for the write to complete.
 func (c *websocketClient) WriteSync(device string, opts []scheme.WriteData) ([]*scheme.Transaction, error) {
+	return c.WriteSyncContext(context.Background(), device, opts)
+}
+
+// WriteSyncContext is like WriteSync, but uses the given context.
+func (c *websocketClient) WriteSyncContext(ctx context.Context, device string, opts []scheme.WriteData) ([]*scheme.Transaction, error) {
 	req := scheme.RequestWrite{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -453,7 +711,7 @@ func (c *websocketClient) WriteSync(device string, opts []scheme.WriteData) ([]*
 	}
 
 	resp := new([]*scheme.Transaction)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -463,6 +721,11 @@ func (c *websocketClient) WriteSync(device string, opts []scheme.WriteData) ([]*
 
 // Transactions returns the sorted list of all cached transaction IDs.
 func (c *websocketClient) Transactions() ([]string, error) {
+	return c.TransactionsContext(context.Background())
+}
+
+// TransactionsContext is like Transactions, but uses the given context.
+func (c *websocketClient) TransactionsContext(ctx context.Context) ([]string, error) {
 	req := scheme.RequestTransactions{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -471,7 +734,7 @@ func (c *websocketClient) Transactions() ([]string, error) {
 	}
 
 	resp := new([]string)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -481,6 +744,11 @@ func (c *websocketClient) Transactions() ([]string, error) {
 
 // Transaction returns the state and status of a write transaction.
 func (c *websocketClient) Transaction(id string) (*scheme.Transaction, error) {
+	return c.TransactionContext(context.Background(), id)
+}
+
+// TransactionContext is like Transaction, but uses the given context.
+func (c *websocketClient) TransactionContext(ctx context.Context, id string) (*scheme.Transaction, error) {
 	req := scheme.RequestTransaction{
 		EventMeta: scheme.EventMeta{
 			ID:    c.addCounter(),
@@ -492,7 +760,7 @@ func (c *websocketClient) Transaction(id string) (*scheme.Transaction, error) {
 	}
 
 	resp := new(scheme.Transaction)
-	err := c.makeRequestResponse(req, resp)
+	err := c.makeRequestResponse(ctx, req, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -510,62 +778,165 @@ func (c *websocketClient) addCounter() uint64 {
 	return atomic.AddUint64(&c.counter, 1)
 }
 
-// makeRequest issues a request event, reads its response event and parse the
-// response back.
-// FIXME - refer to #22. Need to think more about how async will work in this case.
-func (c *websocketClient) makeRequestResponse(req, resp interface{}) error {
+// getSession returns the current session, or an error if the connection is not
+// open. If the connection is being re-established, it waits for the reconnect
+// to finish, bound by the context and the timeout (a zero timeout waits for as
+// long as the reconnect takes).
+func (c *websocketClient) getSession(ctx context.Context, timeout time.Duration) (*session, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		c.mu.Lock()
+		s, r, rerr := c.session, c.reconnecting, c.reconnectErr
+		c.mu.Unlock()
+
+		if r == nil {
+			switch {
+			case s == nil:
+				return nil, &TransportError{Err: errors.New("websocket connection is not open")}
+			case !s.closed():
+				return s, nil
+			case rerr != nil:
+				return nil, rerr
+			default:
+				return nil, s.err
+			}
+		}
+
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-expired:
+			return nil, errors.Wrapf(ErrTimeout, "connection was not re-established within %v", timeout)
+		}
+	}
+}
+
+// makeRequestResponse issues a request event, waits for its response event and
+// parse the response back. The request is bound by the given context and by
+// the configured request timeout. Any number of requests may be in flight at
+// once; responses are matched to their request by ID.
+//
+// If the request fails with a transport or server error and the client has
+// other addresses, it fails over to the next address and the request is
+// made again.
+func (c *websocketClient) makeRequestResponse(ctx context.Context, req, resp interface{}) error {
+	for attempt := 1; ; attempt++ {
+		s, err := c.getSession(ctx, c.options.WebSocket.RequestTimeout)
+		if err != nil {
+			return err
+		}
+
+		err = c.requestResponse(ctx, s, req, resp)
+		if err == nil || ctx.Err() != nil || !failsOver(err) || attempt >= len(c.endpoints.addresses) {
+			return err
+		}
+		c.failover(s)
+	}
+}
+
+// requestResponse issues a request event on the session, waits for its
+// response event and parse the response back.
+func (c *websocketClient) requestResponse(ctx context.Context, s *session, req, resp interface{}) error {
+	id := requestMeta(req).ID
+	w := s.register(id, 1)
+	defer s.unregister(id)
+
 	// Write to the connection.
-	err := c.connection.WriteJSON(req)
+	err := s.writeJSON(ctx, req)
 	if err != nil {
-		return errors.Wrap(err, "failed to write to connection")
+		return contextError(ctx, newTransportError(err, "failed to write to connection"))
 	}
 
-	return c.readResponse(req, resp)
+	r, err := s.wait(ctx, w, c.options.WebSocket.RequestTimeout)
+	if err != nil {
+		return err
+	}
+	return c.parseResponseMessage(r, req, resp)
 }
 
 // makeRequest issues a request event. It does not attempt to read back a response
 // for the request.
 func (c *websocketClient) makeRequest(req interface{}) error {
-	err := c.connection.WriteJSON(req)
+	s, err := c.getSession(context.Background(), c.options.WebSocket.RequestTimeout)
+	if err != nil {
+		return err
+	}
+
+	err = s.writeJSON(context.Background(), req)
 	if err != nil {
-		return errors.Wrap(err, "failed to issue request")
+		return newTransportError(err, "failed to issue request")
 	}
 	return nil
 }
 
-// readResponse reads the response for a given request.
-func (c *websocketClient) readResponse(req, resp interface{}) error {
-	// Read from the connection.
-	var re scheme.Response
-	err := c.connection.ReadJSON(&re)
-	if err != nil {
-		return errors.Wrap(err, "failed to read response message")
+// streamRequest issues a request event and forwards each of its response events
+// to the stream channel until the stop channel is closed or the context is done.
+// The stream shares the connection with any other requests. If the connection
+// is lost and reconnecting is enabled, the request is re-issued on the new
+// connection and the stream carries on.
+func (c *websocketClient) streamRequest(ctx context.Context, req, resp interface{}, stream chan interface{}, stop chan struct{}) error {
+	defer close(stream)
+
+	// Terminate the stream once the stop channel is closed.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	id := requestMeta(req).ID
+	failovers := 0
+	for {
+		s, err := c.getSession(ctx, 0)
+		if err != nil {
+			return contextError(ctx, err)
+		}
+
+		err = c.subscribe(ctx, s, id, req, resp, stream)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// A server error fails the stream over to the next address, unless
+		// it failed on all of them.
+		if IsServerError(err) && failovers+1 < len(c.endpoints.addresses) {
+			failovers++
+			c.failover(s)
+		}
+
+		// Re-issue the request only if the stream ended because the
+		// connection was lost.
+		if !c.reconnects() || !s.closed() {
+			return err
+		}
 	}
-	return c.parseResponseMessage(re, req, resp)
 }
 
-func (c *websocketClient) streamRequest(req, resp interface{}, stream chan interface{}, stop chan struct{}) error {
-	defer close(stream)
+// subscribe issues a stream request event on the session and forwards its
+// response events to the stream channel until the context is done or the
+// session ends.
+func (c *websocketClient) subscribe(ctx context.Context, s *session, id uint64, req, resp interface{}, stream chan interface{}) error {
+	w := s.register(id, streamBuffer)
+	defer s.unregister(id)
 
-	err := c.connection.WriteJSON(req)
+	err := s.writeJSON(ctx, req)
 	if err != nil {
-		return errors.Wrap(err, "failed to send request")
+		return contextError(ctx, newTransportError(err, "failed to send request"))
 	}
 
 	for {
-		// If the stop channel is closed, terminate the stream.
-		select {
-		case _, open := <-stop:
-			if !open {
-				return nil
-			}
-		default:
-			// Do nothing and continue on. The next iteration of the
-			// loop will check again whether the stream should stop.
-		}
-
-		var response scheme.Response
-		err := c.connection.ReadJSON(&response)
+		response, err := s.wait(ctx, w, 0)
 		if err != nil {
 			return errors.Wrap(err, "failed to read data from stream")
 		}
@@ -576,10 +947,178 @@ func (c *websocketClient) streamRequest(req, resp interface{}, stream chan inter
 			return errors.Wrap(err, "failed to parse response message")
 		}
 
-		stream <- respInst
+		select {
+		case stream <- respInst:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// sessionEnded is called when a session ends. Unless the session was closed
+// by the client, it starts reconnecting if that is enabled.
+func (c *websocketClient) sessionEnded(s *session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session != s || c.reconnecting != nil || s.closing.Load() || !c.reconnects() {
+		return
+	}
+
+	r := newReconnect()
+	c.reconnecting = r
+	go c.reconnect(s, r)
+}
+
+// reconnects reports whether the client re-establishes a lost connection,
+// which it does if reconnecting is enabled or if it has other addresses to
+// fail over to.
+func (c *websocketClient) reconnects() bool {
+	return c.options.WebSocket.Reconnect.Enabled || c.endpoints.multiple()
+}
+
+// failover ends the session, after a request failed on it, and reconnects to
+// the next address. It has no effect if the session was already replaced or
+// is being reconnected.
+func (c *websocketClient) failover(s *session) {
+	c.mu.Lock()
+	if c.session != s || c.reconnecting != nil {
+		c.mu.Unlock()
+		return
+	}
+	r := newReconnect()
+	c.reconnecting = r
+	s.closing.Store(true)
+	c.mu.Unlock()
+
+	s.close()
+	go c.reconnect(s, r)
+}
+
+// reconnect re-establishes the connection of the lost session, waiting with
+// exponential backoff and jitter between attempts. Each attempt tries the
+// addresses after the one of the lost session first. Requests issued while
+// reconnecting wait for it to finish.
+//
+// If reconnecting is not enabled, the client is failing over, and tries each
+// of its addresses once, without waiting.
+func (c *websocketClient) reconnect(lost *session, r *reconnect) {
+	opts := c.options.WebSocket.Reconnect
+	count := opts.Count
+	if !opts.Enabled {
+		count = 1
+	}
+	addresses := c.endpoints.after(lost.address)
+
+	var err error
+	for attempt := uint(0); count == 0 || attempt < count; attempt++ {
+		// Failing over to another address does not wait.
+		wait := time.Duration(0)
+		if attempt > 0 || !c.endpoints.multiple() {
+			wait = backoff(opts.WaitTime, opts.MaxWaitTime, attempt)
+		}
+		select {
+		case <-time.After(wait):
+		case <-r.aborted:
+			c.finishReconnect(lost, r, nil, "", nil)
+			return
+		}
+
+		var conn *websocket.Conn
+		var address string
+		conn, address, err = c.dialAny(context.Background(), addresses)
+		if err == nil {
+			c.finishReconnect(lost, r, conn, address, nil)
+			return
+		}
+	}
+
+	c.finishReconnect(lost, r, nil, "", newTransportError(err, fmt.Sprintf("failed to reconnect after %d attempts", count)))
+}
+
+// finishReconnect installs the new connection, if any, and releases the
+// requests waiting on the reconnect.
+func (c *websocketClient) finishReconnect(lost *session, r *reconnect, conn *websocket.Conn, address string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(r.done)
+
+	c.reconnecting = nil
+
+	// The client was closed or re-opened in the meantime.
+	if c.session != lost {
+		if conn != nil {
+			_ = conn.Close()
+		}
+		return
+	}
+
+	if conn != nil {
+		c.setSession(conn, address)
+	}
+	c.reconnectErr = err
+}
+
+// watchPrimary checks the primary address every health interval while the
+// session is connected to another address, until the client is back on the
+// primary address.
+func (c *websocketClient) watchPrimary(s *session) {
+	ticker := time.NewTicker(c.endpoints.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if c.promote(s) {
+				return
+			}
+		case <-s.done:
+			return
+		}
 	}
 }
 
+// promote replaces the session with a session on the primary address, if it
+// is healthy. Requests in flight on the replaced session are made again on
+// the new one, and its streams are re-issued. It reports whether the session
+// was replaced, by this or by something else.
+func (c *websocketClient) promote(s *session) bool {
+	primary := c.endpoints.primary()
+	conn, err := c.dial(context.Background(), primary)
+	if err != nil {
+		return false
+	}
+
+	candidate := newSession(conn, primary, c.options.WebSocket, c.sessionEnded)
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.WebSocket.RequestTimeout)
+	defer cancel()
+	req := scheme.RequestStatus{
+		EventMeta: scheme.EventMeta{
+			ID:    c.addCounter(),
+			Event: requestStatus,
+		},
+	}
+	if err := c.requestResponse(ctx, candidate, req, new(scheme.Status)); err != nil {
+		candidate.close()
+		return false
+	}
+
+	c.mu.Lock()
+	if c.session != s || c.reconnecting != nil {
+		c.mu.Unlock()
+		candidate.close()
+		return true
+	}
+	c.session = candidate
+	c.endpoints.activate(primary)
+	s.closing.Store(true)
+	c.mu.Unlock()
+
+	_ = s.writeClose(closeTimeout)
+	s.close()
+	return true
+}
+
 func (c *websocketClient) parseResponseMessage(r scheme.Response, req, resp interface{}) error {
 	if r.Event == responseError {
 		var e scheme.Error
@@ -589,20 +1128,17 @@ func (c *websocketClient) parseResponseMessage(r scheme.Response, req, resp inte
 			return errors.Wrap(err, "failed to decode map into a proper scheme")
 		}
 
-		return errors.Errorf(
-			"got a %v error response from synse server at %v, saying %v, with context: %v",
-			e.HTTPCode, e.Timestamp, e.Description, e.Context,
-		)
+		return newAPIError(e)
 	}
 
 	// Verify if the request and response metadata are matched.
-	v := reflect.ValueOf(req)
-	if v.FieldByName("ID").Uint() != r.ID {
-		return errors.Errorf("response id mismatch: %v != %v", v.FieldByName("ID"), r.ID)
+	meta := requestMeta(req)
+	if meta.ID != r.ID {
+		return errors.Errorf("response id mismatch: %v != %v", meta.ID, r.ID)
 	}
 
-	if matchEvent(v.FieldByName("Event").String()) != r.Event {
-		return errors.Errorf("(%v) %v did not match %v", v.FieldByName("Event"), matchEvent(v.FieldByName("Event").String()), r.Event)
+	if matchEvent(meta.Event) != r.Event {
+		return errors.Errorf("(%v) %v did not match %v", meta.Event, matchEvent(meta.Event), r.Event)
 	}
 
 	// Handle successful response.
@@ -614,6 +1150,11 @@ func (c *websocketClient) parseResponseMessage(r scheme.Response, req, resp inte
 	return nil
 }
 
+// requestMeta returns the event metadata of a request event.
+func requestMeta(req interface{}) scheme.EventMeta {
+	return reflect.ValueOf(req).FieldByName("EventMeta").Interface().(scheme.EventMeta)
+}
+
 // matchEvent returns a corresponding response event for a given request event.
 func matchEvent(reqEvent string) string {
 	switch reqEvent {
@@ -1,7 +1,10 @@
 package synse
 
 import (
+	"context"
 	"crypto/tls"
+	"encoding/json"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -46,6 +49,11 @@ func TestNewWebSocketClientV3_defaults(t *testing.T) {
 
 	assert.Equal(t, "localhost:5000", client.GetOptions().Address)
 	assert.Equal(t, 45*time.Second, client.GetOptions().WebSocket.HandshakeTimeout)
+	assert.Equal(t, 30*time.Second, client.GetOptions().WebSocket.RequestTimeout)
+	assert.Equal(t, 30*time.Second, client.GetOptions().WebSocket.PingInterval)
+	assert.Equal(t, 10*time.Second, client.GetOptions().WebSocket.PongTimeout)
+	assert.Equal(t, 10*time.Second, client.GetOptions().WebSocket.WriteTimeout)
+	assert.False(t, client.GetOptions().WebSocket.Reconnect.Enabled)
 	assert.Empty(t, client.GetOptions().TLS.CertFile)
 	assert.Empty(t, client.GetOptions().TLS.KeyFile)
 	assert.False(t, client.GetOptions().TLS.Enabled)
@@ -155,6 +163,532 @@ func TestWebSocketClientV3_Status_500(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestWebSocketClientV3_StatusContext_Timeout(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	// The server reads requests but never responds to them.
+	server.Stream([]string{})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	resp, err := client.StatusContext(ctx)
+	assert.Nil(t, resp)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_StatusContext_CancelKeepsConnection(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	// The first request is answered late, the others right away.
+	server.ServeFunc(func(request []byte) []string {
+		var req scheme.EventMeta
+		if err := json.Unmarshal(request, &req); err != nil {
+			return nil
+		}
+		if req.ID == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return []string{fmt.Sprintf(
+			`{"id":%d,"event":"response/status","data":{"status":"ok","timestamp":"%d"}}`,
+			req.ID, req.ID,
+		)}
+	})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, client.Open())
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = client.StatusContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+
+	// The cancelled request does not affect the connection, and its late
+	// response is discarded.
+	resp, err := client.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resp.Timestamp)
+
+	time.Sleep(250 * time.Millisecond)
+	resp, err = client.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "3", resp.Timestamp)
+}
+
+func TestWebSocketClientV3_ReadStreamContext_Cancelled(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	// The server reads requests but never responds to them.
+	server.Stream([]string{})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	readings := make(chan *scheme.Read)
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- client.ReadStreamContext(ctx, scheme.ReadStreamOptions{}, readings, make(chan struct{}))
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errs:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout: read stream was not terminated by context")
+	}
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_ReadStreamContext_CancelledNotReceiving(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	server.Stream([]string{
+		`{"id":1,"event":"response/reading","data":{"device":"1","type":"state","value":"off"}}`,
+	})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	// The readings are never received, so the stream is blocked on sending
+	// the first one when it is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	readings := make(chan *scheme.Read)
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- client.ReadStreamContext(ctx, scheme.ReadStreamOptions{}, readings, make(chan struct{}))
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errs:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout: read stream was not terminated by context")
+	}
+
+	// Nothing is sent to the out channel after the stream returned.
+	select {
+	case r := <-readings:
+		t.Fatalf("unexpected reading after the stream returned: %v", r)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_Status_RequestTimeout(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	// The server reads requests but never responds to them.
+	server.Stream([]string{})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+		WebSocket: WebSocketOptions{
+			RequestTimeout: 100 * time.Millisecond,
+		},
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	resp, err := client.Status()
+	assert.Nil(t, resp)
+	assert.True(t, IsTimeout(err))
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_ConcurrentRequests(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	// Respond to each request after a delay which is shorter for later
+	// requests, so that the responses arrive out of order.
+	server.ServeFunc(func(request []byte) []string {
+		var req scheme.EventMeta
+		if err := json.Unmarshal(request, &req); err != nil {
+			return nil
+		}
+
+		time.Sleep(time.Duration(50-req.ID) * time.Millisecond)
+		return []string{fmt.Sprintf(
+			`{"id":%d,"event":"response/status","data":{"status":"ok","timestamp":"%d"}}`,
+			req.ID, req.ID,
+		)}
+	})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	timestamps := make(chan string, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Status()
+			assert.NoError(t, err)
+			if resp != nil {
+				timestamps <- resp.Timestamp
+			}
+		}()
+	}
+	wg.Wait()
+	close(timestamps)
+
+	// Every request got its own response.
+	seen := map[string]bool{}
+	for ts := range timestamps {
+		seen[ts] = true
+	}
+	assert.Len(t, seen, 20)
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_RequestDuringStream(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	server.ServeFunc(func(request []byte) []string {
+		var req scheme.EventMeta
+		if err := json.Unmarshal(request, &req); err != nil {
+			return nil
+		}
+
+		switch req.Event {
+		case requestReadStream:
+			var out []string
+			for i := 0; i < 5; i++ {
+				out = append(out, fmt.Sprintf(
+					`{"id":%d,"event":"response/reading","data":{"device":"led","type":"state","value":"on"}}`,
+					req.ID,
+				))
+			}
+			return out
+		default:
+			return []string{fmt.Sprintf(
+				`{"id":%d,"event":"response/version","data":{"version":"3.0.0","api_version":"v3"}}`,
+				req.ID,
+			)}
+		}
+	})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	readings := make(chan *scheme.Read)
+	stop := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- client.ReadStream(scheme.ReadStreamOptions{}, readings, stop)
+	}()
+
+	// Receive a reading so the stream is known to be active.
+	select {
+	case r := <-readings:
+		assert.Equal(t, "led", r.Device)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout: failed getting read stream data from channel")
+	}
+
+	resp, err := client.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, &scheme.Version{Version: "3.0.0", APIVersion: "v3"}, resp)
+
+	close(stop)
+	select {
+	case err := <-errs:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout: read stream was not terminated")
+	}
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_Reconnect(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	server.ServeFunc(func(request []byte) []string {
+		var req scheme.EventMeta
+		if err := json.Unmarshal(request, &req); err != nil {
+			return nil
+		}
+		return []string{fmt.Sprintf(
+			`{"id":%d,"event":"response/status","data":{"status":"ok","timestamp":"2019-03-20T17:37:07Z"}}`,
+			req.ID,
+		)}
+	})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+		WebSocket: WebSocketOptions{
+			Reconnect: ReconnectOptions{
+				Enabled:     true,
+				WaitTime:    10 * time.Millisecond,
+				MaxWaitTime: 50 * time.Millisecond,
+			},
+		},
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	resp, err := client.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp.Status)
+
+	server.DropConnections()
+
+	// The request waits for the connection to be re-established.
+	assert.Eventually(t, func() bool {
+		resp, err := client.Status()
+		return err == nil && resp.Status == "ok"
+	}, 2*time.Second, 20*time.Millisecond)
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_Reconnect_Disabled(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	server.ServeFunc(func(request []byte) []string {
+		var req scheme.EventMeta
+		if err := json.Unmarshal(request, &req); err != nil {
+			return nil
+		}
+		return []string{fmt.Sprintf(
+			`{"id":%d,"event":"response/status","data":{"status":"ok","timestamp":"2019-03-20T17:37:07Z"}}`,
+			req.ID,
+		)}
+	})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	server.DropConnections()
+
+	assert.Eventually(t, func() bool {
+		_, err := client.Status()
+		return IsTransport(err)
+	}, 2*time.Second, 20*time.Millisecond)
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_Reconnect_ResumeStream(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	streams := make(chan scheme.RequestReadStream, 2)
+	server.ServeFunc(func(request []byte) []string {
+		var req scheme.RequestReadStream
+		if err := json.Unmarshal(request, &req); err != nil {
+			return nil
+		}
+		if req.Event != requestReadStream || req.Data.Stop {
+			return nil
+		}
+
+		streams <- req
+		return []string{fmt.Sprintf(
+			`{"id":%d,"event":"response/reading","data":{"device":"led","type":"state","value":"on"}}`,
+			req.ID,
+		)}
+	})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+		WebSocket: WebSocketOptions{
+			Reconnect: ReconnectOptions{
+				Enabled:     true,
+				WaitTime:    10 * time.Millisecond,
+				MaxWaitTime: 50 * time.Millisecond,
+			},
+		},
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	opts := scheme.ReadStreamOptions{
+		Ids: []string{"led"},
+	}
+	readings := make(chan *scheme.Read)
+	stop := make(chan struct{})
+	go func() {
+		_ = client.ReadStream(opts, readings, stop)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-readings:
+			assert.Equal(t, "led", r.Device)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout: failed getting read stream data from channel")
+		}
+
+		// The stream is issued again with its original options.
+		req := <-streams
+		assert.Equal(t, opts, req.Data)
+
+		server.DropConnections()
+	}
+
+	close(stop)
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_Keepalive_DeadConnection(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	server.Stall()
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+		WebSocket: WebSocketOptions{
+			PingInterval: 50 * time.Millisecond,
+			PongTimeout:  50 * time.Millisecond,
+		},
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- client.ReadStream(scheme.ReadStreamOptions{}, make(chan *scheme.Read), make(chan struct{}))
+	}()
+
+	resp, err := client.Status()
+	assert.Nil(t, resp)
+	assert.True(t, IsConnectionDead(err))
+	assert.True(t, IsTransport(err))
+
+	select {
+	case err := <-errs:
+		assert.True(t, IsConnectionDead(err))
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout: read stream was not terminated")
+	}
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
+func TestWebSocketClientV3_Keepalive_Alive(t *testing.T) {
+	server := test.NewWebSocketServerV3()
+	defer server.Close()
+
+	// The server answers pings while it is reading requests.
+	server.Stream([]string{})
+
+	client, err := NewWebSocketClientV3(&Options{
+		Address: server.URL,
+		WebSocket: WebSocketOptions{
+			PingInterval:   20 * time.Millisecond,
+			PongTimeout:    20 * time.Millisecond,
+			RequestTimeout: 200 * time.Millisecond,
+		},
+	})
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
+	err = client.Open()
+	assert.NoError(t, err)
+
+	// The request outlives several ping intervals, so the connection is
+	// only kept alive by the pongs.
+	resp, err := client.Status()
+	assert.Nil(t, resp)
+	assert.True(t, IsTimeout(err))
+	assert.False(t, IsConnectionDead(err))
+
+	err = client.Close()
+	assert.NoError(t, err)
+}
+
 func TestWebSocketClientV3_Version_200(t *testing.T) {
 	in := `
 {